@@ -0,0 +1,12 @@
+package dto
+
+import "github.com/google/uuid"
+
+// Actor is the user a request acts on behalf of.
+type Actor struct {
+	UserID uuid.UUID
+
+	// Privileged tells whether the request may use the privileged permissions of the user,
+	// false for the personal access tokens without the admin scope.
+	Privileged bool
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "github.com/google/uuid"
+
+// AuditContext describes who took a privileged action and the request they took it with.
+type AuditContext struct {
+	ActorID   *uuid.UUID `json:"actorID"`
+	IPAddress string     `json:"ipAddress"`
+	RequestID string     `json:"requestID"`
+}
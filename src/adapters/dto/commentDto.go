@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CommentSnapshotDto is the state of a comment recorded in the audit log, without its replies.
+type CommentSnapshotDto struct {
+	ID        uuid.UUID  `json:"id"`
+	AuthorID  uuid.UUID  `json:"authorID"`
+	Content   string     `json:"content"`
+	IsBest    bool       `json:"isBest"`
+	DeletedAt *time.Time `json:"deletedAt"`
+
+	// PostAuthorID is the author of the post of the comment, who picks its best answer.
+	// It tells the moderation apart and is not recorded.
+	PostAuthorID uuid.UUID `json:"-"`
+}
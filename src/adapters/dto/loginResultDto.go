@@ -0,0 +1,23 @@
+package dto
+
+// LoginResultDto is the outcome of a successful password or second factor check.
+// Either the tokens of the new session are set, or MFAToken is when the user still has to give a second factor,
+// or PasswordResetToken is when the user has to change their password before logging in.
+type LoginResultDto struct {
+	AccessToken  string
+	RefreshToken string
+
+	// MFAToken is a short-lived token to exchange, along with a valid code, for the tokens of a session.
+	MFAToken string
+
+	// MFAEnrollmentRequired is set when the role of the user requires two-factor authentication
+	// but the user has not set up an authenticator yet.
+	MFAEnrollmentRequired bool
+
+	// PasswordResetToken is a short-lived password reset token, set instead of the tokens of a session
+	// when the password of the user must be changed.
+	PasswordResetToken string
+
+	// RecoveryCodes are only set when the login also enabled two-factor authentication.
+	RecoveryCodes []string
+}
@@ -13,6 +13,7 @@ type RoleDto struct {
 	Permission int            `json:"permission"`
 	AdminRole  bool           `json:"adminRole"`
 	ModRole    bool           `json:"modRole"`
+	RequireMFA bool           `json:"requireMFA"`
 	CreatedAt  time.Time      `json:"createdAt"`
 	UpdatedAt  time.Time      `json:"updatedAt"`
 	DeletedAt  gorm.DeletedAt `json:"deletedAt"`
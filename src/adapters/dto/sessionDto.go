@@ -0,0 +1,8 @@
+package dto
+
+// SessionDto describes the device a session is opened from.
+type SessionDto struct {
+	DeviceName string `json:"deviceName"`
+	UserAgent  string `json:"userAgent"`
+	IPAddress  string `json:"ipAddress"`
+}
@@ -14,6 +14,7 @@ type UserDto struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
 	Email       string         `json:"email"`
+	Locale      string         `json:"locale"`
 	Banned      bool           `json:"locked"`
 	Role        RoleDto        `json:"role"`
 	CreatedAt   time.Time      `json:"createdAt"`
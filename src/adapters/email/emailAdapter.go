@@ -2,33 +2,61 @@ package email
 
 import (
 	"fmt"
+	"net/mail"
 	"net/smtp"
+	"time"
 
-	"github.com/Dialosoft/src/app/config"
+	"github.com/Dialosoft/src/pkg/mails"
 )
 
-func SendEmail(to []string, subject, body string, config config.GeneralConfig) error {
-	headers := make(map[string]string)
-	headers["From"] = config.FromAddress
-	headers["To"] = to[0]
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=\"UTF-8\""
-
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
-	}
-	message += "\r\n" + body
+// Transport delivers an email message.
+type Transport interface {
+	// Send delivers the message to its recipients.
+	Send(message mails.Message) error
+}
 
-	auth := smtp.PlainAuth("", config.MailUsername, config.MailPassword, config.SMTPHost)
+// SMTPConfig holds the settings of the SMTP server used to send emails.
+type SMTPConfig struct {
+	Host        string
+	Port        string
+	Username    string
+	Password    string
+	FromAddress string
+}
 
-	addr := fmt.Sprintf("%s:%s", config.SMTPHost, config.SMTPPort)
+// SMTPTransport sends emails through an SMTP server.
+type SMTPTransport struct {
+	config SMTPConfig
+}
 
-	err := smtp.SendMail(addr, auth, config.FromAddress, to, []byte(message))
+// Send implements Transport.
+func (transport *SMTPTransport) Send(message mails.Message) error {
+	raw, err := message.Encode(transport.config.FromAddress, time.Now())
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// the SMTP envelope only takes bare addresses
+	from, err := mail.ParseAddress(transport.config.FromAddress)
+	if err != nil {
+		return err
+	}
+	to := make([]string, 0, len(message.To))
+	for _, recipient := range message.To {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return err
+		}
+		to = append(to, address.Address)
+	}
+
+	auth := smtp.PlainAuth("", transport.config.Username, transport.config.Password, transport.config.Host)
+
+	addr := fmt.Sprintf("%s:%s", transport.config.Host, transport.config.Port)
+
+	return smtp.SendMail(addr, auth, from.Address, to, raw)
+}
+
+func NewSMTPTransport(config SMTPConfig) *SMTPTransport {
+	return &SMTPTransport{config: config}
 }
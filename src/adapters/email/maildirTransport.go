@@ -0,0 +1,53 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Dialosoft/src/pkg/mails"
+)
+
+// MaildirTransport writes every email as a file in a maildir instead of sending it,
+// so the whole flow can be run locally without a mail server. Any mail client
+// that reads maildirs can open the result.
+type MaildirTransport struct {
+	dir         string
+	fromAddress string
+}
+
+// Send implements Transport.
+func (transport *MaildirTransport) Send(message mails.Message) error {
+	raw, err := message.Encode(transport.fromAddress, time.Now())
+	if err != nil {
+		return err
+	}
+
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.dialosoft.eml", time.Now().UnixNano(), hex.EncodeToString(random))
+
+	// written to tmp and moved to new, so readers never see a partial file
+	tmpPath := filepath.Join(transport.dir, "tmp", name)
+	if err := os.WriteFile(tmpPath, raw, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(transport.dir, "new", name))
+}
+
+// NewMaildirTransport returns a MaildirTransport that writes to dir, creating the maildir if needed.
+func NewMaildirTransport(dir string, fromAddress string) (*MaildirTransport, error) {
+	for _, subdir := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, subdir), 0o700); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MaildirTransport{dir: dir, fromAddress: fromAddress}, nil
+}
@@ -0,0 +1,42 @@
+package email
+
+import (
+	"sync"
+
+	"github.com/Dialosoft/src/pkg/mails"
+)
+
+// MemoryTransport keeps every email in memory instead of sending it, for local runs and tests.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []mails.Message
+}
+
+// Send implements Transport.
+func (transport *MemoryTransport) Send(message mails.Message) error {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+
+	transport.messages = append(transport.messages, message)
+	return nil
+}
+
+// Messages returns a copy of the emails sent so far.
+func (transport *MemoryTransport) Messages() []mails.Message {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+
+	return append([]mails.Message(nil), transport.messages...)
+}
+
+// Reset forgets the emails sent so far.
+func (transport *MemoryTransport) Reset() {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+
+	transport.messages = nil
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
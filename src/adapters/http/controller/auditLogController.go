@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+)
+
+type AuditLogController struct {
+	AuditService services.AuditService
+}
+
+func NewAuditLogController(auditService services.AuditService) *AuditLogController {
+	return &AuditLogController{AuditService: auditService}
+}
+
+func (ac *AuditLogController) GetEntries(c fiber.Ctx) error {
+	var query request.AuditLogQuery
+	if err := c.Bind().Query(&query); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse AuditLogQuery in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	page, err := ac.AuditService.GetEntries(query)
+	if err != nil {
+		logger.CaptureError(err, "Error retrieving the audit log", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "OK", page)
+}
+
+func (ac *AuditLogController) Export(c fiber.Ctx) error {
+	var query request.AuditLogQuery
+	if err := c.Bind().Query(&query); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse AuditLogQuery in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if query.Format == "" {
+		query.Format = services.AuditExportCSV
+	}
+
+	var export bytes.Buffer
+	if err := ac.AuditService.Export(query, &export); err != nil {
+		logger.CaptureError(err, "Error exporting the audit log", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	logger.Info("Audit log exported", map[string]interface{}{
+		"format": query.Format,
+		"userID": c.Locals("userID"),
+	})
+
+	// the content type follows the extension of the file
+	c.Attachment(fmt.Sprintf("audit-log-%s.%s", time.Now().UTC().Format("20060102T150405Z"), query.Format))
+
+	return c.Send(export.Bytes())
+}
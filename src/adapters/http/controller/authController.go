@@ -2,15 +2,21 @@ package controller
 
 import (
 	"errors"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/Dialosoft/src/adapters/dto"
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
 	"github.com/Dialosoft/src/domain/services"
 	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/passwords"
 	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -25,6 +31,9 @@ func NewAuthController(authService services.AuthService) *AuthController {
 func (ac *AuthController) Register(c fiber.Ctx) error {
 	var req request.RegisterRequest
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to parse RegisterRequest in Controller", map[string]interface{}{
 			"request-tried": req,
 			"route":         c.Path(),
@@ -36,10 +45,24 @@ func (ac *AuthController) Register(c fiber.Ctx) error {
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password,
+		Locale:   req.Locale,
+	}
+	if userDto.Locale == "" {
+		userDto.Locale = c.Get(fiber.HeaderAcceptLanguage)
 	}
 
-	userID, token, refreshToken, err := ac.AuthService.Register(userDto)
+	userID, token, refreshToken, err := ac.AuthService.Register(userDto, newSessionDto(c, req.DeviceName))
 	if err != nil {
+		var violation *passwords.Violation
+		if errors.As(err, &violation) {
+			return response.ErrValidation(c, validation.Errors{validation.PasswordViolation("password", violation)})
+		}
+		switch err {
+		case errorsUtils.ErrUsernameReserved:
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+		case errorsUtils.ErrUsernameInvalid:
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+		}
 		if errors.Is(err, gorm.ErrDuplicatedKey) ||
 			strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
 			logger.Warn("Duplicate key error during registration", map[string]interface{}{
@@ -74,6 +97,9 @@ func (ac *AuthController) Register(c fiber.Ctx) error {
 func (ac *AuthController) Login(c fiber.Ctx) error {
 	var req request.LoginRequest
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to parse LoginRequest in Controller", map[string]interface{}{
 			"request-tried": req,
 			"route":         c.Path(),
@@ -82,40 +108,72 @@ func (ac *AuthController) Login(c fiber.Ctx) error {
 		return response.ErrBadRequest(c)
 	}
 
-	accessToken, refreshToken, err := ac.AuthService.Login(req.Username, req.Password)
+	identifier := req.Identifier
+	if identifier == "" {
+		identifier = req.Username
+	}
+
+	result, err := ac.AuthService.Login(identifier, req.Password, newSessionDto(c, req.DeviceName))
 	if err != nil {
+		var blocked *errorsUtils.LoginBlockedError
+		if errors.As(err, &blocked) {
+			logger.Warn("Login refused by brute-force protection", map[string]interface{}{
+				"identifier": identifier,
+				"error":      err.Error(),
+				"route":      c.Path(),
+				"method":     c.Method(),
+			})
+			return loginBlockedResponse(c, blocked)
+		}
+		var banned *errorsUtils.BannedError
+		if errors.As(err, &banned) {
+			return response.ErrBanned(c, banned.Reason, banned.ExpiresAt)
+		}
 		if err == errorsUtils.ErrUnauthorizedAcces || err == gorm.ErrRecordNotFound {
 			logger.Warn("Unauthorized login attempt", map[string]interface{}{
-				"username": req.Username,
-				"error":    err.Error(),
-				"route":    c.Path(),
-				"method":   c.Method(),
+				"identifier": identifier,
+				"error":      err.Error(),
+				"route":      c.Path(),
+				"method":     c.Method(),
 			})
 			return response.ErrUnauthorized(c)
 		}
 		logger.CaptureError(err, "Error during user login", map[string]interface{}{
-			"username": req.Username,
-			"route":    c.Path(),
-			"method":   c.Method(),
+			"identifier": identifier,
+			"route":      c.Path(),
+			"method":     c.Method(),
 		})
 		return response.ErrInternalServer(c)
 	}
 
+	if result.MFAToken != "" {
+		logger.Info("Login pending a second factor", map[string]interface{}{
+			"identifier": identifier,
+			"route":      c.Path(),
+			"method":     c.Method(),
+		})
+		return response.Standard(c, "Two-factor authentication required", mapper.LoginResultDtoToLoginResponse(result))
+	}
+
+	if result.PasswordResetToken != "" {
+		return response.Standard(c, "The password must be changed, reset it with the token given", mapper.LoginResultDtoToLoginResponse(result))
+	}
+
 	logger.Info("User logged in successfully", map[string]interface{}{
-		"username": req.Username,
-		"route":    c.Path(),
-		"method":   c.Method(),
+		"identifier": identifier,
+		"route":      c.Path(),
+		"method":     c.Method(),
 	})
 
-	return response.Standard(c, "Successfully logged in", response.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	})
+	return response.Standard(c, "Successfully logged in", mapper.LoginResultDtoToLoginResponse(result))
 }
 
 func (ac *AuthController) RefreshToken(c fiber.Ctx) error {
 	var req request.RefreshToken
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to parse RefreshToken request in Controller", map[string]interface{}{
 			"request-tried": req,
 			"route":         c.Path(),
@@ -124,8 +182,22 @@ func (ac *AuthController) RefreshToken(c fiber.Ctx) error {
 		return response.ErrBadRequest(c)
 	}
 
-	accessToken, err := ac.AuthService.RefreshToken(req.Refresh)
+	accessToken, refreshToken, err := ac.AuthService.RefreshToken(req.Refresh)
 	if err != nil {
+		var banned *errorsUtils.BannedError
+		if errors.As(err, &banned) {
+			return response.ErrBanned(c, banned.Reason, banned.ExpiresAt)
+		}
+		if err == errorsUtils.ErrMFAEnrollmentRequired {
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusForbidden)
+		}
+		if err == errorsUtils.ErrRefreshTokenReused {
+			logger.Warn("Reused refresh token, session revoked", map[string]interface{}{
+				"route":  c.Path(),
+				"method": c.Method(),
+			})
+			return response.PersonalizedErr(c, "Refresh Token has already been used, log in again", fiber.StatusUnauthorized)
+		}
 		if err == errorsUtils.ErrUnauthorizedAcces || err == gorm.ErrRecordNotFound ||
 			err == errorsUtils.ErrRefreshTokenExpiredOrInvalid || err == errorsUtils.ErrNotFound {
 			logger.Warn("Invalid or expired refresh token", map[string]interface{}{
@@ -150,6 +222,343 @@ func (ac *AuthController) RefreshToken(c fiber.Ctx) error {
 	})
 
 	return response.Standard(c, "successfully refreshed", fiber.Map{
-		"accessToken": accessToken,
+		"accessToken":  accessToken,
+		"refreshToken": refreshToken,
+	})
+}
+
+func (ac *AuthController) Logout(c fiber.Ctx) error {
+	var req request.RefreshToken
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse Logout request in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := ac.AuthService.Logout(req.Refresh); err != nil {
+		if err == errorsUtils.ErrRefreshTokenExpiredOrInvalid || err == gorm.ErrRecordNotFound {
+			logger.Warn("Logout with invalid refresh token", map[string]interface{}{
+				"error":  err.Error(),
+				"route":  c.Path(),
+				"method": c.Method(),
+			})
+			return response.ErrUnauthorized(c)
+		}
+		logger.CaptureError(err, "Error during logout", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "Successfully logged out", nil)
+}
+
+func (ac *AuthController) LogoutAll(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := ac.AuthService.LogoutAll(userUUID); err != nil {
+		logger.CaptureError(err, "Error during logout of all sessions", map[string]interface{}{
+			"userID": userUUID.String(),
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "Successfully logged out of all sessions", nil)
+}
+
+func (ac *AuthController) GetSessions(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	sessionUUID, _ := getSessionIDFromLocals(c)
+
+	sessions, err := ac.AuthService.GetSessions(userUUID, sessionUUID)
+	if err != nil {
+		logger.CaptureError(err, "Error retrieving user sessions", map[string]interface{}{
+			"userID": userUUID.String(),
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "OK", sessions)
+}
+
+func (ac *AuthController) RevokeSession(c fiber.Ctx) error {
+	sessionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := ac.AuthService.RevokeSession(userUUID, sessionUUID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return response.ErrNotFound(c)
+		}
+		logger.CaptureError(err, "Error revoking session", map[string]interface{}{
+			"userID":    userUUID.String(),
+			"sessionID": sessionUUID.String(),
+			"route":     c.Path(),
+			"method":    c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "Session revoked", nil)
+}
+
+func (ac *AuthController) ForgotPassword(c fiber.Ctx) error {
+	var req request.ForgotPasswordRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse ForgotPassword request in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if req.Email == "" {
+		return response.ErrEmptyParametersOrArguments(c)
+	}
+
+	if err := ac.AuthService.ForgotPassword(req.Email); err != nil {
+		logger.CaptureError(err, "Error during forgot password", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	// the same answer is given whether the email is registered or not
+	return response.Standard(c, "If the email is registered, a reset link has been sent", nil)
+}
+
+func (ac *AuthController) ResetPassword(c fiber.Ctx) error {
+	var req request.ResetPasswordRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse ResetPassword request in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := ac.AuthService.ResetPassword(req.Token, req.Password); err != nil {
+		var violation *passwords.Violation
+		if errors.As(err, &violation) {
+			return response.ErrValidation(c, validation.Errors{validation.PasswordViolation("password", violation)})
+		}
+		switch err {
+		case errorsUtils.ErrActionTokenInvalid:
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+		case errorsUtils.ErrParameterCannotBeNull:
+			return response.ErrEmptyParametersOrArguments(c)
+		}
+		logger.CaptureError(err, "Error during password reset", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "Password successfully reset", nil)
+}
+
+func (ac *AuthController) VerifyEmail(c fiber.Ctx) error {
+	var req request.VerifyEmailRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse VerifyEmail request in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := ac.AuthService.VerifyEmail(req.Token); err != nil {
+		if err == errorsUtils.ErrActionTokenInvalid {
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+		}
+		logger.CaptureError(err, "Error during email verification", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "Email successfully verified", nil)
+}
+
+func (ac *AuthController) ResendVerificationEmail(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := ac.AuthService.ResendVerificationEmail(userUUID); err != nil {
+		switch err {
+		case errorsUtils.ErrEmailAlreadyVerified:
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+		case gorm.ErrRecordNotFound:
+			return response.ErrNotFound(c)
+		}
+		logger.CaptureError(err, "Error resending verification email", map[string]interface{}{
+			"userID": userUUID.String(),
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "Verification email sent", nil)
+}
+
+func (ac *AuthController) ChangePassword(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+	sessionUUID, _ := getSessionIDFromLocals(c)
+
+	var req request.ChangePasswordRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse ChangePassword request in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := ac.AuthService.ChangePassword(userUUID, sessionUUID, req.CurrentPassword, req.NewPassword); err != nil {
+		var violation *passwords.Violation
+		if errors.As(err, &violation) {
+			return response.ErrValidation(c, validation.Errors{validation.PasswordViolation("newPassword", violation)})
+		}
+		return ac.handleAccountChangeError(c, err, "Error changing password")
+	}
+
+	return response.Standard(c, "Password successfully changed, the other sessions have been logged out", nil)
+}
+
+func (ac *AuthController) ChangeEmail(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+	sessionUUID, _ := getSessionIDFromLocals(c)
+
+	var req request.ChangeEmailRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse ChangeEmail request in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := ac.AuthService.ChangeEmail(userUUID, sessionUUID, req.CurrentPassword, req.NewEmail); err != nil {
+		return ac.handleAccountChangeError(c, err, "Error requesting email change")
+	}
+
+	return response.Standard(c, "Confirmation email sent to the new address, the other sessions have been logged out", nil)
+}
+
+func (ac *AuthController) ConfirmEmailChange(c fiber.Ctx) error {
+	var req request.ConfirmEmailChangeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse ConfirmEmailChange request in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := ac.AuthService.ConfirmEmailChange(req.Token); err != nil {
+		if err == errorsUtils.ErrActionTokenInvalid {
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+		}
+		return ac.handleAccountChangeError(c, err, "Error confirming email change")
+	}
+
+	return response.Standard(c, "Email successfully changed", nil)
+}
+
+// handleAccountChangeError maps the errors of the password and email changes to a response.
+func (ac *AuthController) handleAccountChangeError(c fiber.Ctx, err error, message string) error {
+	var blocked *errorsUtils.LoginBlockedError
+	if errors.As(err, &blocked) {
+		return loginBlockedResponse(c, blocked)
+	}
+
+	switch err {
+	case errorsUtils.ErrCurrentPasswordInvalid:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusForbidden)
+	case errorsUtils.ErrEmailInUse:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	case errorsUtils.ErrEmailUnchanged:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+	case gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
 	})
+	return response.ErrInternalServer(c)
+}
+
+// loginBlockedResponse answers a login refused by the brute-force protection, telling the client when to retry:
+// 423 if the account is locked, 429 otherwise.
+func loginBlockedResponse(c fiber.Ctx, blocked *errorsUtils.LoginBlockedError) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+
+	if errors.Is(blocked, errorsUtils.ErrAccountLocked) {
+		return response.PersonalizedErr(c, blocked.Error(), fiber.StatusLocked)
+	}
+	return response.PersonalizedErr(c, blocked.Error(), fiber.StatusTooManyRequests)
+}
+
+// newSessionDto describes the device a request comes from, used to label the session opened for it.
+func newSessionDto(c fiber.Ctx, deviceName string) dto.SessionDto {
+	return dto.SessionDto{
+		DeviceName: deviceName,
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		IPAddress:  c.IP(),
+	}
 }
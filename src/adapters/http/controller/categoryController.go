@@ -4,10 +4,13 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/Dialosoft/src/adapters/dto"
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/domain/services"
 	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -15,10 +18,11 @@ import (
 
 type CategoryController struct {
 	CategoryService services.CategoryService
+	AuditService    services.AuditService
 }
 
-func NewCategoryController(categoryService services.CategoryService) *CategoryController {
-	return &CategoryController{CategoryService: categoryService}
+func NewCategoryController(categoryService services.CategoryService, auditService services.AuditService) *CategoryController {
+	return &CategoryController{CategoryService: categoryService, AuditService: auditService}
 }
 
 func (ac *CategoryController) GetAllCategories(c fiber.Ctx) error {
@@ -161,6 +165,9 @@ func (ac *CategoryController) GetCategoryByName(c fiber.Ctx) error {
 func (ac *CategoryController) CreateNewCategory(c fiber.Ctx) error {
 	var req request.NewCategory
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to parse CreateNewCategory request", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -169,11 +176,7 @@ func (ac *CategoryController) CreateNewCategory(c fiber.Ctx) error {
 	}
 
 	if req.Name == nil {
-		logger.Error("Missing parameters in CreateNewCategory request", map[string]interface{}{
-			"route":  c.Path(),
-			"method": c.Method(),
-		})
-		return response.ErrEmptyParametersOrArguments(c)
+		return response.ErrValidation(c, validation.Errors{validation.Required("name")})
 	}
 
 	categoryUUID, err := ac.CategoryService.CreateCategory(req)
@@ -201,6 +204,9 @@ func (ac *CategoryController) CreateNewCategory(c fiber.Ctx) error {
 		"method":     c.Method(),
 	})
 
+	ac.AuditService.Record(newAuditContext(c), models.AuditCategoryCreate, models.AuditTargetCategory, categoryUUID.String(),
+		nil, ac.categorySnapshot(categoryUUID))
+
 	return response.StandardCreated(c, "CREATED", fiber.Map{
 		"id": categoryUUID.String(),
 	})
@@ -229,6 +235,9 @@ func (ac *CategoryController) UpdateCategory(c fiber.Ctx) error {
 	}
 
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to parse UpdateCategory request", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -236,6 +245,8 @@ func (ac *CategoryController) UpdateCategory(c fiber.Ctx) error {
 		return response.ErrBadRequest(c)
 	}
 
+	before := ac.categorySnapshot(categoryUUID)
+
 	err = ac.CategoryService.UpdateCategory(categoryUUID, req)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -260,6 +271,8 @@ func (ac *CategoryController) UpdateCategory(c fiber.Ctx) error {
 		"method":     c.Method(),
 	})
 
+	ac.AuditService.Record(newAuditContext(c), models.AuditCategoryUpdate, models.AuditTargetCategory, id, before, ac.categorySnapshot(categoryUUID))
+
 	return response.Standard(c, "UPDATED", nil)
 }
 
@@ -276,6 +289,8 @@ func (ac *CategoryController) DeleteCategory(c fiber.Ctx) error {
 		return response.ErrUUIDParse(c)
 	}
 
+	before := ac.categorySnapshot(categoryUUID)
+
 	if err = ac.CategoryService.DeleteCategory(categoryUUID); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("Category not found for deletion", map[string]interface{}{
@@ -299,6 +314,8 @@ func (ac *CategoryController) DeleteCategory(c fiber.Ctx) error {
 		"method":     c.Method(),
 	})
 
+	ac.AuditService.Record(newAuditContext(c), models.AuditCategoryDelete, models.AuditTargetCategory, id, before, nil)
+
 	return response.Standard(c, "DELETED", nil)
 }
 
@@ -337,5 +354,17 @@ func (ac *CategoryController) RestoreCategory(c fiber.Ctx) error {
 		"method":     c.Method(),
 	})
 
+	ac.AuditService.Record(newAuditContext(c), models.AuditCategoryRestore, models.AuditTargetCategory, id, nil, ac.categorySnapshot(categoryUUID))
+
 	return response.Standard(c, "RESTORED", nil)
 }
+
+// categorySnapshot retrieves a category for the audit log, nil if it cannot be found.
+func (ac *CategoryController) categorySnapshot(categoryID uuid.UUID) *dto.CategoryDto {
+	category, err := ac.CategoryService.GetCategoryByID(categoryID)
+	if err != nil {
+		return nil
+	}
+
+	return category
+}
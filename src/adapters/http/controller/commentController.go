@@ -0,0 +1,361 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommentController struct {
+	CommentService services.CommentService
+	AuditService   services.AuditService
+}
+
+func NewCommentController(commentService services.CommentService, auditService services.AuditService) *CommentController {
+	return &CommentController{CommentService: commentService, AuditService: auditService}
+}
+
+func (cc *CommentController) GetCommentThread(c fiber.Ctx) error {
+	limit := c.Query("limit")
+	offset := c.Query("offset")
+
+	postUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	if limit == "" {
+		limit = "10"
+	}
+	if offset == "" {
+		offset = "0"
+	}
+
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt <= 0 {
+		return response.ErrBadRequest(c)
+	}
+
+	offsetInt, err := strconv.Atoi(offset)
+	if err != nil || offsetInt < 0 {
+		return response.ErrBadRequest(c)
+	}
+
+	viewerUUID, _ := getUserIDFromLocals(c)
+
+	thread, err := cc.CommentService.GetCommentThread(postUUID, viewerUUID, limitInt, offsetInt)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return response.ErrNotFound(c)
+		}
+		logger.CaptureError(err, "Error retrieving comment thread", map[string]interface{}{
+			"postID": postUUID.String(),
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "OK", thread)
+}
+
+func (cc *CommentController) GetCommentByID(c fiber.Ctx) error {
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	viewerUUID, _ := getUserIDFromLocals(c)
+
+	comment, err := cc.CommentService.GetCommentByID(commentUUID, viewerUUID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return response.ErrNotFound(c)
+		}
+		logger.CaptureError(err, "Error retrieving comment by ID", map[string]interface{}{
+			"commentID": commentUUID.String(),
+			"route":     c.Path(),
+			"method":    c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	return response.Standard(c, "OK", comment)
+}
+
+func (cc *CommentController) CreateComment(c fiber.Ctx) error {
+	var req request.NewComment
+
+	postUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to bind request for creating comment", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	comment, err := cc.CommentService.CreateComment(postUUID, actor, req)
+	if err != nil {
+		return cc.handleCommentError(c, err, "Error creating comment")
+	}
+
+	logger.Info("Comment created successfully", map[string]interface{}{
+		"commentID": comment.ID.String(),
+		"postID":    postUUID.String(),
+		"route":     c.Path(),
+		"method":    c.Method(),
+	})
+
+	return response.StandardCreated(c, "CREATED", comment)
+}
+
+func (cc *CommentController) UpdateComment(c fiber.Ctx) error {
+	var req request.UpdateComment
+
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to bind request for updating comment", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := cc.CommentService.UpdateComment(commentUUID, actor, req.Content); err != nil {
+		return cc.handleCommentError(c, err, "Error updating comment")
+	}
+
+	return response.Standard(c, "UPDATED", nil)
+}
+
+func (cc *CommentController) DeleteComment(c fiber.Ctx) error {
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	before := cc.commentSnapshot(commentUUID)
+
+	if err := cc.CommentService.DeleteComment(commentUUID, actor); err != nil {
+		return cc.handleCommentError(c, err, "Error deleting comment")
+	}
+
+	logger.Info("Comment deleted successfully", map[string]interface{}{
+		"commentID": commentUUID.String(),
+		"userID":    actor.UserID.String(),
+		"route":     c.Path(),
+		"method":    c.Method(),
+	})
+
+	cc.recordModeration(c, actor, models.AuditCommentDelete, commentUUID, before, before, nil)
+
+	return response.Standard(c, "DELETED", nil)
+}
+
+func (cc *CommentController) RestoreComment(c fiber.Ctx) error {
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := cc.CommentService.RestoreComment(commentUUID, actor); err != nil {
+		return cc.handleCommentError(c, err, "Error restoring comment")
+	}
+
+	logger.Info("Comment restored successfully", map[string]interface{}{
+		"commentID": commentUUID.String(),
+		"userID":    actor.UserID.String(),
+		"route":     c.Path(),
+		"method":    c.Method(),
+	})
+
+	after := cc.commentSnapshot(commentUUID)
+	cc.recordModeration(c, actor, models.AuditCommentRestore, commentUUID, after, nil, after)
+
+	return response.Standard(c, "RESTORED", nil)
+}
+
+func (cc *CommentController) VoteComment(c fiber.Ctx) error {
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := cc.CommentService.VoteComment(commentUUID, userUUID); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return response.PersonalizedErr(c, "You already voted this comment", fiber.StatusConflict)
+		}
+		return cc.handleCommentError(c, err, "Error voting comment")
+	}
+
+	return response.Standard(c, "VOTED", nil)
+}
+
+func (cc *CommentController) UnvoteComment(c fiber.Ctx) error {
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := cc.CommentService.UnvoteComment(commentUUID, userUUID); err != nil {
+		return cc.handleCommentError(c, err, "Error removing comment vote")
+	}
+
+	return response.Standard(c, "UNVOTED", nil)
+}
+
+func (cc *CommentController) MarkBestComment(c fiber.Ctx) error {
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	before := cc.commentSnapshot(commentUUID)
+
+	if err := cc.CommentService.MarkBestComment(commentUUID, actor); err != nil {
+		return cc.handleCommentError(c, err, "Error marking best comment")
+	}
+
+	logger.Info("Comment marked as best answer", map[string]interface{}{
+		"commentID": commentUUID.String(),
+		"userID":    actor.UserID.String(),
+		"route":     c.Path(),
+		"method":    c.Method(),
+	})
+
+	cc.recordModeration(c, actor, models.AuditCommentBest, commentUUID, before, before, cc.commentSnapshot(commentUUID))
+
+	return response.Standard(c, "UPDATED", nil)
+}
+
+func (cc *CommentController) UnmarkBestComment(c fiber.Ctx) error {
+	commentUUID, err := uuid.Parse(c.Params("commentID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	before := cc.commentSnapshot(commentUUID)
+
+	if err := cc.CommentService.UnmarkBestComment(commentUUID, actor); err != nil {
+		return cc.handleCommentError(c, err, "Error unmarking best comment")
+	}
+
+	cc.recordModeration(c, actor, models.AuditCommentUnbest, commentUUID, before, before, cc.commentSnapshot(commentUUID))
+
+	return response.Standard(c, "UPDATED", nil)
+}
+
+// commentSnapshot retrieves a comment for the audit log, nil if it cannot be found.
+func (cc *CommentController) commentSnapshot(commentID uuid.UUID) *dto.CommentSnapshotDto {
+	comment, err := cc.CommentService.GetCommentSnapshot(commentID)
+	if err != nil {
+		return nil
+	}
+
+	return comment
+}
+
+// recordModeration records an action on the comment in the audit log when the actor is not the author
+// of the comment, known from one of its snapshots, as only moderators act on the comments of others.
+// The author of the post picks its best answer, which is not moderation either.
+func (cc *CommentController) recordModeration(c fiber.Ctx, actor dto.Actor, action string, commentID uuid.UUID,
+	comment *dto.CommentSnapshotDto, before interface{}, after interface{}) {
+	if comment == nil || comment.AuthorID == actor.UserID {
+		return
+	}
+	if (action == models.AuditCommentBest || action == models.AuditCommentUnbest) && comment.PostAuthorID == actor.UserID {
+		return
+	}
+
+	cc.AuditService.Record(newAuditContext(c), action, models.AuditTargetComment, commentID.String(), before, after)
+}
+
+func (cc *CommentController) handleCommentError(c fiber.Ctx, err error, message string) error {
+	switch err {
+	case gorm.ErrRecordNotFound, errorsUtils.ErrCommentNotFound:
+		return response.ErrNotFound(c)
+	case errorsUtils.ErrUserUnauthorized:
+		return response.ErrForbidden(c)
+	case errorsUtils.ErrInvalidUUID:
+		return response.ErrUUIDParse(c)
+	case errorsUtils.ErrCommentContentInvalid, errorsUtils.ErrCommentParentMismatch:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+	case errorsUtils.ErrCommentParentDeleted:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	case errorsUtils.ErrPostLocked:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusForbidden)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
@@ -6,9 +6,11 @@ import (
 
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/domain/services"
 	"github.com/Dialosoft/src/pkg/utils/devconfig"
 	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -16,10 +18,11 @@ import (
 
 type ForumController struct {
 	ForumService services.ForumService
+	AuditService services.AuditService
 }
 
-func NewForumController(forumService services.ForumService) *ForumController {
-	return &ForumController{ForumService: forumService}
+func NewForumController(forumService services.ForumService, auditService services.AuditService) *ForumController {
+	return &ForumController{ForumService: forumService, AuditService: auditService}
 }
 
 func (fc *ForumController) GetAllForums(c fiber.Ctx) error {
@@ -177,6 +180,9 @@ func (fc *ForumController) GetForumsByCategoryIDAndAllowed(c fiber.Ctx) error {
 func (fc *ForumController) CreateForum(c fiber.Ctx) error {
 	var req request.NewForum
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to parse CreateForum request", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -213,13 +219,26 @@ func (fc *ForumController) CreateForum(c fiber.Ctx) error {
 		"method":  c.Method(),
 	})
 
+	fc.AuditService.Record(newAuditContext(c), models.AuditForumCreate, models.AuditTargetForum, forumUUID.String(),
+		nil, fc.forumSnapshot(forumUUID))
+
 	return response.StandardCreated(c, "CREATED", fiber.Map{
 		"id": forumUUID.String(),
 	})
 }
 
 func (fc *ForumController) UpdateForum(c fiber.Ctx) error {
-	var req request.NewForum
+	var req request.UpdateForum
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse UpdateForum request", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
 
 	id := c.Params("id")
 	if id == "" {
@@ -240,6 +259,8 @@ func (fc *ForumController) UpdateForum(c fiber.Ctx) error {
 		return response.ErrUUIDParse(c)
 	}
 
+	before := fc.forumSnapshot(forumUUID)
+
 	err = fc.ForumService.UpdateForum(forumUUID, req)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -264,6 +285,8 @@ func (fc *ForumController) UpdateForum(c fiber.Ctx) error {
 		"method":  c.Method(),
 	})
 
+	fc.AuditService.Record(newAuditContext(c), models.AuditForumUpdate, models.AuditTargetForum, id, before, fc.forumSnapshot(forumUUID))
+
 	return response.Standard(c, "UPDATED", nil)
 }
 
@@ -280,6 +303,8 @@ func (fc *ForumController) DeleteForum(c fiber.Ctx) error {
 		return response.ErrUUIDParse(c)
 	}
 
+	before := fc.forumSnapshot(forumUUID)
+
 	if err = fc.ForumService.DeleteForum(forumUUID); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("Forum not found for deletion", map[string]interface{}{
@@ -303,6 +328,8 @@ func (fc *ForumController) DeleteForum(c fiber.Ctx) error {
 		"method":  c.Method(),
 	})
 
+	fc.AuditService.Record(newAuditContext(c), models.AuditForumDelete, models.AuditTargetForum, id, before, nil)
+
 	return response.Standard(c, "DELETED", nil)
 }
 
@@ -341,5 +368,17 @@ func (fc *ForumController) RestoreForum(c fiber.Ctx) error {
 		"method":  c.Method(),
 	})
 
+	fc.AuditService.Record(newAuditContext(c), models.AuditForumRestore, models.AuditTargetForum, id, nil, fc.forumSnapshot(forumUUID))
+
 	return response.Standard(c, "RESTORED", nil)
 }
+
+// forumSnapshot retrieves a forum for the audit log, nil if it cannot be found.
+func (fc *ForumController) forumSnapshot(forumID uuid.UUID) *response.ForumResponse {
+	forum, err := fc.ForumService.GetForumByID(forumID)
+	if err != nil {
+		return nil
+	}
+
+	return &forum
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"slices"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/google/uuid"
+)
+
+// getUserIDFromLocals returns the ID of the authenticated user stored in the
+// request context by SecurityMiddleware.GetAndVerifyAccessToken.
+func getUserIDFromLocals(c fiber.Ctx) (uuid.UUID, bool) {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return uuid.UUID{}, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+
+	return userUUID, true
+}
+
+// getActorFromLocals returns the authenticated user of the request and whether it may use
+// privileged permissions, which a personal access token needs the admin scope for.
+func getActorFromLocals(c fiber.Ctx) (dto.Actor, bool) {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return dto.Actor{}, false
+	}
+
+	actor := dto.Actor{UserID: userUUID, Privileged: true}
+	if tokenID, _ := c.Locals("personalTokenID").(string); tokenID != "" {
+		scopes, _ := c.Locals("personalTokenScopes").([]string)
+		actor.Privileged = slices.Contains(scopes, models.ScopeAdmin)
+	}
+
+	return actor, true
+}
+
+// getSessionIDFromLocals returns the ID of the session the access token of the
+// request was issued for, stored in the request context by SecurityMiddleware.GetAndVerifyAccessToken.
+func getSessionIDFromLocals(c fiber.Ctx) (uuid.UUID, bool) {
+	sessionID, ok := c.Locals("sessionID").(string)
+	if !ok || sessionID == "" {
+		return uuid.UUID{}, false
+	}
+
+	sessionUUID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+
+	return sessionUUID, true
+}
+
+// newAuditContext describes the user and the request a privileged action is taken with, for the audit log.
+func newAuditContext(c fiber.Ctx) dto.AuditContext {
+	auditContext := dto.AuditContext{
+		IPAddress: c.IP(),
+		RequestID: requestid.FromContext(c),
+	}
+	if userUUID, ok := getUserIDFromLocals(c); ok {
+		auditContext.ActorID = &userUUID
+	}
+
+	return auditContext
+}
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"time"
+
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
 	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ManagementController struct {
@@ -16,11 +22,18 @@ type ManagementController struct {
 	UserService     services.UserService
 	AuthService     services.AuthService
 	CacheService    services.CacheService
+
+	LoginThrottleService services.LoginThrottleService
+	BanService           services.BanService
+	AuditService         services.AuditService
 }
 
 func (mc *ManagementController) ChangeUserRole(c fiber.Ctx) error {
 	var req request.ChangeUserRole
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to bind ChangeUserRole request", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -49,6 +62,19 @@ func (mc *ManagementController) ChangeUserRole(c fiber.Ctx) error {
 		return response.ErrUUIDParse(c)
 	}
 
+	previousUser, err := mc.UserService.GetUserResponseByID(userUUID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return response.ErrNotFound(c)
+		}
+		logger.CaptureError(err, "Failed to find user to change the role of", map[string]interface{}{
+			"userUUID": userUUID,
+			"route":    c.Path(),
+			"method":   c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
 	newUserRequest := request.NewUser{
 		RoleID: &req.RoleID,
 	}
@@ -64,10 +90,9 @@ func (mc *ManagementController) ChangeUserRole(c fiber.Ctx) error {
 		return response.ErrInternalServer(c)
 	}
 
-	// Retrieve the refresh token
-	refreshToken, err := mc.CacheService.GetRefreshTokenByID(userUUID)
-	if err != nil {
-		logger.CaptureError(err, "Failed to retrieve refresh token for user", map[string]interface{}{
+	// Revoke every session and personal access token, the new role applies to the credentials issued afterwards
+	if err := mc.AuthService.LogoutAll(userUUID); err != nil {
+		logger.CaptureError(err, "Failed to revoke user sessions", map[string]interface{}{
 			"userUUID": userUUID,
 			"route":    c.Path(),
 			"method":   c.Method(),
@@ -75,27 +100,198 @@ func (mc *ManagementController) ChangeUserRole(c fiber.Ctx) error {
 		return response.ErrInternalServer(c)
 	}
 
-	// Invalidate the refresh token
-	if err := mc.CacheService.InvalidateRefreshToken(refreshToken); err != nil {
-		logger.CaptureError(err, "Failed to invalidate refresh token", map[string]interface{}{
-			"userUUID":     userUUID,
-			"refreshToken": refreshToken,
-			"route":        c.Path(),
-			"method":       c.Method(),
-		})
-		return response.ErrInternalServer(c)
-	}
-
-	logger.Info("User role updated and refresh token invalidated successfully", map[string]interface{}{
+	logger.Info("User role updated and sessions revoked successfully", map[string]interface{}{
 		"userUUID": userUUID,
 		"roleID":   req.RoleID,
 		"route":    c.Path(),
 		"method":   c.Method(),
 	})
 
+	mc.AuditService.Record(newAuditContext(c), models.AuditUserRoleChange, models.AuditTargetUser, userUUID.String(),
+		fiber.Map{"roleID": previousUser.Role.ID}, fiber.Map{"roleID": req.RoleID})
+
 	return response.Standard(c, "UPDATED", nil)
 }
 
+func (mc *ManagementController) UnlockLogin(c fiber.Ctx) error {
+	var req request.UnlockLogin
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to bind UnlockLogin request", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if req.UserID == "" && req.IPAddress == "" {
+		return response.ErrEmptyParametersOrArguments(c)
+	}
+
+	if req.UserID != "" {
+		userUUID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return response.ErrUUIDParse(c)
+		}
+
+		user, err := mc.UserService.GetUserByID(userUUID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return response.ErrNotFound(c)
+			}
+			logger.CaptureError(err, "Failed to find user to unlock", map[string]interface{}{
+				"userUUID": userUUID,
+				"route":    c.Path(),
+				"method":   c.Method(),
+			})
+			return response.ErrInternalServer(c)
+		}
+
+		if err := mc.LoginThrottleService.Unlock(user.Username); err != nil {
+			logger.CaptureError(err, "Failed to unlock user", map[string]interface{}{
+				"userUUID": userUUID,
+				"route":    c.Path(),
+				"method":   c.Method(),
+			})
+			return response.ErrInternalServer(c)
+		}
+
+		mc.AuditService.Record(newAuditContext(c), models.AuditUserLoginUnlock, models.AuditTargetUser, userUUID.String(), nil, nil)
+	}
+
+	if req.IPAddress != "" {
+		if err := mc.LoginThrottleService.UnlockIP(req.IPAddress); err != nil {
+			logger.CaptureError(err, "Failed to unlock IP", map[string]interface{}{
+				"ip":     req.IPAddress,
+				"route":  c.Path(),
+				"method": c.Method(),
+			})
+			return response.ErrInternalServer(c)
+		}
+
+		mc.AuditService.Record(newAuditContext(c), models.AuditUserLoginUnlock, models.AuditTargetIP, req.IPAddress, nil, nil)
+	}
+
+	logger.Info("Login unlocked by an administrator", map[string]interface{}{
+		"userID": req.UserID,
+		"ip":     req.IPAddress,
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+
+	return response.Standard(c, "UNLOCKED", nil)
+}
+
+func (mc *ManagementController) BanUser(c fiber.Ctx) error {
+	moderatorUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	var req request.BanUser
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to bind BanUser request", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	userUUID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	ban, err := mc.BanService.Ban(userUUID, moderatorUUID, req.Reason, time.Duration(req.DurationHours)*time.Hour)
+	if err != nil {
+		return mc.handleBanError(c, err, "Failed to ban user")
+	}
+
+	mc.AuditService.Record(newAuditContext(c), models.AuditUserBan, models.AuditTargetUser, userUUID.String(), nil, ban)
+
+	return response.StandardCreated(c, "BANNED", ban)
+}
+
+func (mc *ManagementController) UnbanUser(c fiber.Ctx) error {
+	moderatorUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	var req request.UnbanUser
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to bind UnbanUser request", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	userUUID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	bans, err := mc.BanService.GetBans(userUUID)
+	if err != nil {
+		return mc.handleBanError(c, err, "Failed to retrieve the bans of a user")
+	}
+
+	if err := mc.BanService.Unban(userUUID, moderatorUUID); err != nil {
+		return mc.handleBanError(c, err, "Failed to lift the ban of a user")
+	}
+
+	// the ban that was lifted is the active one
+	var liftedBan *response.BanResponse
+	for i := range bans {
+		if bans[i].Active {
+			liftedBan = &bans[i]
+			break
+		}
+	}
+	mc.AuditService.Record(newAuditContext(c), models.AuditUserUnban, models.AuditTargetUser, userUUID.String(), liftedBan, nil)
+
+	return response.Standard(c, "UNBANNED", nil)
+}
+
+func (mc *ManagementController) GetUserBans(c fiber.Ctx) error {
+	userUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	bans, err := mc.BanService.GetBans(userUUID)
+	if err != nil {
+		return mc.handleBanError(c, err, "Failed to retrieve the bans of a user")
+	}
+
+	return response.Standard(c, "OK", bans)
+}
+
+// handleBanError maps the errors of the bans service to a response.
+func (mc *ManagementController) handleBanError(c fiber.Ctx, err error, message string) error {
+	switch err {
+	case errorsUtils.ErrBanSelf, errorsUtils.ErrBanNotAllowed:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusForbidden)
+	case gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
+
 func NewManagamentController(
 	forumService services.ForumService,
 	categoryService services.CategoryService,
@@ -103,6 +299,9 @@ func NewManagamentController(
 	userService services.UserService,
 	AuthService services.AuthService,
 	CacheService services.CacheService,
+	loginThrottleService services.LoginThrottleService,
+	banService services.BanService,
+	auditService services.AuditService,
 ) *ManagementController {
 
 	return &ManagementController{
@@ -112,5 +311,9 @@ func NewManagamentController(
 		UserService:     userService,
 		AuthService:     AuthService,
 		CacheService:    CacheService,
+
+		LoginThrottleService: loginThrottleService,
+		BanService:           banService,
+		AuditService:         auditService,
 	}
 }
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+type MFAController struct {
+	MFAService  services.MFAService
+	AuthService services.AuthService
+}
+
+func NewMFAController(mfaService services.MFAService, authService services.AuthService) *MFAController {
+	return &MFAController{MFAService: mfaService, AuthService: authService}
+}
+
+func (mc *MFAController) GetStatus(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	status, err := mc.MFAService.GetStatus(userUUID)
+	if err != nil {
+		return mc.handleError(c, err, "Error retrieving two-factor authentication status")
+	}
+
+	return response.Standard(c, "OK", status)
+}
+
+func (mc *MFAController) Setup(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	setup, err := mc.MFAService.Setup(userUUID)
+	if err != nil {
+		return mc.handleError(c, err, "Error setting up two-factor authentication")
+	}
+
+	return response.Standard(c, "Scan the code with your authenticator and confirm it with a code", setup)
+}
+
+func (mc *MFAController) SetupPending(c fiber.Ctx) error {
+	var req request.MFASetupRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse MFASetupRequest in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	setup, err := mc.AuthService.SetupMFA(req.MFAToken)
+	if err != nil {
+		return mc.handleError(c, err, "Error setting up two-factor authentication")
+	}
+
+	return response.Standard(c, "Scan the code with your authenticator and confirm it with a code", setup)
+}
+
+func (mc *MFAController) Enable(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	var req request.MFACodeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse MFACodeRequest in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	recoveryCodes, err := mc.MFAService.Enable(userUUID, req.Code)
+	if err != nil {
+		return mc.handleError(c, err, "Error enabling two-factor authentication")
+	}
+
+	return response.Standard(c, "Two-factor authentication enabled, keep the recovery codes in a safe place",
+		response.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (mc *MFAController) Verify(c fiber.Ctx) error {
+	var req request.MFAVerifyRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse MFAVerifyRequest in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	result, err := mc.AuthService.VerifyMFA(req.MFAToken, req.Code, newSessionDto(c, req.DeviceName))
+	if err != nil {
+		return mc.handleError(c, err, "Error verifying two-factor authentication")
+	}
+
+	if result.PasswordResetToken != "" {
+		return response.Standard(c, "The password must be changed, reset it with the token given", mapper.LoginResultDtoToLoginResponse(result))
+	}
+
+	return response.Standard(c, "Successfully logged in", mapper.LoginResultDtoToLoginResponse(result))
+}
+
+func (mc *MFAController) Disable(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	var req request.MFACodeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse MFACodeRequest in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := mc.MFAService.Disable(userUUID, req.Code); err != nil {
+		return mc.handleError(c, err, "Error disabling two-factor authentication")
+	}
+
+	return response.Standard(c, "Two-factor authentication disabled", nil)
+}
+
+func (mc *MFAController) RegenerateRecoveryCodes(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	var req request.MFACodeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse MFACodeRequest in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	recoveryCodes, err := mc.MFAService.RegenerateRecoveryCodes(userUUID, req.Code)
+	if err != nil {
+		return mc.handleError(c, err, "Error regenerating recovery codes")
+	}
+
+	return response.Standard(c, "New recovery codes generated, the previous ones no longer work",
+		response.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+// handleError maps the errors of the two-factor authentication services to a response.
+func (mc *MFAController) handleError(c fiber.Ctx, err error, message string) error {
+	var blocked *errorsUtils.LoginBlockedError
+	if errors.As(err, &blocked) {
+		return loginBlockedResponse(c, blocked)
+	}
+	var banned *errorsUtils.BannedError
+	if errors.As(err, &banned) {
+		return response.ErrBanned(c, banned.Reason, banned.ExpiresAt)
+	}
+
+	switch err {
+	case errorsUtils.ErrMFACodeInvalid, errorsUtils.ErrMFATokenInvalid:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusUnauthorized)
+	case errorsUtils.ErrMFAAlreadyEnabled:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	case errorsUtils.ErrMFANotEnabled, errorsUtils.ErrMFASetupMissing:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+	case errorsUtils.ErrMFARequiredByRole:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusForbidden)
+	case gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
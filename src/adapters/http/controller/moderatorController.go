@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ModeratorController struct {
+	ModeratorService services.ModeratorService
+	AuditService     services.AuditService
+}
+
+func NewModeratorController(moderatorService services.ModeratorService, auditService services.AuditService) *ModeratorController {
+	return &ModeratorController{ModeratorService: moderatorService, AuditService: auditService}
+}
+
+func (mc *ModeratorController) GetForumModerators(c fiber.Ctx) error {
+	forumUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	moderators, err := mc.ModeratorService.GetForumModerators(forumUUID)
+	if err != nil {
+		return mc.handleError(c, err, "Error retrieving the moderators of a forum")
+	}
+
+	return response.Standard(c, "OK", moderators)
+}
+
+func (mc *ModeratorController) GetCategoryModerators(c fiber.Ctx) error {
+	categoryUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	moderators, err := mc.ModeratorService.GetCategoryModerators(categoryUUID)
+	if err != nil {
+		return mc.handleError(c, err, "Error retrieving the moderators of a category")
+	}
+
+	return response.Standard(c, "OK", moderators)
+}
+
+func (mc *ModeratorController) AddForumModerator(c fiber.Ctx) error {
+	forumUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	var req request.NewModerator
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse NewModerator in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	moderator, err := mc.ModeratorService.AddForumModerator(forumUUID, req)
+	if err != nil {
+		return mc.handleError(c, err, "Error adding a moderator to a forum")
+	}
+
+	logger.Info("Forum moderator added", map[string]interface{}{
+		"forumID":     forumUUID,
+		"moderatorID": moderator.ID,
+		"userID":      moderator.UserID,
+		"roleID":      moderator.RoleID,
+	})
+
+	mc.AuditService.Record(newAuditContext(c), models.AuditModeratorAdd, models.AuditTargetModerator, moderator.ID.String(), nil, moderator)
+
+	return response.StandardCreated(c, "CREATED", moderator)
+}
+
+func (mc *ModeratorController) AddCategoryModerator(c fiber.Ctx) error {
+	categoryUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	var req request.NewModerator
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse NewModerator in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	moderator, err := mc.ModeratorService.AddCategoryModerator(categoryUUID, req)
+	if err != nil {
+		return mc.handleError(c, err, "Error adding a moderator to a category")
+	}
+
+	logger.Info("Category moderator added", map[string]interface{}{
+		"categoryID":  categoryUUID,
+		"moderatorID": moderator.ID,
+		"userID":      moderator.UserID,
+		"roleID":      moderator.RoleID,
+	})
+
+	mc.AuditService.Record(newAuditContext(c), models.AuditModeratorAdd, models.AuditTargetModerator, moderator.ID.String(), nil, moderator)
+
+	return response.StandardCreated(c, "CREATED", moderator)
+}
+
+func (mc *ModeratorController) RemoveForumModerator(c fiber.Ctx) error {
+	forumUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+	moderatorUUID, err := uuid.Parse(c.Params("moderatorID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	moderators, _ := mc.ModeratorService.GetForumModerators(forumUUID)
+	before := moderatorSnapshot(moderators, moderatorUUID)
+
+	if err := mc.ModeratorService.RemoveForumModerator(forumUUID, moderatorUUID); err != nil {
+		return mc.handleError(c, err, "Error removing a moderator from a forum")
+	}
+
+	logger.Info("Forum moderator removed", map[string]interface{}{
+		"forumID":     forumUUID,
+		"moderatorID": moderatorUUID,
+	})
+
+	mc.AuditService.Record(newAuditContext(c), models.AuditModeratorRemove, models.AuditTargetModerator, moderatorUUID.String(), before, nil)
+
+	return response.Standard(c, "DELETED", nil)
+}
+
+func (mc *ModeratorController) RemoveCategoryModerator(c fiber.Ctx) error {
+	categoryUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+	moderatorUUID, err := uuid.Parse(c.Params("moderatorID"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	moderators, _ := mc.ModeratorService.GetCategoryModerators(categoryUUID)
+	before := moderatorSnapshot(moderators, moderatorUUID)
+
+	if err := mc.ModeratorService.RemoveCategoryModerator(categoryUUID, moderatorUUID); err != nil {
+		return mc.handleError(c, err, "Error removing a moderator from a category")
+	}
+
+	logger.Info("Category moderator removed", map[string]interface{}{
+		"categoryID":  categoryUUID,
+		"moderatorID": moderatorUUID,
+	})
+
+	mc.AuditService.Record(newAuditContext(c), models.AuditModeratorRemove, models.AuditTargetModerator, moderatorUUID.String(), before, nil)
+
+	return response.Standard(c, "DELETED", nil)
+}
+
+func (mc *ModeratorController) GetModeratedForums(c fiber.Ctx) error {
+	userUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	forums, err := mc.ModeratorService.GetModeratedForums(userUUID)
+	if err != nil {
+		return mc.handleError(c, err, "Error retrieving the forums moderated by a user")
+	}
+
+	return response.Standard(c, "OK", forums)
+}
+
+// moderatorSnapshot finds a moderator assignment among moderators for the audit log, nil if it is not there.
+func moderatorSnapshot(moderators []response.ModeratorResponse, moderatorID uuid.UUID) *response.ModeratorResponse {
+	for i := range moderators {
+		if moderators[i].ID == moderatorID {
+			return &moderators[i]
+		}
+	}
+
+	return nil
+}
+
+// handleError maps the errors of the moderators service to a response.
+func (mc *ModeratorController) handleError(c fiber.Ctx, err error, message string) error {
+	switch err {
+	case errorsUtils.ErrModeratorAlreadyAssigned:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	case errorsUtils.ErrModeratorSubjectNotFound:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+	case gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+type OAuthController struct {
+	OAuthService services.OAuthService
+}
+
+func NewOAuthController(oauthService services.OAuthService) *OAuthController {
+	return &OAuthController{OAuthService: oauthService}
+}
+
+func (oc *OAuthController) GetProviders(c fiber.Ctx) error {
+	return response.Standard(c, "OK", oc.OAuthService.Providers())
+}
+
+func (oc *OAuthController) Authorize(c fiber.Ctx) error {
+	authorizationURL, err := oc.OAuthService.AuthorizationURL(c.Params("provider"))
+	if err != nil {
+		return oc.handleError(c, err, "Error starting a login with a provider")
+	}
+
+	return response.Standard(c, "OK", response.OAuthAuthorizationResponse{AuthorizationURL: authorizationURL})
+}
+
+func (oc *OAuthController) LinkAuthorize(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	authorizationURL, err := oc.OAuthService.LinkAuthorizationURL(c.Params("provider"), userUUID)
+	if err != nil {
+		return oc.handleError(c, err, "Error starting to link a provider")
+	}
+
+	return response.Standard(c, "OK", response.OAuthAuthorizationResponse{AuthorizationURL: authorizationURL})
+}
+
+func (oc *OAuthController) Callback(c fiber.Ctx) error {
+	var req request.OAuthCallbackRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse OAuthCallbackRequest in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	result, linked, err := oc.OAuthService.Callback(c.Params("provider"), req.Code, req.State, newSessionDto(c, req.DeviceName))
+	if err != nil {
+		return oc.handleError(c, err, "Error finishing a login with a provider")
+	}
+
+	if linked {
+		return response.Standard(c, "Provider linked to the account", nil)
+	}
+
+	if result.MFAToken != "" {
+		return response.Standard(c, "Two-factor authentication required", mapper.LoginResultDtoToLoginResponse(result))
+	}
+
+	if result.PasswordResetToken != "" {
+		return response.Standard(c, "The password must be changed, reset it with the token given", mapper.LoginResultDtoToLoginResponse(result))
+	}
+
+	return response.Standard(c, "Successfully logged in", mapper.LoginResultDtoToLoginResponse(result))
+}
+
+func (oc *OAuthController) GetIdentities(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	identities, err := oc.OAuthService.GetIdentities(userUUID)
+	if err != nil {
+		return oc.handleError(c, err, "Error retrieving the linked providers")
+	}
+
+	return response.Standard(c, "OK", identities)
+}
+
+func (oc *OAuthController) Unlink(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	if err := oc.OAuthService.Unlink(userUUID, c.Params("provider")); err != nil {
+		return oc.handleError(c, err, "Error unlinking a provider")
+	}
+
+	return response.Standard(c, "Provider unlinked from the account", nil)
+}
+
+// handleError maps the errors of the login with providers to a response.
+func (oc *OAuthController) handleError(c fiber.Ctx, err error, message string) error {
+	var banned *errorsUtils.BannedError
+	if errors.As(err, &banned) {
+		return response.ErrBanned(c, banned.Reason, banned.ExpiresAt)
+	}
+
+	switch err {
+	case errorsUtils.ErrOAuthProviderUnknown, gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	case errorsUtils.ErrOAuthStateInvalid, errorsUtils.ErrOAuthLoginFailed, errorsUtils.ErrOAuthEmailMissing:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+	case errorsUtils.ErrOAuthAccountExists, errorsUtils.ErrOAuthIdentityLinked,
+		errorsUtils.ErrOAuthProviderLinked, errorsUtils.ErrOAuthLastLoginMethod:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PersonalTokenController struct {
+	PersonalTokenService services.PersonalTokenService
+}
+
+func NewPersonalTokenController(personalTokenService services.PersonalTokenService) *PersonalTokenController {
+	return &PersonalTokenController{PersonalTokenService: personalTokenService}
+}
+
+func (pc *PersonalTokenController) GetAllTokens(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	tokens, err := pc.PersonalTokenService.GetAll(userUUID)
+	if err != nil {
+		return pc.handleError(c, err, "Error retrieving personal access tokens")
+	}
+
+	return response.Standard(c, "OK", tokens)
+}
+
+func (pc *PersonalTokenController) CreateToken(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	var req request.CreatePersonalTokenRequest
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse CreatePersonalTokenRequest in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	token, err := pc.PersonalTokenService.Create(userUUID, req.Name, req.Scopes, time.Duration(req.ExpiresInDays)*24*time.Hour)
+	if err != nil {
+		return pc.handleError(c, err, "Error creating a personal access token")
+	}
+
+	return response.StandardCreated(c, "Personal access token created, copy it now as it will not be shown again", token)
+}
+
+func (pc *PersonalTokenController) RevokeToken(c fiber.Ctx) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	tokenUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	if err := pc.PersonalTokenService.Revoke(userUUID, tokenUUID); err != nil {
+		return pc.handleError(c, err, "Error revoking a personal access token")
+	}
+
+	return response.Standard(c, "Personal access token revoked", nil)
+}
+
+// handleError maps the errors of the personal access tokens service to a response.
+func (pc *PersonalTokenController) handleError(c fiber.Ctx, err error, message string) error {
+	switch err {
+	case errorsUtils.ErrPersonalTokenNameTaken, errorsUtils.ErrPersonalTokenLimit:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	case gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
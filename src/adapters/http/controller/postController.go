@@ -5,27 +5,33 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Dialosoft/src/adapters/dto"
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type PostController struct {
-	PostService services.PostService
+	PostService  services.PostService
+	AuditService services.AuditService
 }
 
-func NewPostController(postService services.PostService) *PostController {
-	return &PostController{PostService: postService}
+func NewPostController(postService services.PostService, auditService services.AuditService) *PostController {
+	return &PostController{PostService: postService, AuditService: auditService}
 }
 
 func (pc *PostController) GetAllPostsByForum(c fiber.Ctx) error {
 	limit := c.Query("limit")
 	offset := c.Query("offset")
 
-	forumID := c.Params("id")
+	forumID := c.Params("forumID")
 	forumUUID, err := uuid.Parse(forumID)
 	if err != nil {
 		return response.ErrUUIDParse(c)
@@ -193,6 +199,9 @@ func (pc *PostController) GetPostNumberOfLikes(c fiber.Ctx) error {
 func (pc *PostController) CreateNewPost(c fiber.Ctx) error {
 	var req request.NewPost
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		return response.ErrBadRequest(c)
 	}
 
@@ -211,6 +220,9 @@ func (pc *PostController) UpdatePostTitle(c fiber.Ctx) error {
 	var req request.UpdatePostTitle
 
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		return response.ErrBadRequest(c)
 	}
 
@@ -234,6 +246,9 @@ func (pc *PostController) UpdatePostContent(c fiber.Ctx) error {
 	var req request.UpdatePostContent
 
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		return response.ErrBadRequest(c)
 	}
 
@@ -300,6 +315,9 @@ func (pc *PostController) RestorePost(c fiber.Ctx) error {
 func (pc *PostController) LikePost(c fiber.Ctx) error {
 	var req request.LikeOrUnlikePost
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		return response.ErrBadRequest(c)
 	}
 
@@ -330,6 +348,9 @@ func (pc *PostController) LikePost(c fiber.Ctx) error {
 func (pc *PostController) UnlikePost(c fiber.Ctx) error {
 	var req request.LikeOrUnlikePost
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		return response.ErrBadRequest(c)
 	}
 
@@ -376,3 +397,133 @@ func (pc *PostController) GetPostLikesByUserID(c fiber.Ctx) error {
 		"postsIDsLikes": likes,
 	})
 }
+
+func (pc *PostController) LockPost(c fiber.Ctx) error {
+	return pc.moderatePost(c, models.AuditPostLock, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.SetPostLocked(postID, actor, true)
+	})
+}
+
+func (pc *PostController) UnlockPost(c fiber.Ctx) error {
+	return pc.moderatePost(c, models.AuditPostUnlock, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.SetPostLocked(postID, actor, false)
+	})
+}
+
+func (pc *PostController) PinPost(c fiber.Ctx) error {
+	return pc.moderatePost(c, models.AuditPostPin, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.SetPostPinned(postID, actor, true)
+	})
+}
+
+func (pc *PostController) UnpinPost(c fiber.Ctx) error {
+	return pc.moderatePost(c, models.AuditPostUnpin, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.SetPostPinned(postID, actor, false)
+	})
+}
+
+func (pc *PostController) AnnouncePost(c fiber.Ctx) error {
+	return pc.moderatePost(c, models.AuditPostAnnounce, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.SetPostAnnouncement(postID, actor, true)
+	})
+}
+
+func (pc *PostController) UnannouncePost(c fiber.Ctx) error {
+	return pc.moderatePost(c, models.AuditPostUnannounce, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.SetPostAnnouncement(postID, actor, false)
+	})
+}
+
+func (pc *PostController) MovePost(c fiber.Ctx) error {
+	var req request.MovePost
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse MovePost in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	return pc.moderatePost(c, models.AuditPostMove, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.MovePost(postID, actor, req)
+	})
+}
+
+// MergePost merges the post into another thread. After the merge, the audit log
+// records the thread its ID redirects to.
+func (pc *PostController) MergePost(c fiber.Ctx) error {
+	var req request.MergePosts
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse MergePosts in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	return pc.moderatePost(c, models.AuditPostMerge, func(postID uuid.UUID, actor dto.Actor) error {
+		return pc.PostService.MergePosts(postID, actor, req)
+	})
+}
+
+// moderatePost applies a moderation action to the post of the id route parameter
+// on behalf of the actor of the request, and records it in the audit log.
+func (pc *PostController) moderatePost(c fiber.Ctx, action string, moderate func(postID uuid.UUID, actor dto.Actor) error) error {
+	postUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	before, _ := pc.PostService.GetPostByID(postUUID)
+
+	if err := moderate(postUUID, actor); err != nil {
+		return pc.handleError(c, err, "Error moderating a post")
+	}
+
+	after, _ := pc.PostService.GetPostByID(postUUID)
+
+	logger.Info("Post moderated", map[string]interface{}{
+		"postID": postUUID.String(),
+		"action": action,
+		"userID": actor.UserID.String(),
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+
+	pc.AuditService.Record(newAuditContext(c), action, models.AuditTargetPost, postUUID.String(), before, after)
+
+	return response.Standard(c, "UPDATED", after)
+}
+
+// handleError maps the errors of the posts service to a response.
+func (pc *PostController) handleError(c fiber.Ctx, err error, message string) error {
+	switch err {
+	case gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	case errorsUtils.ErrUserUnauthorized:
+		return response.ErrForbidden(c)
+	case errorsUtils.ErrInvalidUUID:
+		return response.ErrUUIDParse(c)
+	case errorsUtils.ErrPostSameForum, errorsUtils.ErrPostForumNotFound, errorsUtils.ErrPostMergeSelf:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+	case errorsUtils.ErrPostIsRedirect:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
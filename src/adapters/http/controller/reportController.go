@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReportController struct {
+	ReportService services.ReportService
+	AuditService  services.AuditService
+}
+
+func NewReportController(reportService services.ReportService, auditService services.AuditService) *ReportController {
+	return &ReportController{ReportService: reportService, AuditService: auditService}
+}
+
+func (rc *ReportController) ReportPost(c fiber.Ctx) error {
+	return rc.sendReport(c, "id", rc.ReportService.ReportPost)
+}
+
+func (rc *ReportController) ReportComment(c fiber.Ctx) error {
+	return rc.sendReport(c, "commentID", rc.ReportService.ReportComment)
+}
+
+func (rc *ReportController) ReportUser(c fiber.Ctx) error {
+	return rc.sendReport(c, "id", rc.ReportService.ReportUser)
+}
+
+func (rc *ReportController) GetQueue(c fiber.Ctx) error {
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	limit := c.Query("limit")
+	offset := c.Query("offset")
+	status := c.Query("status", models.ReportStatusOpen)
+	targetType := c.Query("type")
+
+	if limit == "" {
+		limit = "20"
+	}
+	if offset == "" {
+		offset = "0"
+	}
+
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt <= 0 || limitInt > 100 {
+		return response.ErrBadRequest(c)
+	}
+
+	offsetInt, err := strconv.Atoi(offset)
+	if err != nil || offsetInt < 0 {
+		return response.ErrBadRequest(c)
+	}
+
+	// "all" lists the reports whatever their status
+	if status == "all" {
+		status = ""
+	} else if status != models.ReportStatusOpen && status != models.ReportStatusClaimed && status != models.ReportStatusResolved {
+		return response.ErrBadRequest(c)
+	}
+
+	if targetType != "" && !slices.Contains(models.ReportTargets, targetType) {
+		return response.ErrBadRequest(c)
+	}
+
+	var forumUUID *uuid.UUID
+	if forumID := c.Query("forumID"); forumID != "" {
+		parsed, err := uuid.Parse(forumID)
+		if err != nil {
+			return response.ErrUUIDParse(c)
+		}
+		forumUUID = &parsed
+	}
+
+	queue, err := rc.ReportService.GetQueue(actor, status, targetType, forumUUID, limitInt, offsetInt)
+	if err != nil {
+		return rc.handleError(c, err, "Error retrieving the moderation queue")
+	}
+
+	return response.Standard(c, "OK", queue)
+}
+
+func (rc *ReportController) GetReport(c fiber.Ctx) error {
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	reportUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	report, err := rc.ReportService.GetReport(actor, reportUUID)
+	if err != nil {
+		return rc.handleError(c, err, "Error retrieving a report")
+	}
+
+	return response.Standard(c, "OK", report)
+}
+
+func (rc *ReportController) ResolveReport(c fiber.Ctx) error {
+	actor, ok := getActorFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	reportUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	var req request.ResolveReport
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse ResolveReport in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	report, err := rc.ReportService.Resolve(actor, reportUUID, req)
+	if err != nil {
+		return rc.handleError(c, err, "Error resolving a report")
+	}
+
+	rc.AuditService.Record(newAuditContext(c), models.AuditReportResolve, models.AuditTargetReport, reportUUID.String(), nil, report)
+
+	return response.Standard(c, "Report resolved", report)
+}
+
+// sendReport reports the target identified by the param route parameter on behalf of the user of the request.
+func (rc *ReportController) sendReport(c fiber.Ctx, param string, report func(targetID uuid.UUID, reporterID uuid.UUID, req request.NewReport) error) error {
+	userUUID, ok := getUserIDFromLocals(c)
+	if !ok {
+		return response.ErrUnauthorized(c)
+	}
+
+	targetUUID, err := uuid.Parse(c.Params(param))
+	if err != nil {
+		return response.ErrUUIDParse(c)
+	}
+
+	var req request.NewReport
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to parse NewReport in Controller", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+
+	if err := report(targetUUID, userUUID, req); err != nil {
+		return rc.handleError(c, err, "Error sending a report")
+	}
+
+	return response.StandardCreated(c, "Report sent, a moderator will review it", nil)
+}
+
+// handleError maps the errors of the reports service to a response.
+func (rc *ReportController) handleError(c fiber.Ctx, err error, message string) error {
+	switch err {
+	case errorsUtils.ErrReportAlreadySent, errorsUtils.ErrReportResolved, errorsUtils.ErrReportClaimed:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+	case errorsUtils.ErrReportSelf, errorsUtils.ErrReportActionInvalid:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+	case errorsUtils.ErrBanSelf, errorsUtils.ErrBanNotAllowed:
+		return response.PersonalizedErr(c, err.Error(), fiber.StatusForbidden)
+	case errorsUtils.ErrUserUnauthorized:
+		return response.ErrForbidden(c)
+	case gorm.ErrRecordNotFound:
+		return response.ErrNotFound(c)
+	}
+
+	logger.CaptureError(err, message, map[string]interface{}{
+		"route":  c.Path(),
+		"method": c.Method(),
+	})
+	return response.ErrInternalServer(c)
+}
@@ -7,19 +7,23 @@ import (
 	"github.com/Dialosoft/src/adapters/dto"
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
 	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type RoleController struct {
-	RoleService services.RoleService
+	RoleService  services.RoleService
+	AuditService services.AuditService
 }
 
-func NewRoleController(roleService services.RoleService) *RoleController {
-	return &RoleController{RoleService: roleService}
+func NewRoleController(roleService services.RoleService, auditService services.AuditService) *RoleController {
+	return &RoleController{RoleService: roleService, AuditService: auditService}
 }
 
 func (rc *RoleController) GetAllRoles(c fiber.Ctx) error {
@@ -135,6 +139,9 @@ func (rc *RoleController) GetRoleByType(c fiber.Ctx) error {
 func (rc *RoleController) CreateNewRole(c fiber.Ctx) error {
 	var req request.NewRole
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to bind request for creating new role", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -181,6 +188,9 @@ func (rc *RoleController) CreateNewRole(c fiber.Ctx) error {
 		"method": c.Method(),
 	})
 
+	rc.AuditService.Record(newAuditContext(c), models.AuditRoleCreate, models.AuditTargetRole, roleUUID.String(),
+		nil, rc.roleSnapshot(roleUUID))
+
 	return response.StandardCreated(c, "CREATED", fiber.Map{
 		"id": roleUUID.String(),
 	})
@@ -209,6 +219,9 @@ func (rc *RoleController) UpdateRole(c fiber.Ctx) error {
 	}
 
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to bind request for updating role", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -216,6 +229,8 @@ func (rc *RoleController) UpdateRole(c fiber.Ctx) error {
 		return response.ErrBadRequest(c)
 	}
 
+	before := rc.roleSnapshot(roleUUID)
+
 	err = rc.RoleService.UpdateRole(roleUUID, req)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -240,6 +255,9 @@ func (rc *RoleController) UpdateRole(c fiber.Ctx) error {
 		"method": c.Method(),
 	})
 
+	rc.AuditService.Record(newAuditContext(c), models.AuditRoleUpdate, models.AuditTargetRole, id,
+		before, rc.roleSnapshot(roleUUID))
+
 	return response.Standard(c, "UPDATED", nil)
 }
 
@@ -265,6 +283,9 @@ func (rc *RoleController) SetRolePermissionsByRoleID(c fiber.Ctx) error {
 	}
 
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to bind request for updating role", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -272,7 +293,10 @@ func (rc *RoleController) SetRolePermissionsByRoleID(c fiber.Ctx) error {
 		return response.ErrBadRequest(c)
 	}
 
-	err = rc.RoleService.SetRolePermissionsByRoleID(roleUUID, req)
+	// the snapshot is best effort, the role not existing is reported by SetRolePermissionsByRoleID
+	previousPermissions, _ := rc.RoleService.GetRolePermissionsByRoleID(roleUUID)
+
+	err = rc.RoleService.SetRolePermissionsByRoleID(roleUUID, req.Permissions)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("Role not found for update", map[string]interface{}{
@@ -282,6 +306,9 @@ func (rc *RoleController) SetRolePermissionsByRoleID(c fiber.Ctx) error {
 			})
 			return response.ErrNotFound(c)
 		}
+		if errors.Is(err, errorsUtils.ErrPermissionUnknown) {
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+		}
 		logger.CaptureError(err, "Error updating role", map[string]interface{}{
 			"roleID": id,
 			"route":  c.Path(),
@@ -290,12 +317,88 @@ func (rc *RoleController) SetRolePermissionsByRoleID(c fiber.Ctx) error {
 		return response.ErrInternalServer(c)
 	}
 
-	logger.Info("Role updated successfully", map[string]interface{}{
-		"roleID": id,
-		"route":  c.Path(),
-		"method": c.Method(),
+	logger.Info("Role permissions updated", map[string]interface{}{
+		"roleID":      id,
+		"permissions": req.Permissions,
+		"route":       c.Path(),
+		"method":      c.Method(),
+	})
+
+	currentPermissions, _ := rc.RoleService.GetRolePermissionsByRoleID(roleUUID)
+	rc.AuditService.Record(newAuditContext(c), models.AuditRolePermissionsSet, models.AuditTargetRole, id,
+		fiber.Map{"permissions": previousPermissions}, fiber.Map{"permissions": currentPermissions})
+
+	return response.Standard(c, "UPDATED", nil)
+}
+
+func (rc *RoleController) SetRoleRequireMFA(c fiber.Ctx) error {
+	var req request.RoleRequireMFA
+	id := c.Params("id")
+	if id == "" {
+		logger.Error("Empty parameters or arguments", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrEmptyParametersOrArguments(c)
+	}
+
+	roleUUID, err := uuid.Parse(id)
+	if err != nil {
+		logger.Error("Invalid UUID format", map[string]interface{}{
+			"provided-id": id,
+			"route":       c.Path(),
+			"method":      c.Method(),
+		})
+		return response.ErrUUIDParse(c)
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
+		logger.CaptureError(err, "Failed to bind request for requiring 2FA on a role", map[string]interface{}{
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrBadRequest(c)
+	}
+	if req.RequireMFA == nil {
+		return response.ErrEmptyParametersOrArguments(c)
+	}
+
+	before := rc.roleSnapshot(roleUUID)
+
+	err = rc.RoleService.SetRoleRequireMFA(roleUUID, *req.RequireMFA)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logger.Warn("Role not found for update", map[string]interface{}{
+				"roleID": id,
+				"route":  c.Path(),
+				"method": c.Method(),
+			})
+			return response.ErrNotFound(c)
+		}
+		if err == errorsUtils.ErrMFARoleNotPrivileged {
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+		}
+		logger.CaptureError(err, "Error updating role", map[string]interface{}{
+			"roleID": id,
+			"route":  c.Path(),
+			"method": c.Method(),
+		})
+		return response.ErrInternalServer(c)
+	}
+
+	logger.Info("Role two-factor authentication requirement updated", map[string]interface{}{
+		"roleID":     id,
+		"requireMFA": *req.RequireMFA,
+		"route":      c.Path(),
+		"method":     c.Method(),
 	})
 
+	rc.AuditService.Record(newAuditContext(c), models.AuditRoleRequireMFASet, models.AuditTargetRole, id,
+		before, rc.roleSnapshot(roleUUID))
+
 	return response.Standard(c, "UPDATED", nil)
 }
 
@@ -319,7 +422,7 @@ func (rc *RoleController) GetRolePermissionsByRoleID(c fiber.Ctx) error {
 		return response.ErrUUIDParse(c)
 	}
 
-	rolePermission, err := rc.RoleService.GetRolePermissionsByRoleID(roleUUID)
+	rolePermissions, err := rc.RoleService.GetRolePermissionsByRoleID(roleUUID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("Role not found", map[string]interface{}{
@@ -343,7 +446,14 @@ func (rc *RoleController) GetRolePermissionsByRoleID(c fiber.Ctx) error {
 		"method": c.Method(),
 	})
 
-	return response.Standard(c, "OK", rolePermission)
+	return response.Standard(c, "OK", fiber.Map{
+		"roleID":      roleUUID,
+		"permissions": rolePermissions,
+	})
+}
+
+func (rc *RoleController) GetAllPermissions(c fiber.Ctx) error {
+	return response.Standard(c, "OK", rc.RoleService.GetAllPermissions())
 }
 
 func (rc *RoleController) DeleteRole(c fiber.Ctx) error {
@@ -366,6 +476,8 @@ func (rc *RoleController) DeleteRole(c fiber.Ctx) error {
 		return response.ErrUUIDParse(c)
 	}
 
+	before := rc.roleSnapshot(roleUUID)
+
 	if err = rc.RoleService.DeleteRole(roleUUID); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("Role not found for deletion", map[string]interface{}{
@@ -389,6 +501,8 @@ func (rc *RoleController) DeleteRole(c fiber.Ctx) error {
 		"method": c.Method(),
 	})
 
+	rc.AuditService.Record(newAuditContext(c), models.AuditRoleDelete, models.AuditTargetRole, id, before, nil)
+
 	return response.Standard(c, "DELETED", nil)
 }
 
@@ -427,5 +541,17 @@ func (rc *RoleController) RestoreRole(c fiber.Ctx) error {
 		"method": c.Method(),
 	})
 
+	rc.AuditService.Record(newAuditContext(c), models.AuditRoleRestore, models.AuditTargetRole, id, nil, rc.roleSnapshot(roleUUID))
+
 	return response.Standard(c, "RESTORED", nil)
 }
+
+// roleSnapshot retrieves a role for the audit log, nil if it cannot be found.
+func (rc *RoleController) roleSnapshot(roleID uuid.UUID) *dto.RoleDto {
+	roleDto, err := rc.RoleService.GetRoleByID(roleID)
+	if err != nil {
+		return nil
+	}
+
+	return roleDto
+}
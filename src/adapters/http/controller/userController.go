@@ -7,19 +7,23 @@ import (
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
 	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
 	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type UserController struct {
-	UserService services.UserService
+	UserService  services.UserService
+	AuditService services.AuditService
 }
 
-func NewUserController(userService services.UserService) *UserController {
-	return &UserController{UserService: userService}
+func NewUserController(userService services.UserService, auditService services.AuditService) *UserController {
+	return &UserController{UserService: userService, AuditService: auditService}
 }
 
 func (uc *UserController) GetAllUsers(c fiber.Ctx) error {
@@ -135,6 +139,9 @@ func (uc *UserController) GetUserByUsername(c fiber.Ctx) error {
 func (uc *UserController) CreateNewUser(c fiber.Ctx) error {
 	var req request.UserRequest
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to bind request for creating new user", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -194,6 +201,9 @@ func (uc *UserController) UpdateUser(c fiber.Ctx) error {
 	}
 
 	if err := c.Bind().Body(&req); err != nil {
+		if fieldErrors, ok := validation.FieldErrorsOf(err); ok {
+			return response.ErrValidation(c, fieldErrors)
+		}
 		logger.CaptureError(err, "Failed to bind request for updating user", map[string]interface{}{
 			"route":  c.Path(),
 			"method": c.Method(),
@@ -203,6 +213,12 @@ func (uc *UserController) UpdateUser(c fiber.Ctx) error {
 
 	err = uc.UserService.UpdateUser(userUUID, req)
 	if err != nil {
+		switch err {
+		case errorsUtils.ErrUsernameReserved, errorsUtils.ErrUsernameInUse:
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusConflict)
+		case errorsUtils.ErrUsernameInvalid:
+			return response.PersonalizedErr(c, err.Error(), fiber.StatusBadRequest)
+		}
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("User not found for update", map[string]interface{}{
 				"userID": id,
@@ -241,6 +257,8 @@ func (uc *UserController) DeleteUser(c fiber.Ctx) error {
 		return response.ErrUUIDParse(c)
 	}
 
+	before := uc.userSnapshot(userUUID)
+
 	if err = uc.UserService.DeleteUser(userUUID); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("User not found for deletion", map[string]interface{}{
@@ -264,6 +282,8 @@ func (uc *UserController) DeleteUser(c fiber.Ctx) error {
 		"method": c.Method(),
 	})
 
+	uc.AuditService.Record(newAuditContext(c), models.AuditUserDelete, models.AuditTargetUser, id, before, nil)
+
 	return response.Standard(c, "DELETED", nil)
 }
 
@@ -303,6 +323,8 @@ func (uc *UserController) RestoreUser(c fiber.Ctx) error {
 		"method": c.Method(),
 	})
 
+	uc.AuditService.Record(newAuditContext(c), models.AuditUserRestore, models.AuditTargetUser, id, nil, uc.userSnapshot(userUUID))
+
 	return response.Standard(c, "RESTORED", nil)
 }
 
@@ -377,3 +399,13 @@ func (uc *UserController) ChangeUserAvatar(c fiber.Ctx) error {
 	// Respuesta exitosa
 	return response.Standard(c, "Avatar uploaded successfully", nil)
 }
+
+// userSnapshot retrieves a user for the audit log, nil if it cannot be found.
+func (uc *UserController) userSnapshot(userID uuid.UUID) *response.UserResponse {
+	user, err := uc.UserService.GetUserResponseByID(userID)
+	if err != nil {
+		return nil
+	}
+
+	return &user
+}
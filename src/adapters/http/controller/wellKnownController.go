@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"github.com/Dialosoft/src/pkg/utils/jsonWebToken"
+	"github.com/gofiber/fiber/v3"
+)
+
+type WellKnownController struct {
+	JwtKeys *jsonWebToken.KeySet
+}
+
+func NewWellKnownController(jwtKeys *jsonWebToken.KeySet) *WellKnownController {
+	return &WellKnownController{JwtKeys: jwtKeys}
+}
+
+// GetJWKS returns the public keys verifying the tokens, in the JSON Web Key Set format other services expect.
+func (wc *WellKnownController) GetJWKS(c fiber.Ctx) error {
+	// other services fetch the set again when a token carries a kid they do not know yet
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(wc.JwtKeys.JWKS())
+}
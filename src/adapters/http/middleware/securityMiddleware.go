@@ -1,31 +1,48 @@
 package middleware
 
 import (
+	"errors"
+	"slices"
 	"strings"
 
 	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
 	"github.com/Dialosoft/src/pkg/utils/jsonWebToken"
 	"github.com/Dialosoft/src/pkg/utils/logger"
 	"github.com/gofiber/fiber/v3"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type SecurityMiddleware struct {
-	AuthService  services.AuthService
-	CacheService services.CacheService
-	JwtKey       string
+	AuthService          services.AuthService
+	CacheService         services.CacheService
+	PersonalTokenService services.PersonalTokenService
+	BanService           services.BanService
+	JwtKeys              *jsonWebToken.KeySet
+	RequireVerifiedEmail bool
 }
 
-func NewSecurityMiddleware(authService services.AuthService, cacheService services.CacheService, jwtKey string) *SecurityMiddleware {
-	return &SecurityMiddleware{AuthService: authService, CacheService: cacheService, JwtKey: jwtKey}
+func NewSecurityMiddleware(authService services.AuthService, cacheService services.CacheService, personalTokenService services.PersonalTokenService, banService services.BanService, jwtKeys *jsonWebToken.KeySet, requireVerifiedEmail bool) *SecurityMiddleware {
+	return &SecurityMiddleware{
+		AuthService:          authService,
+		CacheService:         cacheService,
+		PersonalTokenService: personalTokenService,
+		BanService:           banService,
+		JwtKeys:              jwtKeys,
+		RequireVerifiedEmail: requireVerifiedEmail,
+	}
 }
 
 // GetAndVerifyAccessToken retrieves the access token from the Authorization header,
 // checks its format, and verifies the token. If the token is invalid, expired, or missing,
 // appropriate error responses are returned. If valid, the user's ID and role are extracted
 // from the token and stored in the request context for further use.
+// A personal access token is accepted instead of an access token if it has the scope of the request.
+// Requests of banned users are refused, telling them the reason and the end of the ban.
 func (sm *SecurityMiddleware) GetAndVerifyAccessToken() fiber.Handler {
 	return func(c fiber.Ctx) error {
 		accessTokenHeader := c.Get("Authorization")
@@ -47,7 +64,17 @@ func (sm *SecurityMiddleware) GetAndVerifyAccessToken() fiber.Handler {
 
 		accessToken := accessTokenParts[1]
 
-		claimsAccess, err := jsonWebToken.ValidateJWT(accessToken, sm.JwtKey)
+		if strings.HasPrefix(accessToken, services.PersonalTokenPrefix) {
+			if ok, err := sm.authenticatePersonalToken(c, accessToken); !ok {
+				return err
+			}
+			if ok, err := sm.checkBan(c); !ok {
+				return err
+			}
+			return c.Next()
+		}
+
+		claimsAccess, err := jsonWebToken.ValidateJWT(accessToken, sm.JwtKeys)
 		if err != nil {
 			if err == jwt.ErrTokenExpired {
 				logger.Warn("Access token expired", map[string]interface{}{
@@ -81,8 +108,23 @@ func (sm *SecurityMiddleware) GetAndVerifyAccessToken() fiber.Handler {
 			return response.PersonalizedErr(c, "Error in token: claims", fiber.StatusForbidden)
 		}
 
+		sessionID, _ := claimsAccess["sid"].(string)
+		if sessionID != "" && sm.CacheService.IsSessionRevoked(sessionID) {
+			logger.Warn("Access token belongs to a revoked session", map[string]interface{}{
+				"userID":    userID,
+				"sessionID": sessionID,
+				"route":     c.Path(),
+			})
+			return response.PersonalizedErr(c, "Session has been revoked", fiber.StatusUnauthorized)
+		}
+
 		c.Locals("userID", userID)
 		c.Locals("roleID", roleID)
+		c.Locals("sessionID", sessionID)
+
+		if ok, err := sm.checkBan(c); !ok {
+			return err
+		}
 
 		logger.Info("Access token verified", map[string]interface{}{
 			"userID": userID,
@@ -97,8 +139,13 @@ func (sm *SecurityMiddleware) GetAndVerifyAccessToken() fiber.Handler {
 // VerifyRefreshToken checks the presence of a refresh token in the X-Refresh-Token header,
 // validates it using JWT, and checks if the token has been blacklisted. If the token is invalid
 // or blacklisted, an error response is returned. If valid, the request proceeds.
+// Requests authenticated by a personal access token have no refresh token and are let through.
 func (sm *SecurityMiddleware) VerifyRefreshToken() fiber.Handler {
 	return func(c fiber.Ctx) error {
+		if isPersonalTokenRequest(c) {
+			return c.Next()
+		}
+
 		refreshToken := c.Get("X-Refresh-Token")
 		if refreshToken == "" {
 			logger.Warn("Refresh token header missing", map[string]interface{}{
@@ -107,7 +154,7 @@ func (sm *SecurityMiddleware) VerifyRefreshToken() fiber.Handler {
 			return response.ErrUnauthorizedHeader(c)
 		}
 
-		_, err := jsonWebToken.ValidateJWT(refreshToken, sm.JwtKey)
+		_, err := jsonWebToken.ValidateJWT(refreshToken, sm.JwtKeys)
 		if err != nil {
 			logger.Error("Refresh token validation error", map[string]interface{}{
 				"error": err.Error(),
@@ -134,6 +181,66 @@ func (sm *SecurityMiddleware) VerifyRefreshToken() fiber.Handler {
 	}
 }
 
+// SessionRequired refuses the requests authenticated by a personal access token, for the routes that
+// manage the account itself, its sessions and its tokens. It must run after GetAndVerifyAccessToken.
+func (sm *SecurityMiddleware) SessionRequired() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if isPersonalTokenRequest(c) {
+			logger.Warn("Personal access token used on a route that needs a session", map[string]interface{}{
+				"tokenID": c.Locals("personalTokenID"),
+				"route":   c.Path(),
+			})
+			return response.PersonalizedErr(c, errorsUtils.ErrPersonalTokenNotAllowed.Error(), fiber.StatusForbidden)
+		}
+
+		return c.Next()
+	}
+}
+
+// VerifiedEmailRequired ensures that the user has confirmed their email address when the
+// RequireVerifiedEmail policy is enabled. It must run after GetAndVerifyAccessToken.
+// Users who have not confirmed their email address get a forbidden error.
+func (sm *SecurityMiddleware) VerifiedEmailRequired() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !sm.RequireVerifiedEmail {
+			return c.Next()
+		}
+
+		userID, ok := c.Locals("userID").(string)
+		if !ok || userID == "" {
+			logger.Error("Invalid userID format in context", map[string]interface{}{
+				"route": c.Path(),
+			})
+			return response.ErrUnauthorized(c)
+		}
+
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return response.ErrUUIDParse(c)
+		}
+
+		verified, err := sm.AuthService.IsEmailVerified(userUUID)
+		if err != nil {
+			logger.Error("Error checking email verification", map[string]interface{}{
+				"userID": userID,
+				"error":  err.Error(),
+				"route":  c.Path(),
+			})
+			return response.ErrInternalServer(c)
+		}
+
+		if !verified {
+			logger.Warn("Unverified email address", map[string]interface{}{
+				"userID": userID,
+				"route":  c.Path(),
+			})
+			return response.PersonalizedErr(c, errorsUtils.ErrEmailNotVerified.Error(), fiber.StatusForbidden)
+		}
+
+		return c.Next()
+	}
+}
+
 // RoleRequiredByName ensures that the user has the required role by name to access the route.
 // It retrieves the user's role from the context and compares it with the required role.
 // If the role doesn't match or is missing, an error is returned.
@@ -156,6 +263,10 @@ func (sm *SecurityMiddleware) RoleRequiredByName(roleRequired string) fiber.Hand
 			return response.PersonalizedErr(c, "Error in token: claims", fiber.StatusForbidden)
 		}
 
+		if !personalTokenHasScope(c, models.ScopeAdmin) {
+			return response.PersonalizedErr(c, errorsUtils.ErrPersonalTokenScope.Error(), fiber.StatusForbidden)
+		}
+
 		roleName, err := sm.AuthService.GetRoleInformationByRoleID(roleIDString)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
@@ -208,6 +319,10 @@ func (sm *SecurityMiddleware) RoleRequiredByID(roleRequiredID string) fiber.Hand
 			return response.PersonalizedErr(c, "Error in token: claims", fiber.StatusForbidden)
 		}
 
+		if !personalTokenHasScope(c, models.ScopeAdmin) {
+			return response.PersonalizedErr(c, errorsUtils.ErrPersonalTokenScope.Error(), fiber.StatusForbidden)
+		}
+
 		if roleIDString != roleRequiredID {
 			logger.Warn("Insufficient role permissions", map[string]interface{}{
 				"requiredRoleID": roleRequiredID,
@@ -276,7 +391,14 @@ func (sm *SecurityMiddleware) GetRoleFromToken() fiber.Handler {
 
 		accessToken := accessTokenParts[1]
 
-		claimsAccess, err := jsonWebToken.ValidateJWT(accessToken, sm.JwtKey)
+		if strings.HasPrefix(accessToken, services.PersonalTokenPrefix) {
+			if ok, err := sm.authenticatePersonalToken(c, accessToken); !ok {
+				return err
+			}
+			return c.Next()
+		}
+
+		claimsAccess, err := jsonWebToken.ValidateJWT(accessToken, sm.JwtKeys)
 		if err != nil {
 			if err == jwt.ErrTokenExpired {
 				logger.Warn("Access token expired", map[string]interface{}{
@@ -302,6 +424,9 @@ func (sm *SecurityMiddleware) GetRoleFromToken() fiber.Handler {
 		}
 
 		c.Locals("roleID", roleID)
+		if userID, ok := claimsAccess["sub"].(string); ok {
+			c.Locals("userID", userID)
+		}
 
 		logger.Info("Role obteneid successfully", map[string]interface{}{
 			"roleID": roleID,
@@ -311,3 +436,96 @@ func (sm *SecurityMiddleware) GetRoleFromToken() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// authenticatePersonalToken authenticates a request made with a personal access token, storing its user,
+// the role of the user and the token in the request context. The token must have the scope of the method:
+// read for GET requests, write for the others.
+// Returns false with the response already sent if the token is refused.
+func (sm *SecurityMiddleware) authenticatePersonalToken(c fiber.Ctx, token string) (bool, error) {
+	tokenEntity, userEntity, err := sm.PersonalTokenService.Authenticate(token)
+	if err != nil {
+		switch err {
+		case errorsUtils.ErrPersonalTokenInvalid:
+			logger.Warn("Invalid personal access token", map[string]interface{}{
+				"route": c.Path(),
+			})
+			return false, response.PersonalizedErr(c, err.Error(), fiber.StatusUnauthorized)
+		}
+		logger.Error("Personal access token validation error", map[string]interface{}{
+			"error": err.Error(),
+			"route": c.Path(),
+		})
+		return false, response.ErrInternalServer(c)
+	}
+
+	scope := models.ScopeWrite
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		scope = models.ScopeRead
+	}
+	if !tokenEntity.HasScope(scope) {
+		logger.Warn("Personal access token used without the scope of the request", map[string]interface{}{
+			"tokenID": tokenEntity.ID,
+			"scope":   scope,
+			"route":   c.Path(),
+		})
+		return false, response.PersonalizedErr(c, errorsUtils.ErrPersonalTokenScope.Error(), fiber.StatusForbidden)
+	}
+
+	c.Locals("userID", userEntity.ID.String())
+	c.Locals("roleID", userEntity.RoleID.String())
+	c.Locals("sessionID", "")
+	c.Locals("personalTokenID", tokenEntity.ID.String())
+	c.Locals("personalTokenScopes", tokenEntity.ScopeList())
+
+	logger.Info("Personal access token verified", map[string]interface{}{
+		"userID":  userEntity.ID,
+		"tokenID": tokenEntity.ID,
+		"route":   c.Path(),
+	})
+
+	return true, nil
+}
+
+// checkBan refuses the request if the user stored in the request context is banned.
+// Returns false with the response already sent if the request is refused.
+func (sm *SecurityMiddleware) checkBan(c fiber.Ctx) (bool, error) {
+	userID, _ := c.Locals("userID").(string)
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return false, response.PersonalizedErr(c, "Error in token: claims", fiber.StatusForbidden)
+	}
+
+	if err := sm.BanService.CheckBan(userUUID); err != nil {
+		var banned *errorsUtils.BannedError
+		if errors.As(err, &banned) {
+			logger.Warn("Request of a banned user", map[string]interface{}{
+				"userID": userID,
+				"route":  c.Path(),
+			})
+			return false, response.ErrBanned(c, banned.Reason, banned.ExpiresAt)
+		}
+		logger.CaptureError(err, "Error checking the bans of a user", map[string]interface{}{
+			"userID": userID,
+			"route":  c.Path(),
+		})
+		return false, response.ErrInternalServer(c)
+	}
+
+	return true, nil
+}
+
+// isPersonalTokenRequest reports whether the request was authenticated by a personal access token.
+func isPersonalTokenRequest(c fiber.Ctx) bool {
+	tokenID, ok := c.Locals("personalTokenID").(string)
+	return ok && tokenID != ""
+}
+
+// personalTokenHasScope reports whether the request has a scope, always true when it was not made with a personal access token.
+func personalTokenHasScope(c fiber.Ctx, scope string) bool {
+	if !isPersonalTokenRequest(c) {
+		return true
+	}
+	scopes, _ := c.Locals("personalTokenScopes").([]string)
+	return slices.Contains(scopes, scope)
+}
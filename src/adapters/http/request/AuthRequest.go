@@ -1,16 +1,62 @@
 package request
 
 type RegisterRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Username   string `json:"username" validate:"required,username"`
+	Email      string `json:"email" validate:"required,email,max=100"`
+	Password   string `json:"password" validate:"required,password"`
+	Locale     string `json:"locale" validate:"omitempty,max=10"`
+	DeviceName string `json:"deviceName" validate:"omitempty,max=100"`
 }
 
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	// Identifier is the username or the email of the account, Username is accepted for older clients
+	Identifier string `json:"identifier" validate:"required_without=Username,omitempty,max=255"`
+	Username   string `json:"username" validate:"omitempty,max=255"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"deviceName" validate:"omitempty,max=100"`
 }
 
 type RefreshToken struct {
-	Refresh string `json:"refreshToken"`
+	Refresh string `json:"refreshToken" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type MFASetupRequest struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken   string `json:"mfaToken" validate:"required"`
+	Code       string `json:"code" validate:"required,max=32"`
+	DeviceName string `json:"deviceName" validate:"omitempty,max=100"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required,max=255"`
+	NewPassword     string `json:"newPassword" validate:"required,password"`
+}
+
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required,max=255"`
+	NewEmail        string `json:"newEmail" validate:"required,email,max=100"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
 }
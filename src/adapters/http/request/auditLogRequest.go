@@ -0,0 +1,15 @@
+package request
+
+// AuditLogQuery filters the audit log, the empty fields do not filter.
+// From and To are RFC 3339 times, To is excluded.
+type AuditLogQuery struct {
+	ActorID    string `query:"actorID" validate:"omitempty,uuid"`
+	Action     string `query:"action" validate:"max=100"`
+	TargetType string `query:"targetType" validate:"max=50"`
+	TargetID   string `query:"targetID" validate:"max=100"`
+	From       string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Limit      int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset     int    `query:"offset" validate:"omitempty,min=0"`
+	Format     string `query:"format" validate:"omitempty,oneof=csv json"`
+}
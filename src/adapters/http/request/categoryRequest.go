@@ -1,7 +1,7 @@
 package request
 
 type NewCategory struct {
-	Name           *string  `json:"name"`
-	Description    *string  `json:"description"`
-	RolesAllowedID []string `json:"rolesAllowedID"`
+	Name           *string  `json:"name" validate:"omitempty,min=3,max=100"`
+	Description    *string  `json:"description" validate:"omitempty,max=255"`
+	RolesAllowedID []string `json:"rolesAllowedID" validate:"omitempty,dive,uuid"`
 }
@@ -0,0 +1,10 @@
+package request
+
+type NewComment struct {
+	ParentID *string `json:"parentID" validate:"omitempty,uuid"`
+	Content  string  `json:"content" validate:"required,max=10000"`
+}
+
+type UpdateComment struct {
+	Content string `json:"content" validate:"required,max=10000"`
+}
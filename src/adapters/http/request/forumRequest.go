@@ -1,10 +1,20 @@
 package request
 
 type NewForum struct {
-	Name         *string  `json:"name"`
-	Description  *string  `json:"description"`
-	Type         *string  `json:"type"`
+	Name         *string  `json:"name" validate:"required,min=3,max=100"`
+	Description  *string  `json:"description" validate:"omitempty,max=255"`
+	Type         *string  `json:"type" validate:"omitempty,min=1,max=100"`
 	IsActive     *bool    `json:"isActive"`
-	RolesAllowed []string `json:"rolesAllowed"`
-	CategoryID   *string  `json:"categoryID" validate:"required"`
+	RolesAllowed []string `json:"rolesAllowed" validate:"omitempty,dive,uuid"`
+	CategoryID   *string  `json:"categoryID" validate:"required,uuid"`
+}
+
+// UpdateForum holds the fields of a forum to change, the missing ones are kept.
+type UpdateForum struct {
+	Name         *string  `json:"name" validate:"omitempty,min=3,max=100"`
+	Description  *string  `json:"description" validate:"omitempty,max=255"`
+	Type         *string  `json:"type" validate:"omitempty,min=1,max=100"`
+	IsActive     *bool    `json:"isActive"`
+	RolesAllowed []string `json:"rolesAllowed" validate:"omitempty,dive,uuid"`
+	CategoryID   *string  `json:"categoryID" validate:"omitempty,uuid"`
 }
@@ -1,6 +1,21 @@
 package request
 
 type ChangeUserRole struct {
-	UserID string `json:"userID"`
-	RoleID string `json:"roleID"`
+	UserID string `json:"userID" validate:"required,uuid"`
+	RoleID string `json:"roleID" validate:"required,uuid"`
+}
+
+type UnlockLogin struct {
+	UserID    string `json:"userID" validate:"required_without=IPAddress,omitempty,uuid"`
+	IPAddress string `json:"ipAddress" validate:"omitempty,ip"`
+}
+
+type BanUser struct {
+	UserID        string `json:"userID" validate:"required,uuid"`
+	Reason        string `json:"reason" validate:"required,max=500"`
+	DurationHours int    `json:"durationHours" validate:"omitempty,min=1,max=87600"`
+}
+
+type UnbanUser struct {
+	UserID string `json:"userID" validate:"required,uuid"`
 }
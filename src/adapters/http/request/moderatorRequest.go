@@ -0,0 +1,9 @@
+package request
+
+import "github.com/google/uuid"
+
+// NewModerator assigns either a user or a role as moderator.
+type NewModerator struct {
+	UserID *uuid.UUID `json:"userID" validate:"required_without=RoleID,excluded_with=RoleID"`
+	RoleID *uuid.UUID `json:"roleID" validate:"required_without=UserID"`
+}
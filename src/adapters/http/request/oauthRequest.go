@@ -0,0 +1,7 @@
+package request
+
+type OAuthCallbackRequest struct {
+	Code       string `json:"code" validate:"required,max=2048"`
+	State      string `json:"state" validate:"required,max=255"`
+	DeviceName string `json:"deviceName" validate:"omitempty,max=100"`
+}
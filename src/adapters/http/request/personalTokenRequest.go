@@ -0,0 +1,9 @@
+package request
+
+type CreatePersonalTokenRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=read write admin"`
+
+	// ExpiresInDays is empty for a token that never expires.
+	ExpiresInDays int `json:"expiresInDays" validate:"omitempty,min=1,max=365"`
+}
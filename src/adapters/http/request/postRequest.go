@@ -1,23 +1,34 @@
 package request
 
 type NewPost struct {
-	UserID  string `json:"userID"`
-	ForumID string `json:"forumID"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	UserID  string `json:"userID" validate:"required,uuid"`
+	ForumID string `json:"forumID" validate:"required,uuid"`
+	Title   string `json:"title" validate:"required,min=3,max=255"`
+	Content string `json:"content" validate:"required,max=50000"`
 }
 
 type UpdatePostTitle struct {
-	Title  string `json:"title"`
-	PostID string `json:"postID"`
+	Title  string `json:"title" validate:"required,min=3,max=255"`
+	PostID string `json:"postID" validate:"required,uuid"`
 }
 
 type UpdatePostContent struct {
-	Content string `json:"content"`
-	PostID  string `json:"postID"`
+	Content string `json:"content" validate:"required,max=50000"`
+	PostID  string `json:"postID" validate:"required,uuid"`
+}
+
+// MovePost moves a post to another forum, leaving a redirect stub in its previous forum when LeaveRedirect is set.
+type MovePost struct {
+	ForumID       string `json:"forumID" validate:"required,uuid"`
+	LeaveRedirect bool   `json:"leaveRedirect"`
+}
+
+// MergePosts merges a post into the thread of TargetID.
+type MergePosts struct {
+	TargetID string `json:"targetID" validate:"required,uuid"`
 }
 
 type LikeOrUnlikePost struct {
-	PostID string `json:"postID"`
-	UserID string `json:"userID"`
+	PostID string `json:"postID" validate:"required,uuid"`
+	UserID string `json:"userID" validate:"required,uuid"`
 }
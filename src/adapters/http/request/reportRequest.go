@@ -0,0 +1,15 @@
+package request
+
+// NewReport is the report of a post, a comment or a user.
+type NewReport struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam abuse harassment off_topic illegal other"`
+	Details string `json:"details" validate:"max=1000"`
+}
+
+// ResolveReport is the action a moderator takes on a report. Warnings and bans are sent to the user
+// with the note as their reason.
+type ResolveReport struct {
+	Action           string `json:"action" validate:"required,oneof=dismiss delete warn ban"`
+	Note             string `json:"note" validate:"required_if=Action warn,required_if=Action ban,max=500"`
+	BanDurationHours int    `json:"banDurationHours" validate:"omitempty,min=1,max=87600"`
+}
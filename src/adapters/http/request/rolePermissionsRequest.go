@@ -1,8 +1,5 @@
 package request
 
 type NewRolePermissions struct {
-	CanManageCategories *bool `json:"canManageCategories"`
-	CanManageForums     *bool `json:"canManageForums"`
-	CanManageRoles      *bool `json:"canManageRoles"`
-	CanManageUsers      *bool `json:"canManageUsers"`
+	Permissions []string `json:"permissions" validate:"required,dive,required"`
 }
@@ -1,8 +1,12 @@
 package request
 
 type NewRole struct {
-	RoleType   *string `json:"roleType"`
-	Permission *int    `json:"permission"`
+	RoleType   *string `json:"roleType" validate:"omitempty,min=1,max=250"`
+	Permission *int    `json:"permission" validate:"omitempty,min=0"`
 	AdminRole  *bool   `json:"adminRole"`
 	ModRole    *bool   `json:"modRole"`
 }
+
+type RoleRequireMFA struct {
+	RequireMFA *bool `json:"requireMFA" validate:"required"`
+}
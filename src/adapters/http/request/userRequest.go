@@ -1,19 +1,17 @@
 package request
 
 type UserRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required,username"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required,password"`
 }
 
 type UpdateUserRequest struct {
-	Username string `jso:"username"`
-	Email    string `json:"email"`
+	Username string `json:"username" validate:"omitempty,username"`
 }
 
 type NewUser struct {
-	Username *string `json:"username"`
-	Locked   *bool   `json:"locked"`
+	Username *string `json:"username" validate:"omitempty,username"`
 	Disable  *bool   `json:"disable"`
-	RoleID   *string `json:"userID"`
+	RoleID   *string `json:"userID" validate:"omitempty,uuid"`
 }
@@ -0,0 +1,28 @@
+package response
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditLogResponse struct {
+	ID         uint64          `json:"id"`
+	ActorID    *uuid.UUID      `json:"actorID"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   string          `json:"targetID"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IPAddress  string          `json:"ipAddress"`
+	RequestID  string          `json:"requestID"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+type AuditLogPageResponse struct {
+	Total   int64              `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+	Entries []AuditLogResponse `json:"entries"`
+}
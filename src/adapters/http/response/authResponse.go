@@ -7,6 +7,12 @@ type RegisterResponse struct {
 }
 
 type LoginResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	AccessToken           string   `json:"accessToken,omitempty"`
+	RefreshToken          string   `json:"refreshToken,omitempty"`
+	MFARequired           bool     `json:"mfaRequired,omitempty"`
+	MFAToken              string   `json:"mfaToken,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfaEnrollmentRequired,omitempty"`
+	RecoveryCodes         []string `json:"recoveryCodes,omitempty"`
+	MustChangePassword    bool     `json:"mustChangePassword,omitempty"`
+	PasswordResetToken    string   `json:"passwordResetToken,omitempty"`
 }
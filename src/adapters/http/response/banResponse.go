@@ -0,0 +1,19 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BanResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"userID"`
+	Reason    string     `json:"reason"`
+	IssuedBy  uuid.UUID  `json:"issuedBy"`
+	StartsAt  time.Time  `json:"startsAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	LiftedAt  *time.Time `json:"liftedAt,omitempty"`
+	LiftedBy  *uuid.UUID `json:"liftedBy,omitempty"`
+	Active    bool       `json:"active"`
+}
@@ -0,0 +1,29 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CommentResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	User      UserResponse      `json:"user"`
+	PostID    uuid.UUID         `json:"postID"`
+	ParentID  *uuid.UUID        `json:"parentID"`
+	Content   string            `json:"content"`
+	IsBest    bool              `json:"isBest"`
+	Votes     int64             `json:"votes"`
+	Voted     bool              `json:"voted"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Replies   []CommentResponse `json:"replies"`
+}
+
+type CommentThreadResponse struct {
+	PostID   uuid.UUID         `json:"postID"`
+	Total    int64             `json:"total"`
+	Limit    int               `json:"limit"`
+	Offset   int               `json:"offset"`
+	Comments []CommentResponse `json:"comments"`
+}
@@ -1,6 +1,11 @@
 package response
 
-import "github.com/gofiber/fiber/v3"
+import (
+	"time"
+
+	"github.com/Dialosoft/src/pkg/utils/validation"
+	"github.com/gofiber/fiber/v3"
+)
 
 type StandardError struct {
 	ErrorMessage string `json:"error"`
@@ -89,3 +94,32 @@ func PersonalizedErr(c fiber.Ctx, message string, status int) error {
 	}
 	return c.Status(status).JSON(err)
 }
+
+type BannedError struct {
+	ErrorMessage string     `json:"error"`
+	Reason       string     `json:"reason"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+}
+
+// ErrBanned tells a banned user why and until when, a nil expiresAt meaning until the ban is lifted.
+func ErrBanned(c fiber.Ctx, reason string, expiresAt *time.Time) error {
+	err := BannedError{
+		ErrorMessage: "The account is banned",
+		Reason:       reason,
+		ExpiresAt:    expiresAt,
+	}
+	return c.Status(fiber.StatusForbidden).JSON(err)
+}
+
+type ValidationError struct {
+	ErrorMessage string            `json:"error"`
+	Fields       validation.Errors `json:"fields"`
+}
+
+func ErrValidation(c fiber.Ctx, fields validation.Errors) error {
+	err := ValidationError{
+		ErrorMessage: "VALIDATION FAILED",
+		Fields:       fields,
+	}
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(err)
+}
@@ -0,0 +1,16 @@
+package response
+
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningURI"`
+}
+
+type MFAStatusResponse struct {
+	Enabled           bool  `json:"enabled"`
+	RequiredByRole    bool  `json:"requiredByRole"`
+	RecoveryCodesLeft int64 `json:"recoveryCodesLeft"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
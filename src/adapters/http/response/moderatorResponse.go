@@ -0,0 +1,16 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ModeratorResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	ForumID    *uuid.UUID `json:"forumID,omitempty"`
+	CategoryID *uuid.UUID `json:"categoryID,omitempty"`
+	UserID     *uuid.UUID `json:"userID,omitempty"`
+	RoleID     *uuid.UUID `json:"roleID,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
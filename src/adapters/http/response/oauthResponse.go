@@ -0,0 +1,14 @@
+package response
+
+import "time"
+
+type OAuthAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorizationURL"`
+}
+
+type IdentityResponse struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	LinkedAt    time.Time  `json:"linkedAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+}
@@ -0,0 +1,23 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PersonalTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// PersonalTokenCreatedResponse carries the value of a new token, the only time it is shown.
+type PersonalTokenCreatedResponse struct {
+	PersonalTokenResponse
+	Token string `json:"token"`
+}
@@ -8,16 +8,21 @@ import (
 )
 
 type PostResponse struct {
-	ID        uuid.UUID      `json:"id"`
-	User      UserResponse   `json:"user"`
-	Forum     ForumResponse  `json:"forumID"`
-	Title     string         `json:"title"`
-	Content   string         `json:"content"`
-	Views     uint32         `json:"views"`
-	Comments  uint32         `json:"comments"`
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `json:"deletedAt"`
+	ID           uuid.UUID      `json:"id"`
+	User         UserResponse   `json:"user"`
+	Forum        ForumResponse  `json:"forumID"`
+	Title        string         `json:"title"`
+	Content      string         `json:"content"`
+	Views        uint32         `json:"views"`
+	Comments     uint32         `json:"comments"`
+	Locked       bool           `json:"locked"`
+	Pinned       bool           `json:"pinned"`
+	PinnedAt     *time.Time     `json:"pinnedAt,omitempty"`
+	Announcement bool           `json:"announcement"`
+	RedirectTo   *uuid.UUID     `json:"redirectTo,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `json:"deletedAt"`
 }
 
 type SimplePostResponse struct {
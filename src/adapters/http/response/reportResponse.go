@@ -0,0 +1,39 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReportResponse struct {
+	ID           uuid.UUID             `json:"id"`
+	TargetType   string                `json:"targetType"`
+	TargetID     uuid.UUID             `json:"targetID"`
+	TargetUserID uuid.UUID             `json:"targetUserID"`
+	ForumID      *uuid.UUID            `json:"forumID,omitempty"`
+	Status       string                `json:"status"`
+	ReportCount  int                   `json:"reportCount"`
+	Action       string                `json:"action,omitempty"`
+	ActionNote   string                `json:"actionNote,omitempty"`
+	ResolvedBy   *uuid.UUID            `json:"resolvedBy,omitempty"`
+	ResolvedAt   *time.Time            `json:"resolvedAt,omitempty"`
+	Entries      []ReportEntryResponse `json:"entries,omitempty"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	UpdatedAt    time.Time             `json:"updatedAt"`
+}
+
+type ReportEntryResponse struct {
+	ID         uuid.UUID `json:"id"`
+	ReporterID uuid.UUID `json:"reporterID"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type ReportQueueResponse struct {
+	Total   int64            `json:"total"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+	Reports []ReportResponse `json:"reports"`
+}
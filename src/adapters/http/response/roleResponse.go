@@ -13,6 +13,7 @@ type RoleResponse struct {
 	Permission int            `json:"permission"`
 	AdminRole  bool           `json:"adminRole"`
 	ModRole    bool           `json:"modRole"`
+	RequireMFA bool           `json:"requireMFA"`
 	Email      string         `json:"email"`
 	CreatedAt  time.Time      `json:"createdAt"`
 	UpdatedAt  time.Time      `json:"updatedAt"`
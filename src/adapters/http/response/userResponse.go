@@ -8,14 +8,15 @@ import (
 )
 
 type UserResponse struct {
-	ID          uuid.UUID      `json:"id"`
-	Username    string         `json:"username"`
-	Email       string         `json:"email"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Banned      bool           `json:"banned"`
-	Role        RoleResponse   `json:"role"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `json:"deletedAt"`
+	ID            uuid.UUID      `json:"id"`
+	Username      string         `json:"username"`
+	Email         string         `json:"email"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	Banned        bool           `json:"banned"`
+	EmailVerified bool           `json:"emailVerified"`
+	Role          RoleResponse   `json:"role"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	DeletedAt     gorm.DeletedAt `json:"deletedAt"`
 }
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/gofiber/fiber/v3"
+)
+
+type AuditLogRouter struct {
+	AuditLogController *controller.AuditLogController
+}
+
+func NewAuditLogRouter(auditLogController *controller.AuditLogController) *AuditLogRouter {
+	return &AuditLogRouter{AuditLogController: auditLogController}
+}
+
+func (r *AuditLogRouter) SetupAuditLogRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware, permissionMiddleware *middleware.PermissionMiddleware) {
+	auditLogGroup := api.Group("/audit-log")
+
+	{
+		// protected routes by authenticated users and with permission
+
+		auditLogGroup.Get("/", r.AuditLogController.GetEntries,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), permissionMiddleware.Require(permissions.AuditRead))
+		auditLogGroup.Get("/export", r.AuditLogController.Export,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), permissionMiddleware.Require(permissions.AuditRead))
+	}
+}
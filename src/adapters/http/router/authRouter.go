@@ -20,5 +20,22 @@ func (r *AuthRouter) SetupAuthRoutes(api fiber.Router, middlewares *middleware.S
 		authGroup.Post("/register", r.AuthController.Register)
 		authGroup.Post("/login", r.AuthController.Login)
 		authGroup.Post("/refresh-token", r.AuthController.RefreshToken)
+		authGroup.Post("/forgot-password", r.AuthController.ForgotPassword)
+		authGroup.Post("/reset-password", r.AuthController.ResetPassword)
+		authGroup.Post("/verify-email", r.AuthController.VerifyEmail)
+		authGroup.Post("/change-password", r.AuthController.ChangePassword,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		authGroup.Post("/change-email", r.AuthController.ChangeEmail,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		authGroup.Post("/confirm-email-change", r.AuthController.ConfirmEmailChange)
+		authGroup.Post("/resend-verification", r.AuthController.ResendVerificationEmail,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		authGroup.Post("/logout", r.AuthController.Logout)
+		authGroup.Post("/logout-all", r.AuthController.LogoutAll,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		authGroup.Get("/sessions", r.AuthController.GetSessions,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		authGroup.Delete("/sessions/:id", r.AuthController.RevokeSession,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
 	}
 }
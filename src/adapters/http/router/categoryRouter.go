@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/Dialosoft/src/adapters/http/controller"
 	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/Dialosoft/src/domain/permissions"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -20,7 +21,7 @@ func (r *CategoryRouter) SetupCategoryRoutes(api fiber.Router, securityMiddlewar
 		securityMiddleware.GetAndVerifyAccessToken(),
 		securityMiddleware.VerifyRefreshToken(),
 		securityMiddleware.GetRoleFromToken(),
-		permissionMiddleware.CanManageCategories() /* permission middleware for categories */)
+		permissionMiddleware.Require(permissions.CategoryManage))
 
 	{
 		// public
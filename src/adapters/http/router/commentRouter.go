@@ -0,0 +1,49 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/gofiber/fiber/v3"
+)
+
+type CommentRouter struct {
+	CommentController *controller.CommentController
+}
+
+func NewCommentRouter(commentController *controller.CommentController) *CommentRouter {
+	return &CommentRouter{CommentController: commentController}
+}
+
+func (r *CommentRouter) SetupCommentRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware, permissionMiddleware *middleware.PermissionMiddleware) {
+	commentGroup := api.Group("/posts/:id/comments")
+
+	{
+		// public
+
+		commentGroup.Get("/", r.CommentController.GetCommentThread, middlewares.GetRoleFromToken())
+		commentGroup.Get("/:commentID", r.CommentController.GetCommentByID, middlewares.GetRoleFromToken())
+	}
+
+	{
+		// protected routes by authenticated users
+
+		commentGroup.Post("/", r.CommentController.CreateComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.VerifiedEmailRequired(),
+			permissionMiddleware.Require(permissions.CommentCreate))
+		commentGroup.Put("/:commentID", r.CommentController.UpdateComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		commentGroup.Delete("/:commentID", r.CommentController.DeleteComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		commentGroup.Put("/:commentID/restore", r.CommentController.RestoreComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		commentGroup.Put("/:commentID/vote", r.CommentController.VoteComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		commentGroup.Delete("/:commentID/vote", r.CommentController.UnvoteComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		commentGroup.Put("/:commentID/best", r.CommentController.MarkBestComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		commentGroup.Delete("/:commentID/best", r.CommentController.UnmarkBestComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+	}
+}
@@ -3,8 +3,8 @@ package router
 import (
 	"github.com/Dialosoft/src/adapters/http/controller"
 	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/Dialosoft/src/domain/permissions"
 	"github.com/gofiber/fiber/v3"
-	"github.com/google/uuid"
 )
 
 type ManagementRouter struct {
@@ -15,14 +15,34 @@ func NewManagementRouter(managementController *controller.ManagementController)
 	return &ManagementRouter{ManagementController: managementController}
 }
 
-func (r *ManagementRouter) SetupManagementRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware, defaultRoles map[string]uuid.UUID) {
+func (r *ManagementRouter) SetupManagementRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware, permissionMiddleware *middleware.PermissionMiddleware) {
 	managementGroup := api.Group("/management")
 
 	{
 		managementGroup.Post("/change-user-role", r.ManagementController.ChangeUserRole,
 			middlewares.GetAndVerifyAccessToken(),
 			middlewares.VerifyRefreshToken(),
-			middlewares.RoleRequiredByID(defaultRoles["administrator"].String()),
+			permissionMiddleware.Require(permissions.RoleManage),
+		)
+		managementGroup.Post("/unlock-login", r.ManagementController.UnlockLogin,
+			middlewares.GetAndVerifyAccessToken(),
+			middlewares.VerifyRefreshToken(),
+			permissionMiddleware.Require(permissions.UserManage),
+		)
+		managementGroup.Post("/ban-user", r.ManagementController.BanUser,
+			middlewares.GetAndVerifyAccessToken(),
+			middlewares.VerifyRefreshToken(),
+			permissionMiddleware.Require(permissions.UserBan),
+		)
+		managementGroup.Post("/unban-user", r.ManagementController.UnbanUser,
+			middlewares.GetAndVerifyAccessToken(),
+			middlewares.VerifyRefreshToken(),
+			permissionMiddleware.Require(permissions.UserBan),
+		)
+		managementGroup.Get("/get-user-bans/:id", r.ManagementController.GetUserBans,
+			middlewares.GetAndVerifyAccessToken(),
+			middlewares.VerifyRefreshToken(),
+			permissionMiddleware.Require(permissions.UserBan),
 		)
 		managementGroup.Get("/test", func(c fiber.Ctx) error {
 			return c.SendString("pudiste!")
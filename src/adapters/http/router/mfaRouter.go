@@ -0,0 +1,41 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/gofiber/fiber/v3"
+)
+
+type MFARouter struct {
+	MFAController *controller.MFAController
+}
+
+func NewMFARouter(mfaController *controller.MFAController) *MFARouter {
+	return &MFARouter{MFAController: mfaController}
+}
+
+func (r *MFARouter) SetupMFARoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware) {
+	mfaGroup := api.Group("/auth/mfa")
+
+	{
+		// second step of a login, authenticated by the mfa token returned by /auth/login
+
+		mfaGroup.Post("/setup-pending", r.MFAController.SetupPending)
+		mfaGroup.Post("/verify", r.MFAController.Verify)
+	}
+
+	{
+		// protected routes by authenticated users
+
+		mfaGroup.Get("/", r.MFAController.GetStatus,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		mfaGroup.Post("/setup", r.MFAController.Setup,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		mfaGroup.Post("/enable", r.MFAController.Enable,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		mfaGroup.Post("/disable", r.MFAController.Disable,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		mfaGroup.Post("/recovery-codes", r.MFAController.RegenerateRecoveryCodes,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+	}
+}
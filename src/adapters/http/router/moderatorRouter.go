@@ -0,0 +1,42 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/gofiber/fiber/v3"
+)
+
+type ModeratorRouter struct {
+	ModeratorController *controller.ModeratorController
+}
+
+func NewModeratorRouter(moderatorController *controller.ModeratorController) *ModeratorRouter {
+	return &ModeratorRouter{ModeratorController: moderatorController}
+}
+
+func (r *ModeratorRouter) SetupModeratorRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware, permissionMiddleware *middleware.PermissionMiddleware) {
+	forumModerators := api.Group("/forums/:id/moderators")
+	categoryModerators := api.Group("/categories/:id/moderators")
+
+	{
+		// public
+
+		forumModerators.Get("/", r.ModeratorController.GetForumModerators)
+		categoryModerators.Get("/", r.ModeratorController.GetCategoryModerators)
+		api.Get("/users/:id/moderated-forums", r.ModeratorController.GetModeratedForums)
+	}
+
+	{
+		// protected routes by authenticated users and with permission
+
+		forumModerators.Post("/", r.ModeratorController.AddForumModerator,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), permissionMiddleware.Require(permissions.ForumManage))
+		forumModerators.Delete("/:moderatorID", r.ModeratorController.RemoveForumModerator,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), permissionMiddleware.Require(permissions.ForumManage))
+		categoryModerators.Post("/", r.ModeratorController.AddCategoryModerator,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), permissionMiddleware.Require(permissions.CategoryManage))
+		categoryModerators.Delete("/:moderatorID", r.ModeratorController.RemoveCategoryModerator,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), permissionMiddleware.Require(permissions.CategoryManage))
+	}
+}
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/gofiber/fiber/v3"
+)
+
+type OAuthRouter struct {
+	OAuthController *controller.OAuthController
+}
+
+func NewOAuthRouter(oauthController *controller.OAuthController) *OAuthRouter {
+	return &OAuthRouter{OAuthController: oauthController}
+}
+
+func (r *OAuthRouter) SetupOAuthRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware) {
+	oauthGroup := api.Group("/auth/oauth")
+
+	{
+		// public routes, the frontend sends the code and the state the provider gave back to the callback
+
+		oauthGroup.Get("/providers", r.OAuthController.GetProviders)
+		oauthGroup.Get("/:provider/authorize", r.OAuthController.Authorize)
+		oauthGroup.Post("/:provider/callback", r.OAuthController.Callback)
+	}
+
+	{
+		// protected routes by authenticated users
+
+		oauthGroup.Get("/identities", r.OAuthController.GetIdentities,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		oauthGroup.Post("/:provider/link", r.OAuthController.LinkAuthorize,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		oauthGroup.Delete("/:provider", r.OAuthController.Unlink,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+	}
+}
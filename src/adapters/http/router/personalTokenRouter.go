@@ -0,0 +1,30 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/gofiber/fiber/v3"
+)
+
+type PersonalTokenRouter struct {
+	PersonalTokenController *controller.PersonalTokenController
+}
+
+func NewPersonalTokenRouter(personalTokenController *controller.PersonalTokenController) *PersonalTokenRouter {
+	return &PersonalTokenRouter{PersonalTokenController: personalTokenController}
+}
+
+func (r *PersonalTokenRouter) SetupPersonalTokenRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware) {
+	tokenGroup := api.Group("/auth/tokens")
+
+	{
+		// protected routes by authenticated users, a token cannot create or revoke tokens
+
+		tokenGroup.Get("/", r.PersonalTokenController.GetAllTokens,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		tokenGroup.Post("/", r.PersonalTokenController.CreateToken,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+		tokenGroup.Delete("/:id", r.PersonalTokenController.RevokeToken,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken(), middlewares.SessionRequired())
+	}
+}
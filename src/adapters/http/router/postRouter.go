@@ -3,8 +3,8 @@ package router
 import (
 	"github.com/Dialosoft/src/adapters/http/controller"
 	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/Dialosoft/src/domain/permissions"
 	"github.com/gofiber/fiber/v3"
-	"github.com/google/uuid"
 )
 
 type PostRouter struct {
@@ -15,7 +15,7 @@ func NewPostRouter(postController *controller.PostController) *PostRouter {
 	return &PostRouter{PostController: postController}
 }
 
-func (r *PostRouter) SetupPostRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware, defaultRoles map[string]uuid.UUID) {
+func (r *PostRouter) SetupPostRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware, permissionMiddleware *middleware.PermissionMiddleware) {
 	postGroup := api.Group("/posts") // middlewares.GetAndVerifyAccessToken(),
 	// middlewares.VerifyRefreshToken(),
 	postProtected := postGroup.Group("/protected", middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
@@ -28,7 +28,8 @@ func (r *PostRouter) SetupPostRoutes(api fiber.Router, middlewares *middleware.S
 		// postGroup.Get("/get-posts-by-user-id/:userID", r.PostController.GetPostsByUserID)
 		// postGroup.Get("/get-like-count/:id", r.PostController.GetPostNumberOfLikes)
 		// postGroup.Get("/get-post-likes-by-user-id/:userID", r.PostController.GetPostLikesByUserID)
-		postProtected.Post("/create-new-post", r.PostController.CreateNewPost)
+		postProtected.Post("/create-new-post", r.PostController.CreateNewPost, middlewares.VerifiedEmailRequired(),
+			permissionMiddleware.Require(permissions.PostCreate))
 		// postGroup.Put("/update-post-title/:id", r.PostController.UpdatePostTitle)
 		// postGroup.Put("/update-post-content/:id", r.PostController.UpdatePostContent)
 		// postGroup.Delete("/delete-post/:id", r.PostController.DeletePost)
@@ -36,4 +37,25 @@ func (r *PostRouter) SetupPostRoutes(api fiber.Router, middlewares *middleware.S
 		// postGroup.Put("/like-post", r.PostController.LikePost)
 		// postGroup.Put("/unlike-post", r.PostController.UnlikePost)
 	}
+
+	{
+		// protected routes by authenticated users, the permissions are checked in the forum of the post
+
+		postGroup.Put("/:id/lock", r.PostController.LockPost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		postGroup.Delete("/:id/lock", r.PostController.UnlockPost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		postGroup.Put("/:id/pin", r.PostController.PinPost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		postGroup.Delete("/:id/pin", r.PostController.UnpinPost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		postGroup.Put("/:id/announcement", r.PostController.AnnouncePost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		postGroup.Delete("/:id/announcement", r.PostController.UnannouncePost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		postGroup.Post("/:id/move", r.PostController.MovePost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		postGroup.Post("/:id/merge", r.PostController.MergePost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+	}
 }
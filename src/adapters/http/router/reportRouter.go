@@ -0,0 +1,41 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/gofiber/fiber/v3"
+)
+
+type ReportRouter struct {
+	ReportController *controller.ReportController
+}
+
+func NewReportRouter(reportController *controller.ReportController) *ReportRouter {
+	return &ReportRouter{ReportController: reportController}
+}
+
+func (r *ReportRouter) SetupReportRoutes(api fiber.Router, middlewares *middleware.SecurityMiddleware) {
+	reportGroup := api.Group("/reports")
+
+	{
+		// protected routes by authenticated users
+
+		api.Post("/posts/:id/report", r.ReportController.ReportPost,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		api.Post("/posts/:id/comments/:commentID/report", r.ReportController.ReportComment,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		api.Post("/users/:id/report", r.ReportController.ReportUser,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+	}
+
+	{
+		// protected routes by authenticated moderators, the service checks what they can review
+
+		reportGroup.Get("/", r.ReportController.GetQueue,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		reportGroup.Get("/:id", r.ReportController.GetReport,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+		reportGroup.Post("/:id/resolve", r.ReportController.ResolveReport,
+			middlewares.GetAndVerifyAccessToken(), middlewares.VerifyRefreshToken())
+	}
+}
@@ -3,9 +3,9 @@ package router
 import (
 	"github.com/Dialosoft/src/adapters/http/controller"
 	"github.com/Dialosoft/src/adapters/http/middleware"
+	"github.com/Dialosoft/src/domain/permissions"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/static"
-	"github.com/google/uuid"
 )
 
 type UserRouter struct {
@@ -16,7 +16,7 @@ func NewUserRouter(userController *controller.UserController) *UserRouter {
 	return &UserRouter{UserController: userController}
 }
 
-func (r *UserRouter) SetupUserRoutes(api fiber.Router, middleware *middleware.SecurityMiddleware, defaultRoles map[string]uuid.UUID) {
+func (r *UserRouter) SetupUserRoutes(api fiber.Router, middleware *middleware.SecurityMiddleware, permissionMiddleware *middleware.PermissionMiddleware) {
 
 	// free routes
 	userGroup := api.Group("/users")
@@ -34,9 +34,9 @@ func (r *UserRouter) SetupUserRoutes(api fiber.Router, middleware *middleware.Se
 
 	{
 		userProtectedForAuthenticatedUsersGroup.Delete("/delete-user/:id", r.UserController.DeleteUser,
-			middleware.RoleRequiredByID(defaultRoles["administrator"].String()))
-		userProtectedForAuthenticatedUsersGroup.Patch("/restore-user/:id", r.UserController.RestoreUser, r.UserController.DeleteUser,
-			middleware.RoleRequiredByID(defaultRoles["administrator"].String()))
+			permissionMiddleware.Require(permissions.UserManage))
+		userProtectedForAuthenticatedUsersGroup.Patch("/restore-user/:id", r.UserController.RestoreUser,
+			permissionMiddleware.Require(permissions.UserManage))
 	}
 
 	// protectd routes by self user
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/Dialosoft/src/adapters/http/controller"
+	"github.com/gofiber/fiber/v3"
+)
+
+type WellKnownRouter struct {
+	WellKnownController *controller.WellKnownController
+}
+
+func NewWellKnownRouter(wellKnownController *controller.WellKnownController) *WellKnownRouter {
+	return &WellKnownRouter{WellKnownController: wellKnownController}
+}
+
+// SetupWellKnownRoutes registers the public documents at the root of the server, outside of the versioned API.
+func (r *WellKnownRouter) SetupWellKnownRoutes(app fiber.Router) {
+	wellKnownGroup := app.Group("/.well-known")
+
+	wellKnownGroup.Get("/jwks.json", r.WellKnownController.GetJWKS)
+}
@@ -0,0 +1,21 @@
+package mapper
+
+import (
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func AuditLogEntityToAuditLogResponse(entry *models.AuditLogEntity) response.AuditLogResponse {
+	return response.AuditLogResponse{
+		ID:         entry.ID,
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		IPAddress:  entry.IPAddress,
+		RequestID:  entry.RequestID,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
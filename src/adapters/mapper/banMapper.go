@@ -0,0 +1,22 @@
+package mapper
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func BanEntityToBanResponse(ban *models.BanEntity) response.BanResponse {
+	return response.BanResponse{
+		ID:        ban.ID,
+		UserID:    ban.UserID,
+		Reason:    ban.Reason,
+		IssuedBy:  ban.IssuedBy,
+		StartsAt:  ban.StartsAt,
+		ExpiresAt: ban.ExpiresAt,
+		LiftedAt:  ban.LiftedAt,
+		LiftedBy:  ban.LiftedBy,
+		Active:    ban.Active(time.Now()),
+	}
+}
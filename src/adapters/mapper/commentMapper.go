@@ -0,0 +1,20 @@
+package mapper
+
+import (
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func CommentEntityToCommentResponse(commentEntity *models.Comment) response.CommentResponse {
+	return response.CommentResponse{
+		ID:        commentEntity.ID,
+		User:      UserEntityToUserResponse(&commentEntity.User),
+		PostID:    commentEntity.PostID,
+		ParentID:  commentEntity.CommentID,
+		Content:   commentEntity.Content,
+		IsBest:    commentEntity.IsBest,
+		CreatedAt: commentEntity.CreatedAt,
+		UpdatedAt: commentEntity.UpdatedAt,
+		Replies:   []response.CommentResponse{},
+	}
+}
@@ -0,0 +1,15 @@
+package mapper
+
+import (
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func IdentityEntityToIdentityResponse(identity *models.IdentityEntity) response.IdentityResponse {
+	return response.IdentityResponse{
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		LinkedAt:    identity.CreatedAt,
+		LastLoginAt: identity.LastLoginAt,
+	}
+}
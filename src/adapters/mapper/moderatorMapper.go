@@ -0,0 +1,17 @@
+package mapper
+
+import (
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func ModeratorEntityToModeratorResponse(moderator *models.ModeratorEntity) response.ModeratorResponse {
+	return response.ModeratorResponse{
+		ID:         moderator.ID,
+		ForumID:    moderator.ForumID,
+		CategoryID: moderator.CategoryID,
+		UserID:     moderator.UserID,
+		RoleID:     moderator.RoleID,
+		CreatedAt:  moderator.CreatedAt,
+	}
+}
@@ -0,0 +1,18 @@
+package mapper
+
+import (
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func PersonalTokenEntityToPersonalTokenResponse(token *models.PersonalTokenEntity) response.PersonalTokenResponse {
+	return response.PersonalTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
@@ -7,30 +7,39 @@ import (
 
 func PostEntityToPostResponse(postEntity *models.Post) response.PostResponse {
 	return response.PostResponse{
-		ID:        postEntity.ID,
-		User:      UserEntityToUserResponse(&postEntity.User),
-		Forum:     ForumEntityToForumResponse(&postEntity.Forum),
-		Title:     postEntity.Title,
-		Content:   postEntity.Content,
-		Views:     postEntity.Views,
-		Comments:  postEntity.Comments,
-		CreatedAt: postEntity.CreatedAt,
-		UpdatedAt: postEntity.UpdatedAt,
-		DeletedAt: postEntity.DeletedAt,
+		ID:           postEntity.ID,
+		User:         UserEntityToUserResponse(&postEntity.User),
+		Forum:        ForumEntityToForumResponse(&postEntity.Forum),
+		Title:        postEntity.Title,
+		Content:      postEntity.Content,
+		Views:        postEntity.Views,
+		Comments:     postEntity.Comments,
+		Locked:       postEntity.Locked,
+		Pinned:       postEntity.PinnedAt != nil,
+		PinnedAt:     postEntity.PinnedAt,
+		Announcement: postEntity.Announcement,
+		RedirectTo:   postEntity.RedirectToID,
+		CreatedAt:    postEntity.CreatedAt,
+		UpdatedAt:    postEntity.UpdatedAt,
+		DeletedAt:    postEntity.DeletedAt,
 	}
 }
 
 func PostResponseToPostEntity(postResponse *response.PostResponse) *models.Post {
 	return &models.Post{
-		ID:        postResponse.ID,
-		UserID:    postResponse.User.ID,
-		User:      *UserResponseToUserEntity(&postResponse.User),
-		Title:     postResponse.Title,
-		Content:   postResponse.Content,
-		Views:     postResponse.Views,
-		Comments:  postResponse.Comments,
-		CreatedAt: postResponse.CreatedAt,
-		UpdatedAt: postResponse.UpdatedAt,
-		DeletedAt: postResponse.DeletedAt,
+		ID:           postResponse.ID,
+		UserID:       postResponse.User.ID,
+		User:         *UserResponseToUserEntity(&postResponse.User),
+		Title:        postResponse.Title,
+		Content:      postResponse.Content,
+		Views:        postResponse.Views,
+		Comments:     postResponse.Comments,
+		Locked:       postResponse.Locked,
+		PinnedAt:     postResponse.PinnedAt,
+		Announcement: postResponse.Announcement,
+		RedirectToID: postResponse.RedirectTo,
+		CreatedAt:    postResponse.CreatedAt,
+		UpdatedAt:    postResponse.UpdatedAt,
+		DeletedAt:    postResponse.DeletedAt,
 	}
 }
@@ -0,0 +1,36 @@
+package mapper
+
+import (
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func ReportEntityToReportResponse(report *models.ReportEntity) response.ReportResponse {
+	reportResponse := response.ReportResponse{
+		ID:           report.ID,
+		TargetType:   report.TargetType,
+		TargetID:     report.TargetID,
+		TargetUserID: report.TargetUserID,
+		ForumID:      report.ForumID,
+		Status:       report.Status,
+		ReportCount:  report.ReportCount,
+		Action:       report.Action,
+		ActionNote:   report.ActionNote,
+		ResolvedBy:   report.ResolvedBy,
+		ResolvedAt:   report.ResolvedAt,
+		CreatedAt:    report.CreatedAt,
+		UpdatedAt:    report.UpdatedAt,
+	}
+
+	for _, entry := range report.Entries {
+		reportResponse.Entries = append(reportResponse.Entries, response.ReportEntryResponse{
+			ID:         entry.ID,
+			ReporterID: entry.ReporterID,
+			Reason:     entry.Reason,
+			Details:    entry.Details,
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+
+	return reportResponse
+}
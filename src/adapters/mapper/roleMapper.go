@@ -15,6 +15,7 @@ func RoleDtoToRoleEntity(roleDto *dto.RoleDto) *models.RoleEntity {
 		Permission: roleDto.Permission,
 		AdminRole:  roleDto.AdminRole,
 		ModRole:    roleDto.ModRole,
+		RequireMFA: roleDto.RequireMFA,
 		CreatedAt:  roleDto.CreatedAt,
 		UpdatedAt:  roleDto.UpdatedAt,
 		DeletedAt:  roleDto.DeletedAt,
@@ -32,6 +33,7 @@ func RoleEntityToRoleDto(roleEntity *models.RoleEntity) *dto.RoleDto {
 		Permission: roleEntity.Permission,
 		AdminRole:  roleEntity.AdminRole,
 		ModRole:    roleEntity.ModRole,
+		RequireMFA: roleEntity.RequireMFA,
 		CreatedAt:  roleEntity.CreatedAt,
 		UpdatedAt:  roleEntity.UpdatedAt,
 		DeletedAt:  roleEntity.DeletedAt,
@@ -47,6 +49,7 @@ func RoleEntityToRoleResponse(roleEntity *models.RoleEntity) response.RoleRespon
 		Permission: roleEntity.Permission,
 		AdminRole:  roleEntity.AdminRole,
 		ModRole:    roleEntity.ModRole,
+		RequireMFA: roleEntity.RequireMFA,
 		CreatedAt:  roleEntity.CreatedAt,
 		UpdatedAt:  roleEntity.UpdatedAt,
 		DeletedAt:  roleEntity.DeletedAt,
@@ -60,6 +63,7 @@ func RoleResponseToRoleEntity(roleResponse *response.RoleResponse) *models.RoleE
 		Permission: roleResponse.Permission,
 		AdminRole:  roleResponse.AdminRole,
 		ModRole:    roleResponse.ModRole,
+		RequireMFA: roleResponse.RequireMFA,
 		CreatedAt:  roleResponse.CreatedAt,
 		UpdatedAt:  roleResponse.UpdatedAt,
 		DeletedAt:  roleResponse.DeletedAt,
@@ -0,0 +1,32 @@
+package mapper
+
+import (
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+func TokenEntityToSessionResponse(tokenEntity *models.TokenEntity) response.SessionResponse {
+	return response.SessionResponse{
+		ID:         tokenEntity.FamilyID,
+		DeviceName: tokenEntity.DeviceName,
+		UserAgent:  tokenEntity.UserAgent,
+		IPAddress:  tokenEntity.IPAddress,
+		LastUsedAt: tokenEntity.LastUsedAt,
+		CreatedAt:  tokenEntity.CreatedAt,
+		ExpiresAt:  tokenEntity.ExpiresAt,
+	}
+}
+
+func LoginResultDtoToLoginResponse(result dto.LoginResultDto) response.LoginResponse {
+	return response.LoginResponse{
+		AccessToken:           result.AccessToken,
+		RefreshToken:          result.RefreshToken,
+		MFARequired:           result.MFAToken != "",
+		MFAToken:              result.MFAToken,
+		MFAEnrollmentRequired: result.MFAEnrollmentRequired,
+		RecoveryCodes:         result.RecoveryCodes,
+		MustChangePassword:    result.PasswordResetToken != "",
+		PasswordResetToken:    result.PasswordResetToken,
+	}
+}
@@ -45,7 +45,6 @@ func UserRequestToUserDto(userRequest *request.UserRequest) *dto.UserDto {
 func UserUpdateRequestToUserDto(userRequest *request.UpdateUserRequest) *dto.UserDto {
 	userDto := dto.UserDto{
 		Username: userRequest.Username,
-		Email:    userRequest.Email,
 	}
 
 	return &userDto
@@ -53,31 +52,33 @@ func UserUpdateRequestToUserDto(userRequest *request.UpdateUserRequest) *dto.Use
 
 func UserEntityToUserResponse(userEntity *models.UserEntity) response.UserResponse {
 	return response.UserResponse{
-		ID:          userEntity.ID,
-		Username:    userEntity.Username,
-		Email:       userEntity.Email,
-		Name:        userEntity.Name,
-		Description: userEntity.Description,
-		Banned:      userEntity.Banned,
-		Role:        RoleEntityToRoleResponse(&userEntity.Role),
-		CreatedAt:   userEntity.CreatedAt,
-		UpdatedAt:   userEntity.UpdatedAt,
-		DeletedAt:   userEntity.DeletedAt,
+		ID:            userEntity.ID,
+		Username:      userEntity.Username,
+		Email:         userEntity.Email,
+		Name:          userEntity.Name,
+		Description:   userEntity.Description,
+		Banned:        userEntity.Banned,
+		EmailVerified: userEntity.EmailVerified,
+		Role:          RoleEntityToRoleResponse(&userEntity.Role),
+		CreatedAt:     userEntity.CreatedAt,
+		UpdatedAt:     userEntity.UpdatedAt,
+		DeletedAt:     userEntity.DeletedAt,
 	}
 }
 
 func UserResponseToUserEntity(userResponse *response.UserResponse) *models.UserEntity {
 	return &models.UserEntity{
-		ID:          userResponse.ID,
-		Username:    userResponse.Username,
-		Email:       userResponse.Email,
-		Name:        userResponse.Name,
-		Description: userResponse.Description,
-		Banned:      userResponse.Banned,
-		RoleID:      userResponse.Role.ID,
-		Role:        *RoleResponseToRoleEntity(&userResponse.Role),
-		CreatedAt:   userResponse.CreatedAt,
-		UpdatedAt:   userResponse.UpdatedAt,
-		DeletedAt:   userResponse.DeletedAt,
+		ID:            userResponse.ID,
+		Username:      userResponse.Username,
+		Email:         userResponse.Email,
+		Name:          userResponse.Name,
+		Description:   userResponse.Description,
+		Banned:        userResponse.Banned,
+		RoleID:        userResponse.Role.ID,
+		EmailVerified: userResponse.EmailVerified,
+		Role:          *RoleResponseToRoleEntity(&userResponse.Role),
+		CreatedAt:     userResponse.CreatedAt,
+		UpdatedAt:     userResponse.UpdatedAt,
+		DeletedAt:     userResponse.DeletedAt,
 	}
 }
@@ -0,0 +1,176 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeProvider is a minimal OpenID Connect provider to try the social login locally, without any account
+// at a real provider. It signs in whoever asks: the user is picked with the login_hint parameter of the
+// authorization request (e.g. &login_hint=alice), and a verified address <login>@example.test is made up for them.
+//
+// It follows the authorization-code flow with PKCE like a real provider, so the state and the verifier
+// are checked end to end. It must never be exposed in production.
+type FakeProvider struct {
+	issuer string
+
+	mu     sync.Mutex
+	codes  map[string]fakeCode
+	tokens map[string]Identity
+}
+
+type fakeCode struct {
+	identity      Identity
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+// NewFakeProvider returns a FakeProvider answering as the issuer, the URL it is served at.
+func NewFakeProvider(issuer string) *FakeProvider {
+	return &FakeProvider{
+		issuer: strings.TrimSuffix(issuer, "/"),
+		codes:  make(map[string]fakeCode),
+		tokens: make(map[string]Identity),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (fake *FakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                           fake.issuer,
+			"authorization_endpoint":           fake.issuer + "/authorize",
+			"token_endpoint":                   fake.issuer + "/token",
+			"userinfo_endpoint":                fake.issuer + "/userinfo",
+			"response_types_supported":         []string{"code"},
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	case "/authorize":
+		fake.authorize(w, r)
+	case "/token":
+		fake.token(w, r)
+	case "/userinfo":
+		fake.userInfo(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (fake *FakeProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "only the code flow with PKCE S256 is supported", http.StatusBadRequest)
+		return
+	}
+
+	login := strings.ToLower(query.Get("login_hint"))
+	if login == "" {
+		login = "fakeuser"
+	}
+
+	code := randomString()
+	fake.mu.Lock()
+	fake.codes[code] = fakeCode{
+		identity: Identity{
+			Subject:       "fake-" + login,
+			Email:         login + "@example.test",
+			EmailVerified: true,
+			Username:      login,
+			Name:          login,
+		},
+		clientID:      query.Get("client_id"),
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	fake.mu.Unlock()
+
+	values := redirectURI.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirectURI.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (fake *FakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+	}
+
+	fake.mu.Lock()
+	code, found := fake.codes[r.PostForm.Get("code")]
+	delete(fake.codes, r.PostForm.Get("code"))
+	fake.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || time.Now().After(code.expiresAt) || code.clientID != clientID ||
+		code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != code.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	accessToken := randomString()
+	fake.mu.Lock()
+	fake.tokens[accessToken] = code.identity
+	fake.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (fake *FakeProvider) userInfo(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	identity, found := fake.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	fake.mu.Unlock()
+
+	if !found {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"sub":                identity.Subject,
+		"email":              identity.Email,
+		"email_verified":     identity.EmailVerified,
+		"preferred_username": identity.Username,
+		"name":               identity.Name,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	raw := make([]byte, 24)
+	rand.Read(raw)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
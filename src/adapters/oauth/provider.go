@@ -0,0 +1,331 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Kinds of provider, they differ in how the profile of the user is read.
+const (
+	// KindOIDC is an OpenID Connect provider, its endpoints are discovered from its issuer.
+	KindOIDC = "oidc"
+
+	// KindGitHub is GitHub, which only speaks OAuth2 and keeps the email addresses apart from the profile.
+	KindGitHub = "github"
+)
+
+// Identity is the profile of a user authenticated by a provider.
+type Identity struct {
+	// Subject identifies the user at the provider, it never changes.
+	Subject string
+
+	Email         string
+	EmailVerified bool
+
+	// Username is the handle of the user at the provider, if it has one.
+	Username string
+	Name     string
+}
+
+// Provider signs users in with the OAuth2 authorization-code flow, protected by a state and PKCE.
+type Provider interface {
+	// Name returns the name the provider is configured with, e.g. "github".
+	Name() string
+
+	// AuthCodeURL returns the URL of the provider to send the user to.
+	// The state and the PKCE verifier must be kept to finish the flow with Exchange.
+	AuthCodeURL(state string, verifier string) (string, error)
+
+	// Exchange trades the code the provider sent back for the profile of the user.
+	Exchange(ctx context.Context, code string, verifier string) (Identity, error)
+}
+
+// ProviderConfig holds the settings of a provider.
+// The endpoints of the "github" and "google" providers are known and can be left empty.
+type ProviderConfig struct {
+	Name         string
+	Kind         string
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is where the provider sends the user back, with the code and the state.
+	RedirectURL string
+
+	// Issuer is the URL of an OpenID Connect provider, its endpoints are read from
+	// <Issuer>/.well-known/openid-configuration when they are not set.
+	Issuer string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	Scopes      []string
+}
+
+// NewProvider returns the Provider described by the config.
+func NewProvider(config ProviderConfig) (Provider, error) {
+	applyPreset(&config)
+
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("the client ID of the OAuth provider %s is missing", config.Name)
+	}
+	if config.RedirectURL == "" {
+		return nil, fmt.Errorf("the redirect URL of the OAuth provider %s is missing", config.Name)
+	}
+
+	switch config.Kind {
+	case KindOIDC:
+		if config.Issuer == "" && (config.AuthURL == "" || config.TokenURL == "" || config.UserInfoURL == "") {
+			return nil, fmt.Errorf("the OAuth provider %s needs an issuer or its endpoints", config.Name)
+		}
+	case KindGitHub:
+	default:
+		return nil, fmt.Errorf("unknown kind %q of the OAuth provider %s", config.Kind, config.Name)
+	}
+
+	return &oauth2Provider{config: config, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// applyPreset fills the settings of the well-known providers that are not set.
+func applyPreset(config *ProviderConfig) {
+	switch config.Name {
+	case "github":
+		if config.Kind == "" {
+			config.Kind = KindGitHub
+		}
+		if config.AuthURL == "" {
+			config.AuthURL = "https://github.com/login/oauth/authorize"
+		}
+		if config.TokenURL == "" {
+			config.TokenURL = "https://github.com/login/oauth/access_token"
+		}
+		if config.UserInfoURL == "" {
+			config.UserInfoURL = "https://api.github.com/user"
+		}
+		if len(config.Scopes) == 0 {
+			config.Scopes = []string{"read:user", "user:email"}
+		}
+	case "google":
+		if config.Issuer == "" {
+			config.Issuer = "https://accounts.google.com"
+		}
+	}
+
+	if config.Kind == "" {
+		config.Kind = KindOIDC
+	}
+	if config.Kind == KindOIDC && len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+}
+
+type oauth2Provider struct {
+	config ProviderConfig
+	client *http.Client
+
+	// the endpoints of OpenID Connect providers are discovered on first use, and again if it failed
+	mu          sync.Mutex
+	endpoints   *oauth2.Config
+	userInfoURL string
+}
+
+// Name implements Provider.
+func (provider *oauth2Provider) Name() string {
+	return provider.config.Name
+}
+
+// AuthCodeURL implements Provider.
+func (provider *oauth2Provider) AuthCodeURL(state string, verifier string) (string, error) {
+	oauthConfig, _, err := provider.oauthConfig(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	return oauthConfig.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange implements Provider.
+func (provider *oauth2Provider) Exchange(ctx context.Context, code string, verifier string) (Identity, error) {
+	oauthConfig, userInfoURL, err := provider.oauthConfig(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, provider.client)
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, err
+	}
+	client := oauthConfig.Client(ctx, token)
+
+	if provider.config.Kind == KindGitHub {
+		return gitHubIdentity(ctx, client, userInfoURL)
+	}
+	return oidcIdentity(ctx, client, userInfoURL)
+}
+
+// oauthConfig returns the oauth2.Config and the userinfo endpoint of the provider,
+// discovering them from the issuer the first time if needed.
+func (provider *oauth2Provider) oauthConfig(ctx context.Context) (*oauth2.Config, string, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.endpoints != nil {
+		return provider.endpoints, provider.userInfoURL, nil
+	}
+
+	config := provider.config
+	if config.AuthURL == "" || config.TokenURL == "" || config.UserInfoURL == "" {
+		discovered, err := discover(ctx, provider.client, config.Issuer)
+		if err != nil {
+			return nil, "", err
+		}
+		if config.AuthURL == "" {
+			config.AuthURL = discovered.AuthorizationEndpoint
+		}
+		if config.TokenURL == "" {
+			config.TokenURL = discovered.TokenEndpoint
+		}
+		if config.UserInfoURL == "" {
+			config.UserInfoURL = discovered.UserInfoEndpoint
+		}
+	}
+
+	provider.endpoints = &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  config.RedirectURL,
+		Scopes:       config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  config.AuthURL,
+			TokenURL: config.TokenURL,
+		},
+	}
+	provider.userInfoURL = config.UserInfoURL
+
+	return provider.endpoints, provider.userInfoURL, nil
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// discover reads the OpenID Connect discovery document of an issuer.
+func discover(ctx context.Context, client *http.Client, issuer string) (discoveryDocument, error) {
+	var document discoveryDocument
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, client, url, &document); err != nil {
+		return discoveryDocument{}, fmt.Errorf("discovering the OpenID provider %s: %w", issuer, err)
+	}
+
+	if strings.TrimSuffix(document.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return discoveryDocument{}, fmt.Errorf("the OpenID provider %s announces the issuer %s", issuer, document.Issuer)
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.UserInfoEndpoint == "" {
+		return discoveryDocument{}, fmt.Errorf("the OpenID provider %s does not announce all its endpoints", issuer)
+	}
+
+	return document, nil
+}
+
+// oidcIdentity reads the profile of a user from the userinfo endpoint of an OpenID Connect provider.
+func oidcIdentity(ctx context.Context, client *http.Client, userInfoURL string) (Identity, error) {
+	var claims struct {
+		Subject           string `json:"sub"`
+		Email             string `json:"email"`
+		EmailVerified     any    `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+	}
+	if err := getJSON(ctx, client, userInfoURL, &claims); err != nil {
+		return Identity{}, err
+	}
+	if claims.Subject == "" {
+		return Identity{}, errors.New("the provider did not return the subject of the user")
+	}
+
+	// some providers send the boolean as a string
+	verified := false
+	switch value := claims.EmailVerified.(type) {
+	case bool:
+		verified = value
+	case string:
+		verified, _ = strconv.ParseBool(value)
+	}
+
+	return Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: verified,
+		Username:      claims.PreferredUsername,
+		Name:          claims.Name,
+	}, nil
+}
+
+// gitHubIdentity reads the profile of a GitHub user and their primary verified email address.
+func gitHubIdentity(ctx context.Context, client *http.Client, userInfoURL string) (Identity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, client, userInfoURL, &user); err != nil {
+		return Identity{}, err
+	}
+	if user.ID == 0 {
+		return Identity{}, errors.New("the provider did not return the ID of the user")
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, strings.TrimSuffix(userInfoURL, "/")+"/emails", &emails); err != nil {
+		return Identity{}, err
+	}
+
+	identity := Identity{Subject: strconv.FormatInt(user.ID, 10), Username: user.Login, Name: user.Name}
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			identity.Email = email.Email
+			identity.EmailVerified = true
+		}
+	}
+
+	return identity, nil
+}
+
+// getJSON decodes the JSON answer to a GET request.
+func getJSON(ctx context.Context, client *http.Client, url string, out any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", url, response.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(out)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ActionTokenRepository defines a set of methods for managing the single-use tokens
+// sent to users to confirm actions, such as password resets.
+type ActionTokenRepository interface {
+
+	// Save stores a new ActionTokenEntity.
+	// Returns an error if the operation fails.
+	Save(actionToken models.ActionTokenEntity) error
+
+	// FindValidByHash retrieves an ActionTokenEntity for the given purpose by the hash of its token.
+	// Returns gorm.ErrRecordNotFound if the token does not exist, has expired or has already been used.
+	FindValidByHash(purpose string, tokenHash string) (*models.ActionTokenEntity, error)
+
+	// Consume marks an ActionTokenEntity as used.
+	// Returns gorm.ErrRecordNotFound if the token has already been used.
+	Consume(actionTokenID uuid.UUID) error
+
+	// InvalidateByUserID marks every unused token of a user for the given purpose as used.
+	// Returns an error if the update fails.
+	InvalidateByUserID(userID uuid.UUID, purpose string) error
+}
+
+type actionTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// Save implements ActionTokenRepository.
+func (repo *actionTokenRepositoryImpl) Save(actionToken models.ActionTokenEntity) error {
+	return repo.db.Create(&actionToken).Error
+}
+
+// FindValidByHash implements ActionTokenRepository.
+func (repo *actionTokenRepositoryImpl) FindValidByHash(purpose string, tokenHash string) (*models.ActionTokenEntity, error) {
+	var actionToken models.ActionTokenEntity
+	if err := repo.db.
+		Where("purpose = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?", purpose, tokenHash, time.Now()).
+		First(&actionToken).Error; err != nil {
+		return nil, err
+	}
+
+	return &actionToken, nil
+}
+
+// Consume implements ActionTokenRepository.
+func (repo *actionTokenRepositoryImpl) Consume(actionTokenID uuid.UUID) error {
+	// the condition on used_at makes concurrent uses of the same token fail
+	result := repo.db.Model(&models.ActionTokenEntity{}).
+		Where("id = ? AND used_at IS NULL", actionTokenID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// InvalidateByUserID implements ActionTokenRepository.
+func (repo *actionTokenRepositoryImpl) InvalidateByUserID(userID uuid.UUID, purpose string) error {
+	return repo.db.Model(&models.ActionTokenEntity{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
+
+func NewActionTokenRepository(db *gorm.DB) ActionTokenRepository {
+	return &actionTokenRepositoryImpl{db: db}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter selects entries of the audit log, the empty fields do not filter.
+type AuditLogFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditLogRepository defines a set of methods for appending to and reading the audit log.
+// The entries cannot be changed once stored.
+type AuditLogRepository interface {
+
+	// Create appends an entry to the audit log.
+	Create(entry models.AuditLogEntity) error
+
+	// FindAll retrieves a page of the entries matching filter, the most recent first,
+	// and the number of entries matching it.
+	FindAll(filter AuditLogFilter, limit int, offset int) ([]models.AuditLogEntity, int64, error)
+
+	// FindInBatches calls fn with the entries matching filter, the oldest first, batchSize at a time.
+	// It stops at the first error fn returns.
+	FindInBatches(filter AuditLogFilter, batchSize int, fn func(entries []models.AuditLogEntity) error) error
+}
+
+type auditLogRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// Create implements AuditLogRepository.
+func (repo *auditLogRepositoryImpl) Create(entry models.AuditLogEntity) error {
+	return repo.db.Create(&entry).Error
+}
+
+// FindAll implements AuditLogRepository.
+func (repo *auditLogRepositoryImpl) FindAll(filter AuditLogFilter, limit int, offset int) ([]models.AuditLogEntity, int64, error) {
+	var total int64
+	if err := auditLogsMatching(repo.db, filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLogEntity
+	err := auditLogsMatching(repo.db, filter).Order("id DESC").
+		Limit(limit).Offset(offset).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// FindInBatches implements AuditLogRepository.
+func (repo *auditLogRepositoryImpl) FindInBatches(filter AuditLogFilter, batchSize int, fn func(entries []models.AuditLogEntity) error) error {
+	var entries []models.AuditLogEntity
+	return auditLogsMatching(repo.db, filter).FindInBatches(&entries, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(entries)
+	}).Error
+}
+
+// auditLogsMatching scopes a query to the entries of the audit log matching filter.
+func auditLogsMatching(db *gorm.DB, filter AuditLogFilter) *gorm.DB {
+	query := db.Model(&models.AuditLogEntity{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	return query
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepositoryImpl{db: db}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BanRepository defines a set of methods for managing the bans of the users.
+// It keeps the Banned flag of the users in line with their bans.
+type BanRepository interface {
+
+	// Create stores a new BanEntity, lifting the active ban of the user if any, and returns its ID.
+	Create(ban models.BanEntity) (uuid.UUID, error)
+
+	// FindActive retrieves the ban of a user active at the given time.
+	// Returns gorm.ErrRecordNotFound if the user is not banned.
+	FindActive(userID uuid.UUID, at time.Time) (*models.BanEntity, error)
+
+	// FindAllByUserID retrieves every ban of a user, the most recent first.
+	FindAllByUserID(userID uuid.UUID) ([]models.BanEntity, error)
+
+	// Lift lifts the ban of a user active at the given time.
+	// Returns gorm.ErrRecordNotFound if the user is not banned.
+	Lift(userID uuid.UUID, liftedBy uuid.UUID, at time.Time) error
+
+	// ClearExpired clears the Banned flag of the users whose bans have all expired at the given time.
+	// Returns the IDs of these users.
+	ClearExpired(at time.Time) ([]uuid.UUID, error)
+}
+
+type banRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// Create implements BanRepository.
+func (repo *banRepositoryImpl) Create(ban models.BanEntity) (uuid.UUID, error) {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		err := activeBans(tx, ban.UserID, ban.StartsAt).
+			Updates(map[string]interface{}{"lifted_at": ban.StartsAt, "lifted_by": ban.IssuedBy}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&ban).Error; err != nil {
+			return err
+		}
+
+		return setBannedFlag(tx, ban.UserID, true)
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return ban.ID, nil
+}
+
+// FindActive implements BanRepository.
+func (repo *banRepositoryImpl) FindActive(userID uuid.UUID, at time.Time) (*models.BanEntity, error) {
+	var ban models.BanEntity
+	if err := activeBans(repo.db, userID, at).Order("created_at DESC").First(&ban).Error; err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+// FindAllByUserID implements BanRepository.
+func (repo *banRepositoryImpl) FindAllByUserID(userID uuid.UUID) ([]models.BanEntity, error) {
+	var bans []models.BanEntity
+	if err := repo.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&bans).Error; err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+// Lift implements BanRepository.
+func (repo *banRepositoryImpl) Lift(userID uuid.UUID, liftedBy uuid.UUID, at time.Time) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		result := activeBans(tx, userID, at).
+			Updates(map[string]interface{}{"lifted_at": at, "lifted_by": liftedBy})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return setBannedFlag(tx, userID, false)
+	})
+}
+
+// ClearExpired implements BanRepository.
+func (repo *banRepositoryImpl) ClearExpired(at time.Time) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.UserEntity{}).
+			Where("banned = ?", true).
+			Where("NOT EXISTS (?)", tx.Model(&models.BanEntity{}).
+				Select("1").
+				Where("bans.user_id = users.id AND lifted_at IS NULL AND starts_at <= ?", at).
+				Where("expires_at IS NULL OR expires_at > ?", at)).
+			Pluck("id", &userIDs).Error
+		if err != nil || len(userIDs) == 0 {
+			return err
+		}
+
+		return tx.Unscoped().Model(&models.UserEntity{}).
+			Where("id IN ?", userIDs).
+			Update("banned", false).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// activeBans scopes a query to the bans of a user active at the given time.
+func activeBans(db *gorm.DB, userID uuid.UUID, at time.Time) *gorm.DB {
+	return db.Model(&models.BanEntity{}).
+		Where("user_id = ? AND lifted_at IS NULL AND starts_at <= ?", userID, at).
+		Where("expires_at IS NULL OR expires_at > ?", at)
+}
+
+func setBannedFlag(tx *gorm.DB, userID uuid.UUID, banned bool) error {
+	return tx.Unscoped().Model(&models.UserEntity{}).Where("id = ?", userID).Update("banned", banned).Error
+}
+
+func NewBanRepository(db *gorm.DB) BanRepository {
+	return &banRepositoryImpl{db: db}
+}
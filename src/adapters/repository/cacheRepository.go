@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -22,9 +23,26 @@ type RedisRepository interface {
 	// Returns an error if the key does not exist or if there is a Redis error during deletion.
 	Delete(ctx context.Context, key string) error
 
+	// GetDel retrieves the value associated with the given key and removes the key, atomically.
+	// Returns redis.Nil if the key does not exist.
+	GetDel(ctx context.Context, key string) (string, error)
+
 	// Exists checks if the given key exists in Redis.
 	// Returns true if the key exists, false otherwise, along with an error if the operation fails.
 	Exists(ctx context.Context, key string) (bool, error)
+
+	// TTL returns the time left before the given key expires.
+	// Returns a negative duration if the key does not exist or has no expiration.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+
+	// AddToWindow records an event at the given time in the sliding window stored at key,
+	// forgetting the events older than the window.
+	// Returns the number of events in the window, including the new one.
+	AddToWindow(ctx context.Context, key string, at time.Time, window time.Duration) (int64, error)
+
+	// CountWindow returns the number of events recorded in the sliding window stored at key
+	// that are not older than the window.
+	CountWindow(ctx context.Context, key string, at time.Time, window time.Duration) (int64, error)
 }
 
 type redisRepositoyryImpl struct {
@@ -35,6 +53,10 @@ func (r *redisRepositoyryImpl) Get(ctx context.Context, key string) (string, err
 	return r.client.Get(ctx, key).Result()
 }
 
+func (r *redisRepositoyryImpl) GetDel(ctx context.Context, key string) (string, error) {
+	return r.client.GetDel(ctx, key).Result()
+}
+
 func (r *redisRepositoyryImpl) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
@@ -48,6 +70,29 @@ func (r *redisRepositoyryImpl) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+func (r *redisRepositoyryImpl) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.client.TTL(ctx, key).Result()
+}
+
+func (r *redisRepositoyryImpl) AddToWindow(ctx context.Context, key string, at time.Time, window time.Duration) (int64, error) {
+	// the window is a sorted set of events scored by their time in nanoseconds
+	pipe := r.client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixNano()), Member: strconv.FormatInt(at.UnixNano(), 10)})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(at.Add(-window).UnixNano(), 10))
+	count := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+func (r *redisRepositoyryImpl) CountWindow(ctx context.Context, key string, at time.Time, window time.Duration) (int64, error) {
+	min := strconv.FormatInt(at.Add(-window).UnixNano(), 10)
+	return r.client.ZCount(ctx, key, min, "+inf").Result()
+}
+
 func NewRedisRepository(redisConn *redis.Client) RedisRepository {
 	return &redisRepositoyryImpl{client: redisConn}
 }
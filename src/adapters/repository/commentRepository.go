@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CommentRepository defines a set of methods for managing the comments of a post.
+// Comments are threaded through Comment.CommentID, which points to the parent comment.
+type CommentRepository interface {
+
+	// FindByID retrieves a comment by its UUID, including its author.
+	// Returns gorm.ErrRecordNotFound if the comment does not exist or has been deleted.
+	FindByID(commentID uuid.UUID) (*models.Comment, error)
+
+	// FindByIDWithDeleted retrieves a comment by its UUID even if it has been soft deleted.
+	FindByIDWithDeleted(commentID uuid.UUID) (*models.Comment, error)
+
+	// FindRootsByPostID retrieves the top level comments of a post with pagination options.
+	// The comment marked as best answer always comes first.
+	FindRootsByPostID(postID uuid.UUID, limit, offset int) ([]*models.Comment, error)
+
+	// FindRepliesByParentIDs retrieves the direct replies of every given parent comment.
+	// The comment marked as best answer always comes first.
+	FindRepliesByParentIDs(parentIDs []uuid.UUID) ([]*models.Comment, error)
+
+	// CountRootsByPostID returns the number of top level comments of a post.
+	CountRootsByPostID(postID uuid.UUID) (int64, error)
+
+	// Create inserts a new comment and increments the comments counter of its post.
+	// Returns the created comment and an error if the operation fails.
+	Create(comment models.Comment) (*models.Comment, error)
+
+	// Update modifies the content of an existing comment.
+	// Returns gorm.ErrRecordNotFound if the comment does not exist.
+	Update(commentID uuid.UUID, content string) error
+
+	// Delete soft deletes a comment together with all of its replies and
+	// decrements the comments counter of the post by the number of comments removed.
+	Delete(commentID uuid.UUID) error
+
+	// Restore restores a soft deleted comment together with the replies that were
+	// deleted with it, and increments the comments counter of the post accordingly.
+	Restore(commentID uuid.UUID) error
+
+	// MarkBest flags a comment as the best answer of its post,
+	// clearing the flag of any other comment of the same post.
+	MarkBest(postID uuid.UUID, commentID uuid.UUID) error
+
+	// UnmarkBest clears the best answer flag of a comment.
+	UnmarkBest(commentID uuid.UUID) error
+}
+
+type commentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// FindByID implements CommentRepository.
+func (repo *commentRepositoryImpl) FindByID(commentID uuid.UUID) (*models.Comment, error) {
+	var comment models.Comment
+	if err := repo.db.Preload("User").Preload("User.Role").
+		Where("id = ?", commentID.String()).
+		First(&comment).Error; err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// FindByIDWithDeleted implements CommentRepository.
+func (repo *commentRepositoryImpl) FindByIDWithDeleted(commentID uuid.UUID) (*models.Comment, error) {
+	var comment models.Comment
+	if err := repo.db.Unscoped().
+		Where("id = ?", commentID.String()).
+		First(&comment).Error; err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// FindRootsByPostID implements CommentRepository.
+func (repo *commentRepositoryImpl) FindRootsByPostID(postID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+	var comments []*models.Comment
+	if err := repo.db.Preload("User").Preload("User.Role").
+		Where("post_id = ? AND comment_id IS NULL", postID.String()).
+		Order("is_best DESC, created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// FindRepliesByParentIDs implements CommentRepository.
+func (repo *commentRepositoryImpl) FindRepliesByParentIDs(parentIDs []uuid.UUID) ([]*models.Comment, error) {
+	var comments []*models.Comment
+	if len(parentIDs) == 0 {
+		return comments, nil
+	}
+
+	if err := repo.db.Preload("User").Preload("User.Role").
+		Where("comment_id IN ?", parentIDs).
+		Order("is_best DESC, created_at ASC").
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// CountRootsByPostID implements CommentRepository.
+func (repo *commentRepositoryImpl) CountRootsByPostID(postID uuid.UUID) (int64, error) {
+	var count int64
+	if err := repo.db.Model(&models.Comment{}).
+		Where("post_id = ? AND comment_id IS NULL", postID.String()).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Create implements CommentRepository.
+func (repo *commentRepositoryImpl) Create(comment models.Comment) (*models.Comment, error) {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Create(&comment).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Post{}).
+			Where("id = ?", comment.PostID).
+			UpdateColumn("comments", gorm.Expr("comments + ?", 1)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// Update implements CommentRepository.
+func (repo *commentRepositoryImpl) Update(commentID uuid.UUID, content string) error {
+	result := repo.db.Model(&models.Comment{}).
+		Where("id = ?", commentID).
+		Update("content", content)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete implements CommentRepository.
+func (repo *commentRepositoryImpl) Delete(commentID uuid.UUID) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var comment models.Comment
+		if err := tx.Where("id = ?", commentID).First(&comment).Error; err != nil {
+			return err
+		}
+
+		threadIDs, err := collectThreadIDs(tx, comment.ID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Comment{}).
+			Where("id IN ?", threadIDs).
+			Update("deleted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Model(&models.Post{}).
+			Where("id = ?", comment.PostID).
+			UpdateColumn("comments", gorm.Expr("GREATEST(comments - ?, 0)", result.RowsAffected)).Error
+	})
+}
+
+// Restore implements CommentRepository.
+func (repo *commentRepositoryImpl) Restore(commentID uuid.UUID) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var comment models.Comment
+		if err := tx.Unscoped().Where("id = ?", commentID).First(&comment).Error; err != nil {
+			return err
+		}
+		if !comment.DeletedAt.Valid {
+			return nil
+		}
+
+		threadIDs, err := collectThreadIDs(tx.Unscoped(), comment.ID)
+		if err != nil {
+			return err
+		}
+
+		// Only the replies removed together with this comment are brought back,
+		// replies deleted on their own before keep their state.
+		result := tx.Unscoped().Model(&models.Comment{}).
+			Where("id IN ? AND deleted_at = ?", threadIDs, comment.DeletedAt.Time).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Model(&models.Post{}).
+			Where("id = ?", comment.PostID).
+			UpdateColumn("comments", gorm.Expr("comments + ?", result.RowsAffected)).Error
+	})
+}
+
+// MarkBest implements CommentRepository.
+func (repo *commentRepositoryImpl) MarkBest(postID uuid.UUID, commentID uuid.UUID) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Comment{}).
+			Where("post_id = ? AND is_best = ?", postID, true).
+			Update("is_best", false).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Comment{}).
+			Where("id = ? AND post_id = ?", commentID, postID).
+			Update("is_best", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+// UnmarkBest implements CommentRepository.
+func (repo *commentRepositoryImpl) UnmarkBest(commentID uuid.UUID) error {
+	result := repo.db.Model(&models.Comment{}).
+		Where("id = ?", commentID).
+		Update("is_best", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// collectThreadIDs walks the reply tree under rootID level by level and
+// returns the IDs of the root comment and all of its descendants.
+func collectThreadIDs(db *gorm.DB, rootID uuid.UUID) ([]uuid.UUID, error) {
+	threadIDs := []uuid.UUID{rootID}
+	parents := []uuid.UUID{rootID}
+
+	for len(parents) > 0 {
+		var children []uuid.UUID
+		if err := db.Model(&models.Comment{}).
+			Where("comment_id IN ?", parents).
+			Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+
+		threadIDs = append(threadIDs, children...)
+		parents = children
+	}
+
+	return threadIDs, nil
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepositoryImpl{db: db}
+}
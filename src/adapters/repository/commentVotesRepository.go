@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommentVotesRepository interface {
+	CountByCommentIDs(commentIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	FindCommentIDsVotedByUser(userID uuid.UUID, commentIDs []uuid.UUID) ([]uuid.UUID, error)
+	Save(commentID uuid.UUID, userID uuid.UUID) error
+	Remove(commentID uuid.UUID, userID uuid.UUID) error
+}
+
+type commentVotesRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CountByCommentIDs implements CommentVotesRepository.
+func (repo *commentVotesRepositoryImpl) CountByCommentIDs(commentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		CommentID uuid.UUID
+		Votes     int64
+	}
+
+	counts := make(map[uuid.UUID]int64)
+	if len(commentIDs) == 0 {
+		return counts, nil
+	}
+
+	if err := repo.db.Model(&models.CommentVotes{}).
+		Select("comment_id, COUNT(*) AS votes").
+		Where("comment_id IN ?", commentIDs).
+		Group("comment_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.CommentID] = row.Votes
+	}
+	return counts, nil
+}
+
+// FindCommentIDsVotedByUser implements CommentVotesRepository.
+func (repo *commentVotesRepositoryImpl) FindCommentIDsVotedByUser(userID uuid.UUID, commentIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var voted []uuid.UUID
+	if len(commentIDs) == 0 {
+		return voted, nil
+	}
+
+	if err := repo.db.Model(&models.CommentVotes{}).
+		Where("user_id = ? AND comment_id IN ?", userID, commentIDs).
+		Pluck("comment_id", &voted).Error; err != nil {
+		return nil, err
+	}
+	return voted, nil
+}
+
+// Save implements CommentVotesRepository.
+func (repo *commentVotesRepositoryImpl) Save(commentID uuid.UUID, userID uuid.UUID) error {
+	return repo.db.Create(&models.CommentVotes{
+		CommentID: commentID,
+		UserID:    userID,
+	}).Error
+}
+
+// Remove implements CommentVotesRepository.
+func (repo *commentVotesRepositoryImpl) Remove(commentID uuid.UUID, userID uuid.UUID) error {
+	result := repo.db.Delete(models.CommentVotes{}, "comment_id = ? AND user_id = ?", commentID, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func NewCommentVotesRepository(db *gorm.DB) CommentVotesRepository {
+	return &commentVotesRepositoryImpl{db: db}
+}
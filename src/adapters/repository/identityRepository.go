@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository defines a set of methods for managing the accounts of the users at external providers.
+type IdentityRepository interface {
+
+	// Create stores a new IdentityEntity.
+	// Returns an error if the provider account or the provider of the user is already linked.
+	Create(identity models.IdentityEntity) error
+
+	// FindByProviderSubject retrieves the IdentityEntity of a provider account.
+	// Returns gorm.ErrRecordNotFound if the account is not linked to any user.
+	FindByProviderSubject(provider string, subject string) (*models.IdentityEntity, error)
+
+	// FindAllByUserID retrieves the identities of a user.
+	FindAllByUserID(userID uuid.UUID) ([]models.IdentityEntity, error)
+
+	// TouchLastLogin records that a user has just logged in with an identity.
+	TouchLastLogin(identityID uuid.UUID) error
+
+	// Delete removes the identity of a user at a provider.
+	// Returns gorm.ErrRecordNotFound if the user has not linked the provider.
+	Delete(userID uuid.UUID, provider string) error
+}
+
+type identityRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// Create implements IdentityRepository.
+func (repo *identityRepositoryImpl) Create(identity models.IdentityEntity) error {
+	return repo.db.Create(&identity).Error
+}
+
+// FindByProviderSubject implements IdentityRepository.
+func (repo *identityRepositoryImpl) FindByProviderSubject(provider string, subject string) (*models.IdentityEntity, error) {
+	var identity models.IdentityEntity
+	if err := repo.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// FindAllByUserID implements IdentityRepository.
+func (repo *identityRepositoryImpl) FindAllByUserID(userID uuid.UUID) ([]models.IdentityEntity, error) {
+	var identities []models.IdentityEntity
+	if err := repo.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// TouchLastLogin implements IdentityRepository.
+func (repo *identityRepositoryImpl) TouchLastLogin(identityID uuid.UUID) error {
+	return repo.db.Model(&models.IdentityEntity{}).
+		Where("id = ?", identityID).
+		Update("last_login_at", time.Now()).Error
+}
+
+// Delete implements IdentityRepository.
+func (repo *identityRepositoryImpl) Delete(userID uuid.UUID, provider string) error {
+	result := repo.db.Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.IdentityEntity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepositoryImpl{db: db}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModeratorRepository defines a set of methods for managing the moderators of the forums and categories.
+type ModeratorRepository interface {
+
+	// Create stores a new ModeratorEntity and returns its ID.
+	Create(moderator models.ModeratorEntity) (uuid.UUID, error)
+
+	// FindByID retrieves a moderator assignment.
+	// Returns gorm.ErrRecordNotFound if it does not exist.
+	FindByID(moderatorID uuid.UUID) (*models.ModeratorEntity, error)
+
+	// Exists reports whether the same user or role is already moderator of the same forum or category.
+	Exists(moderator models.ModeratorEntity) (bool, error)
+
+	// FindAllByForumID retrieves the moderators of a forum, including the moderators of its category.
+	FindAllByForumID(forumID uuid.UUID, categoryID uuid.UUID) ([]models.ModeratorEntity, error)
+
+	// FindAllByCategoryID retrieves the moderators of a category.
+	FindAllByCategoryID(categoryID uuid.UUID) ([]models.ModeratorEntity, error)
+
+	// FindAllBySubject retrieves the assignments of a user, and of their role.
+	FindAllBySubject(userID uuid.UUID, roleID uuid.UUID) ([]models.ModeratorEntity, error)
+
+	// IsModerator reports whether a user, or their role, moderates a forum or its category.
+	IsModerator(userID uuid.UUID, roleID uuid.UUID, forumID uuid.UUID, categoryID uuid.UUID) (bool, error)
+
+	// Delete removes a moderator assignment.
+	// Returns gorm.ErrRecordNotFound if it does not exist.
+	Delete(moderatorID uuid.UUID) error
+}
+
+type moderatorRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// Create implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) Create(moderator models.ModeratorEntity) (uuid.UUID, error) {
+	if err := repo.db.Create(&moderator).Error; err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return moderator.ID, nil
+}
+
+// FindByID implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) FindByID(moderatorID uuid.UUID) (*models.ModeratorEntity, error) {
+	var moderator models.ModeratorEntity
+	if err := repo.db.Where("id = ?", moderatorID).First(&moderator).Error; err != nil {
+		return nil, err
+	}
+
+	return &moderator, nil
+}
+
+// Exists implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) Exists(moderator models.ModeratorEntity) (bool, error) {
+	query := repo.db.Model(&models.ModeratorEntity{})
+	for column, value := range map[string]*uuid.UUID{
+		"forum_id":    moderator.ForumID,
+		"category_id": moderator.CategoryID,
+		"user_id":     moderator.UserID,
+		"role_id":     moderator.RoleID,
+	} {
+		if value == nil {
+			query = query.Where(column + " IS NULL")
+		} else {
+			query = query.Where(column+" = ?", *value)
+		}
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// FindAllByForumID implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) FindAllByForumID(forumID uuid.UUID, categoryID uuid.UUID) ([]models.ModeratorEntity, error) {
+	var moderators []models.ModeratorEntity
+	err := repo.db.Where("forum_id = ? OR category_id = ?", forumID, categoryID).
+		Order("created_at").
+		Find(&moderators).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return moderators, nil
+}
+
+// FindAllByCategoryID implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) FindAllByCategoryID(categoryID uuid.UUID) ([]models.ModeratorEntity, error) {
+	var moderators []models.ModeratorEntity
+	if err := repo.db.Where("category_id = ?", categoryID).Order("created_at").Find(&moderators).Error; err != nil {
+		return nil, err
+	}
+
+	return moderators, nil
+}
+
+// FindAllBySubject implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) FindAllBySubject(userID uuid.UUID, roleID uuid.UUID) ([]models.ModeratorEntity, error) {
+	var moderators []models.ModeratorEntity
+	err := repo.db.Where("user_id = ? OR role_id = ?", userID, roleID).
+		Order("created_at").
+		Find(&moderators).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return moderators, nil
+}
+
+// IsModerator implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) IsModerator(userID uuid.UUID, roleID uuid.UUID, forumID uuid.UUID, categoryID uuid.UUID) (bool, error) {
+	var count int64
+	err := repo.db.Model(&models.ModeratorEntity{}).
+		Where("user_id = ? OR role_id = ?", userID, roleID).
+		Where("forum_id = ? OR category_id = ?", forumID, categoryID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Delete implements ModeratorRepository.
+func (repo *moderatorRepositoryImpl) Delete(moderatorID uuid.UUID) error {
+	result := repo.db.Where("id = ?", moderatorID).Delete(&models.ModeratorEntity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func NewModeratorRepository(db *gorm.DB) ModeratorRepository {
+	return &moderatorRepositoryImpl{db: db}
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository defines a set of methods for managing the emails waiting in the outbox.
+type OutboxRepository interface {
+
+	// Enqueue stores a new email in the outbox, ready to be sent.
+	// Returns an error if the operation fails.
+	Enqueue(email models.OutboxEmailEntity) error
+
+	// ClaimDue retrieves up to limit pending emails whose next attempt is due, and postpones their
+	// next attempt by lease so other workers do not pick them while they are being sent.
+	// Rows locked by another worker are skipped.
+	ClaimDue(limit int, lease time.Duration) ([]*models.OutboxEmailEntity, error)
+
+	// MarkSent records that an email has been delivered.
+	// Returns an error if the update fails.
+	MarkSent(emailID uuid.UUID) error
+
+	// MarkRetry records a failed attempt and schedules the next one.
+	// Returns an error if the update fails.
+	MarkRetry(emailID uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error
+
+	// MarkFailed records a failed attempt after which the email will not be retried.
+	// Returns an error if the update fails.
+	MarkFailed(emailID uuid.UUID, attempts int, lastError string) error
+}
+
+type outboxRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// Enqueue implements OutboxRepository.
+func (repo *outboxRepositoryImpl) Enqueue(email models.OutboxEmailEntity) error {
+	email.Status = models.OutboxPending
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = time.Now()
+	}
+
+	return repo.db.Create(&email).Error
+}
+
+// ClaimDue implements OutboxRepository.
+func (repo *outboxRepositoryImpl) ClaimDue(limit int, lease time.Duration) ([]*models.OutboxEmailEntity, error) {
+	var emails []*models.OutboxEmailEntity
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&emails).Error; err != nil {
+			return err
+		}
+
+		if len(emails) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(emails))
+		for _, email := range emails {
+			ids = append(ids, email.ID)
+		}
+
+		return tx.Model(&models.OutboxEmailEntity{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// MarkSent implements OutboxRepository.
+func (repo *outboxRepositoryImpl) MarkSent(emailID uuid.UUID) error {
+	return repo.db.Model(&models.OutboxEmailEntity{}).
+		Where("id = ?", emailID).
+		Updates(map[string]interface{}{
+			"status":     models.OutboxSent,
+			"attempts":   gorm.Expr("attempts + ?", 1),
+			"sent_at":    time.Now(),
+			"last_error": "",
+		}).Error
+}
+
+// MarkRetry implements OutboxRepository.
+func (repo *outboxRepositoryImpl) MarkRetry(emailID uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return repo.db.Model(&models.OutboxEmailEntity{}).
+		Where("id = ?", emailID).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
+
+// MarkFailed implements OutboxRepository.
+func (repo *outboxRepositoryImpl) MarkFailed(emailID uuid.UUID, attempts int, lastError string) error {
+	return repo.db.Model(&models.OutboxEmailEntity{}).
+		Where("id = ?", emailID).
+		Updates(map[string]interface{}{
+			"status":     models.OutboxFailed,
+			"attempts":   attempts,
+			"last_error": lastError,
+		}).Error
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepositoryImpl{db: db}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PersonalTokenRepository defines a set of methods for managing the personal access tokens of the users.
+type PersonalTokenRepository interface {
+
+	// Create stores a new PersonalTokenEntity.
+	// Returns the ID of the token, or an error if the user already has a token with the same name.
+	Create(token models.PersonalTokenEntity) (uuid.UUID, error)
+
+	// FindByHash retrieves a token by the SHA-256 hash of its value.
+	// Returns gorm.ErrRecordNotFound if no token matches.
+	FindByHash(tokenHash string) (*models.PersonalTokenEntity, error)
+
+	// FindAllByUserID retrieves the tokens of a user, the newest first.
+	FindAllByUserID(userID uuid.UUID) ([]models.PersonalTokenEntity, error)
+
+	// CountByUserID returns the number of tokens of a user.
+	CountByUserID(userID uuid.UUID) (int64, error)
+
+	// TouchLastUsed records when a token has been used.
+	TouchLastUsed(tokenID uuid.UUID, usedAt time.Time) error
+
+	// Delete removes a token of a user.
+	// Returns gorm.ErrRecordNotFound if the user has no token with this ID.
+	Delete(userID uuid.UUID, tokenID uuid.UUID) error
+
+	// DeleteAllByUserID deletes every token of a user.
+	// Returns the number of tokens deleted.
+	DeleteAllByUserID(userID uuid.UUID) (int64, error)
+}
+
+type personalTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// Create implements PersonalTokenRepository.
+func (repo *personalTokenRepositoryImpl) Create(token models.PersonalTokenEntity) (uuid.UUID, error) {
+	if err := repo.db.Create(&token).Error; err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return token.ID, nil
+}
+
+// FindByHash implements PersonalTokenRepository.
+func (repo *personalTokenRepositoryImpl) FindByHash(tokenHash string) (*models.PersonalTokenEntity, error) {
+	var token models.PersonalTokenEntity
+	if err := repo.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// FindAllByUserID implements PersonalTokenRepository.
+func (repo *personalTokenRepositoryImpl) FindAllByUserID(userID uuid.UUID) ([]models.PersonalTokenEntity, error) {
+	var tokens []models.PersonalTokenEntity
+	if err := repo.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// CountByUserID implements PersonalTokenRepository.
+func (repo *personalTokenRepositoryImpl) CountByUserID(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := repo.db.Model(&models.PersonalTokenEntity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// TouchLastUsed implements PersonalTokenRepository.
+func (repo *personalTokenRepositoryImpl) TouchLastUsed(tokenID uuid.UUID, usedAt time.Time) error {
+	return repo.db.Model(&models.PersonalTokenEntity{}).
+		Where("id = ?", tokenID).
+		Update("last_used_at", usedAt).Error
+}
+
+// Delete implements PersonalTokenRepository.
+func (repo *personalTokenRepositoryImpl) Delete(userID uuid.UUID, tokenID uuid.UUID) error {
+	result := repo.db.Where("user_id = ? AND id = ?", userID, tokenID).Delete(&models.PersonalTokenEntity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllByUserID implements PersonalTokenRepository.
+func (repo *personalTokenRepositoryImpl) DeleteAllByUserID(userID uuid.UUID) (int64, error) {
+	result := repo.db.Where("user_id = ?", userID).Delete(&models.PersonalTokenEntity{})
+	return result.RowsAffected, result.Error
+}
+
+func NewPersonalTokenRepository(db *gorm.DB) PersonalTokenRepository {
+	return &personalTokenRepositoryImpl{db: db}
+}
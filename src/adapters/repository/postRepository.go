@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/pkg/errorsUtils"
 	"github.com/google/uuid"
@@ -11,12 +13,37 @@ type PostRepository interface {
 	FindAll(limit, offset int) ([]*models.Post, error)
 	FindByID(ID uuid.UUID) (*models.Post, error)
 	FindByUserID(userID uuid.UUID) ([]*models.Post, error)
+
+	// FindAllByForumID retrieves the posts of a forum and the announcements of every forum with pagination options.
+	// The announcements come first, then the pinned posts in the order they were pinned, then the most recent posts.
 	FindAllByForumID(forumID uuid.UUID, limit, offset int) ([]*models.Post, error)
+
 	GetLikeCount(postID uuid.UUID) (int64, error)
 	Create(post models.Post) (*models.Post, error)
 	Update(postID uuid.UUID, updatedPost models.Post) error
 	Delete(postID uuid.UUID) error
 	Restore(postID uuid.UUID) error
+
+	// SetLocked locks or unlocks a post. Returns gorm.ErrRecordNotFound if the post does not exist.
+	SetLocked(postID uuid.UUID, locked bool) error
+
+	// SetPinned pins a post to the top of the listings of its forum, from now, or unpins it.
+	// Returns gorm.ErrRecordNotFound if the post does not exist.
+	SetPinned(postID uuid.UUID, pinned bool) error
+
+	// SetAnnouncement marks or unmarks a post as an announcement of every forum.
+	// Returns gorm.ErrRecordNotFound if the post does not exist.
+	SetAnnouncement(postID uuid.UUID, announcement bool) error
+
+	// Move moves a post to another forum and unpins it, pins only apply to the forum they were made in.
+	// When stub is not nil it is created along, to redirect to the post from its previous forum.
+	// Returns gorm.ErrRecordNotFound if the post does not exist.
+	Move(postID uuid.UUID, forumID uuid.UUID, stub *models.Post) error
+
+	// Merge moves the comments and likes of source to the target post, the opening post of source becoming
+	// a comment dated when it was posted, and turns source into a redirect stub to the target.
+	// The comments counter of the target is updated and the stubs redirecting to source redirect to the target.
+	Merge(source models.Post, targetID uuid.UUID) error
 }
 
 type postRepositoryImpl struct {
@@ -27,7 +54,8 @@ func (repo *postRepositoryImpl) FindAllByForumID(forumID uuid.UUID, limit, offse
 	var posts []*models.Post
 	if err := repo.db.Preload("User").
 		Preload("User.Role").
-		Where("forum_id = ?", forumID.String()).
+		Where("forum_id = ? OR announcement = ?", forumID.String(), true).
+		Order("announcement DESC, pinned_at ASC NULLS LAST, created_at DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&posts).Error; err != nil {
@@ -123,6 +151,131 @@ func (repo *postRepositoryImpl) Restore(postID uuid.UUID) error {
 	return nil
 }
 
+// SetLocked implements PostRepository.
+func (repo *postRepositoryImpl) SetLocked(postID uuid.UUID, locked bool) error {
+	return repo.setColumn(postID, "locked", locked)
+}
+
+// SetPinned implements PostRepository.
+func (repo *postRepositoryImpl) SetPinned(postID uuid.UUID, pinned bool) error {
+	var pinnedAt *time.Time
+	if pinned {
+		now := time.Now()
+		pinnedAt = &now
+	}
+
+	return repo.setColumn(postID, "pinned_at", pinnedAt)
+}
+
+// SetAnnouncement implements PostRepository.
+func (repo *postRepositoryImpl) SetAnnouncement(postID uuid.UUID, announcement bool) error {
+	return repo.setColumn(postID, "announcement", announcement)
+}
+
+// Move implements PostRepository.
+func (repo *postRepositoryImpl) Move(postID uuid.UUID, forumID uuid.UUID, stub *models.Post) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Post{}).
+			Where("id = ?", postID).
+			UpdateColumns(map[string]interface{}{"forum_id": forumID, "pinned_at": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if stub == nil {
+			return nil
+		}
+		return tx.Omit("User", "Forum").Create(stub).Error
+	})
+}
+
+// Merge implements PostRepository.
+func (repo *postRepositoryImpl) Merge(source models.Post, targetID uuid.UUID) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var moved int64
+		if err := tx.Model(&models.Comment{}).Where("post_id = ?", source.ID).Count(&moved).Error; err != nil {
+			return err
+		}
+
+		// The comments keep their dates and threading, so they fall in place among those of the target.
+		// The target keeps its own best answer.
+		if err := tx.Unscoped().Model(&models.Comment{}).
+			Where("post_id = ?", source.ID).
+			UpdateColumns(map[string]interface{}{"post_id": targetID, "is_best": false}).Error; err != nil {
+			return err
+		}
+
+		opening := models.Comment{
+			UserID:    source.UserID,
+			PostID:    targetID,
+			Content:   source.Content,
+			CreatedAt: source.CreatedAt,
+		}
+		if err := tx.Omit("User").Create(&opening).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`INSERT INTO posts_likes (post_id, user_id, created_at)
+			SELECT ?, user_id, created_at FROM posts_likes WHERE post_id = ?
+			ON CONFLICT DO NOTHING`, targetID, source.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", source.ID).Delete(&models.PostLikes{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Post{}).
+			Where("id = ?", targetID).
+			UpdateColumn("comments", gorm.Expr("comments + ?", moved+1)).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Post{}).
+			Where("redirect_to_id = ?", source.ID).
+			UpdateColumn("redirect_to_id", targetID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Post{}).
+			Where("id = ?", source.ID).
+			UpdateColumns(map[string]interface{}{
+				"redirect_to_id": targetID,
+				"content":        "",
+				"comments":       0,
+				"locked":         true,
+				"pinned_at":      nil,
+				"announcement":   false,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+// setColumn updates a moderation column of a post without touching updated_at,
+// those changes are not edits of the post.
+func (repo *postRepositoryImpl) setColumn(postID uuid.UUID, column string, value interface{}) error {
+	result := repo.db.Model(&models.Post{}).
+		Where("id = ?", postID).
+		UpdateColumn(column, value)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func NewPostRepository(db *gorm.DB) PostRepository {
 	return &postRepositoryImpl{db: db}
 }
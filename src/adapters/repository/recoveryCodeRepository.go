@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository defines a set of methods for managing the two-factor authentication
+// recovery codes of the users.
+type RecoveryCodeRepository interface {
+
+	// ReplaceForUser deletes every recovery code of a user and stores the given ones instead.
+	// Returns an error if the operation fails.
+	ReplaceForUser(userID uuid.UUID, codes []models.RecoveryCodeEntity) error
+
+	// FindUnusedByHash retrieves an unused RecoveryCodeEntity of a user by the hash of its code.
+	// Returns gorm.ErrRecordNotFound if the code does not exist or has already been used.
+	FindUnusedByHash(userID uuid.UUID, codeHash string) (*models.RecoveryCodeEntity, error)
+
+	// CountUnusedByUserID returns how many recovery codes a user has left.
+	CountUnusedByUserID(userID uuid.UUID) (int64, error)
+
+	// Consume marks a RecoveryCodeEntity as used.
+	// Returns gorm.ErrRecordNotFound if the code has already been used.
+	Consume(recoveryCodeID uuid.UUID) error
+
+	// DeleteByUserID removes every recovery code of a user.
+	// Returns an error if the deletion fails.
+	DeleteByUserID(userID uuid.UUID) error
+}
+
+type recoveryCodeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// ReplaceForUser implements RecoveryCodeRepository.
+func (repo *recoveryCodeRepositoryImpl) ReplaceForUser(userID uuid.UUID, codes []models.RecoveryCodeEntity) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCodeEntity{}).Error; err != nil {
+			return err
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// FindUnusedByHash implements RecoveryCodeRepository.
+func (repo *recoveryCodeRepositoryImpl) FindUnusedByHash(userID uuid.UUID, codeHash string) (*models.RecoveryCodeEntity, error) {
+	var recoveryCode models.RecoveryCodeEntity
+	if err := repo.db.
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		First(&recoveryCode).Error; err != nil {
+		return nil, err
+	}
+
+	return &recoveryCode, nil
+}
+
+// CountUnusedByUserID implements RecoveryCodeRepository.
+func (repo *recoveryCodeRepositoryImpl) CountUnusedByUserID(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := repo.db.Model(&models.RecoveryCodeEntity{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+
+	return count, err
+}
+
+// Consume implements RecoveryCodeRepository.
+func (repo *recoveryCodeRepositoryImpl) Consume(recoveryCodeID uuid.UUID) error {
+	// the condition on used_at makes concurrent uses of the same code fail
+	result := repo.db.Model(&models.RecoveryCodeEntity{}).
+		Where("id = ? AND used_at IS NULL", recoveryCodeID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteByUserID implements RecoveryCodeRepository.
+func (repo *recoveryCodeRepositoryImpl) DeleteByUserID(userID uuid.UUID) error {
+	return repo.db.Where("user_id = ?", userID).Delete(&models.RecoveryCodeEntity{}).Error
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepositoryImpl{db: db}
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReportFilter selects the reports of the moderation queue, the empty fields do not filter.
+type ReportFilter struct {
+	Status     string
+	TargetType string
+	ForumID    *uuid.UUID
+
+	// ForumIDs restricts the queue to the reports of these forums when not nil,
+	// leaving out the reports of users.
+	ForumIDs []uuid.UUID
+}
+
+// ReportRepository defines a set of methods for managing the reports of the users and the moderation queue.
+type ReportRepository interface {
+
+	// AddEntry adds the report of a user to the open report of the target of report,
+	// creating report if the target has none, and returns the ID of the report it was added to.
+	// Fails with a unique constraint violation if the user already reported the target while its report is open.
+	AddEntry(report models.ReportEntity, entry models.ReportEntryEntity) (uuid.UUID, error)
+
+	// FindByID retrieves a report, including its entries.
+	// Returns gorm.ErrRecordNotFound if it does not exist.
+	FindByID(reportID uuid.UUID) (*models.ReportEntity, error)
+
+	// FindAll retrieves a page of the reports matching filter, the most reported and then the oldest first,
+	// and the number of reports matching it.
+	FindAll(filter ReportFilter, limit int, offset int) ([]models.ReportEntity, int64, error)
+
+	// Claim marks an open report as being resolved by a moderator, so that no other moderator acts on it.
+	// A report claimed more than staleAfter ago can be claimed again, its moderator is assumed gone.
+	// Returns gorm.ErrRecordNotFound if the report is not open, nor claimed long enough ago.
+	Claim(reportID uuid.UUID, claimedBy uuid.UUID, at time.Time, staleAfter time.Duration) error
+
+	// ReleaseClaim reopens a report claimed by a moderator.
+	// Returns gorm.ErrRecordNotFound if the moderator does not hold the claim.
+	ReleaseClaim(reportID uuid.UUID, claimedBy uuid.UUID) error
+
+	// Resolve records the action a moderator resolved a report they claimed with.
+	// Returns gorm.ErrRecordNotFound if the moderator does not hold the claim.
+	Resolve(reportID uuid.UUID, action string, note string, resolvedBy uuid.UUID, at time.Time) error
+}
+
+type reportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// AddEntry implements ReportRepository.
+func (repo *reportRepositoryImpl) AddEntry(report models.ReportEntity, entry models.ReportEntryEntity) (uuid.UUID, error) {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.ReportEntity
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("target_type = ? AND target_id = ? AND status IN ?", report.TargetType, report.TargetID,
+				[]string{models.ReportStatusOpen, models.ReportStatusClaimed}).
+			First(&existing).Error
+		switch err {
+		case nil:
+			report.ID = existing.ID
+			err = tx.Model(&models.ReportEntity{}).
+				Where("id = ?", existing.ID).
+				Update("report_count", gorm.Expr("report_count + 1")).Error
+		case gorm.ErrRecordNotFound:
+			report.Status = models.ReportStatusOpen
+			report.ReportCount = 1
+			err = tx.Omit("Entries").Create(&report).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		entry.ReportID = report.ID
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return report.ID, nil
+}
+
+// FindByID implements ReportRepository.
+func (repo *reportRepositoryImpl) FindByID(reportID uuid.UUID) (*models.ReportEntity, error) {
+	var report models.ReportEntity
+	err := repo.db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).Where("id = ?", reportID).First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// FindAll implements ReportRepository.
+func (repo *reportRepositoryImpl) FindAll(filter ReportFilter, limit int, offset int) ([]models.ReportEntity, int64, error) {
+	var total int64
+	if err := reportsMatching(repo.db, filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reports []models.ReportEntity
+	err := reportsMatching(repo.db, filter).Order("report_count DESC").Order("created_at").
+		Limit(limit).Offset(offset).
+		Find(&reports).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+// Claim implements ReportRepository.
+func (repo *reportRepositoryImpl) Claim(reportID uuid.UUID, claimedBy uuid.UUID, at time.Time, staleAfter time.Duration) error {
+	return updateReport(repo.db.Where("id = ?", reportID).
+		Where("status = ? OR (status = ? AND claimed_at < ?)", models.ReportStatusOpen, models.ReportStatusClaimed, at.Add(-staleAfter)),
+		map[string]interface{}{
+			"status":      models.ReportStatusClaimed,
+			"resolved_by": claimedBy,
+			"claimed_at":  at,
+		})
+}
+
+// ReleaseClaim implements ReportRepository.
+func (repo *reportRepositoryImpl) ReleaseClaim(reportID uuid.UUID, claimedBy uuid.UUID) error {
+	return updateReport(repo.db.Where("id = ? AND status = ? AND resolved_by = ?", reportID, models.ReportStatusClaimed, claimedBy),
+		map[string]interface{}{
+			"status":      models.ReportStatusOpen,
+			"resolved_by": nil,
+			"claimed_at":  nil,
+		})
+}
+
+// Resolve implements ReportRepository.
+func (repo *reportRepositoryImpl) Resolve(reportID uuid.UUID, action string, note string, resolvedBy uuid.UUID, at time.Time) error {
+	return updateReport(repo.db.Where("id = ? AND status = ? AND resolved_by = ?", reportID, models.ReportStatusClaimed, resolvedBy),
+		map[string]interface{}{
+			"status":      models.ReportStatusResolved,
+			"action":      action,
+			"action_note": note,
+			"resolved_at": at,
+		})
+}
+
+// updateReport applies updates to the report selected by query,
+// returning gorm.ErrRecordNotFound if the conditions of query match no report.
+func updateReport(query *gorm.DB, updates map[string]interface{}) error {
+	result := query.Model(&models.ReportEntity{}).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// reportsMatching scopes a query to the reports matching filter.
+func reportsMatching(db *gorm.DB, filter ReportFilter) *gorm.DB {
+	query := db.Model(&models.ReportEntity{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.ForumID != nil {
+		query = query.Where("forum_id = ?", *filter.ForumID)
+	}
+	if filter.ForumIDs != nil {
+		query = query.Where("forum_id IN ?", filter.ForumIDs)
+	}
+
+	return query
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepositoryImpl{db: db}
+}
@@ -1,16 +1,22 @@
 package repository
 
 import (
-	"fmt"
-
 	"github.com/Dialosoft/src/domain/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// RolePermissionsRepository defines a set of methods for managing the permissions granted to the roles.
 type RolePermissionsRepository interface {
-	FindByRoleID(roleID uuid.UUID) (*models.RolePermissions, error)
-	Save(rolePermissions models.RolePermissions) (uuid.UUID, error)
+
+	// FindByRoleID retrieves the names of the permissions granted to a role.
+	FindByRoleID(roleID uuid.UUID) ([]string, error)
+
+	// HasPermission reports whether a permission is granted to a role.
+	HasPermission(roleID uuid.UUID, permission string) (bool, error)
+
+	// Replace grants exactly the given permissions to a role, revoking the others.
+	Replace(roleID uuid.UUID, permissions []string) error
 }
 
 type rolePermissionsRepositoryImpl struct {
@@ -18,23 +24,44 @@ type rolePermissionsRepositoryImpl struct {
 }
 
 // FindByRoleID implements RolePermissionsRepository.
-func (repo *rolePermissionsRepositoryImpl) FindByRoleID(roleID uuid.UUID) (*models.RolePermissions, error) {
-	fmt.Println("entra a FindByRoleID (repository)")
-	var rolePermission models.RolePermissions
-	if err := repo.db.First(&rolePermission, "role_id = ?", roleID.String()).Error; err != nil {
+func (repo *rolePermissionsRepositoryImpl) FindByRoleID(roleID uuid.UUID) ([]string, error) {
+	var permissions []string
+	err := repo.db.Model(&models.RolePermissionEntity{}).
+		Where("role_id = ?", roleID).
+		Order("permission").
+		Pluck("permission", &permissions).Error
+	if err != nil {
 		return nil, err
 	}
 
-	return &rolePermission, nil
+	return permissions, nil
 }
 
-// Save implements RolePermissionsRepository.
-func (repo *rolePermissionsRepositoryImpl) Save(rolePermissions models.RolePermissions) (uuid.UUID, error) {
-	result := repo.db.Save(&rolePermissions)
-	if result.Error != nil {
-		return uuid.UUID{}, result.Error
-	}
-	return rolePermissions.RoleID, nil
+// HasPermission implements RolePermissionsRepository.
+func (repo *rolePermissionsRepositoryImpl) HasPermission(roleID uuid.UUID, permission string) (bool, error) {
+	var count int64
+	err := repo.db.Model(&models.RolePermissionEntity{}).
+		Where("role_id = ? AND permission = ?", roleID, permission).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Replace implements RolePermissionsRepository.
+func (repo *rolePermissionsRepositoryImpl) Replace(roleID uuid.UUID, permissions []string) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermissionEntity{}).Error; err != nil {
+			return err
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+
+		grants := make([]models.RolePermissionEntity, len(permissions))
+		for i, permission := range permissions {
+			grants[i] = models.RolePermissionEntity{RoleID: roleID, Permission: permission}
+		}
+		return tx.Create(&grants).Error
+	})
 }
 
 func NewRolePermissionsRepository(db *gorm.DB) RolePermissionsRepository {
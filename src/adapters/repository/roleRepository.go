@@ -29,6 +29,10 @@ type RoleRepository interface {
 	// Returns an error if the update fails.
 	Update(roleID uuid.UUID, updatedRole models.RoleEntity) error
 
+	// SetRequireMFA sets whether the users of a role must use two-factor authentication.
+	// Returns gorm.ErrRecordNotFound if the role does not exist.
+	SetRequireMFA(roleID uuid.UUID, required bool) error
+
 	// Delete marks a RoleEntity as deleted by its UUID.
 	// Returns an error if the deletion fails.
 	Delete(roleID uuid.UUID) error
@@ -96,6 +100,21 @@ func (repo *roleRepositoryImpl) Update(roleID uuid.UUID, updatedRole models.Role
 	return nil
 }
 
+// SetRequireMFA implements RoleRepository.
+func (repo *roleRepositoryImpl) SetRequireMFA(roleID uuid.UUID, required bool) error {
+	result := repo.db.Model(&models.RoleEntity{}).
+		Where("id = ?", roleID).
+		Update("require_mfa", required)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 // Delete implements RoleRepository.
 func (repo *roleRepositoryImpl) Delete(roleID uuid.UUID) error {
 	return repo.db.Delete(&models.RoleEntity{}, roleID).Error
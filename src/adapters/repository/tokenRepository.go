@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/Dialosoft/src/domain/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -18,9 +20,14 @@ type TokenRepository interface {
 	// Returns a pointer to the TokenEntity if found, or an error otherwise.
 	FindTokenByID(tokenID uuid.UUID) (*models.TokenEntity, error)
 
-	// FindTokenByUserID retrieves a TokenEntity by the associated user's unique identifier (UUID).
-	// Returns a pointer to the TokenEntity if found, or an error otherwise.
-	FindTokenByUserID(userID uuid.UUID) (*models.TokenEntity, error)
+	// FindActiveTokensByUserID retrieves every TokenEntity of a user that is not blocked, rotated or expired,
+	// that is, the current token of each session of the user.
+	// Returns an empty slice if the user has no active tokens.
+	FindActiveTokensByUserID(userID uuid.UUID) ([]*models.TokenEntity, error)
+
+	// FindActiveTokenByFamilyID retrieves the current TokenEntity of a session (token family).
+	// Returns gorm.ErrRecordNotFound if the session has no active token.
+	FindActiveTokenByFamilyID(familyID uuid.UUID) (*models.TokenEntity, error)
 
 	// Save stores a new TokenEntity in the system.
 	// Returns an error if the operation fails.
@@ -30,6 +37,14 @@ type TokenRepository interface {
 	// Returns an error if the update fails.
 	Update(tokenID uuid.UUID, tokenEntity models.TokenEntity) error
 
+	// Rotate retires the TokenEntity identified by tokenID and stores newToken as its replacement.
+	// Returns gorm.ErrRecordNotFound if the token has already been rotated or blocked.
+	Rotate(tokenID uuid.UUID, newToken models.TokenEntity) error
+
+	// BlockFamily marks every TokenEntity of a session (token family) as blocked, so none can be used again.
+	// Returns an error if the update fails.
+	BlockFamily(familyID uuid.UUID) error
+
 	// Delete removes a TokenEntity identified by its UUID.
 	// Returns an error if the deletion fails.
 	Delete(tokenID uuid.UUID) error
@@ -62,10 +77,23 @@ func (repo *tokenRepositoryImpl) FindTokenByID(tokenID uuid.UUID) (*models.Token
 	return &token, nil
 }
 
-// FindTokenByUserID implements TokenRepository.
-func (repo *tokenRepositoryImpl) FindTokenByUserID(userID uuid.UUID) (*models.TokenEntity, error) {
+// FindActiveTokensByUserID implements TokenRepository.
+func (repo *tokenRepositoryImpl) FindActiveTokensByUserID(userID uuid.UUID) ([]*models.TokenEntity, error) {
+	var tokens []*models.TokenEntity
+	if err := repo.db.Where("user_id = ? AND blocked = ? AND rotated_at IS NULL AND expires_at > ?", userID, false, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// FindActiveTokenByFamilyID implements TokenRepository.
+func (repo *tokenRepositoryImpl) FindActiveTokenByFamilyID(familyID uuid.UUID) (*models.TokenEntity, error) {
 	var token models.TokenEntity
-	if err := repo.db.First(&token, "user_id = ?", userID).Error; err != nil {
+	if err := repo.db.Where("family_id = ? AND blocked = ? AND rotated_at IS NULL AND expires_at > ?", familyID, false, time.Now()).
+		First(&token).Error; err != nil {
 		return nil, err
 	}
 
@@ -84,7 +112,7 @@ func (repo *tokenRepositoryImpl) Save(tokenEntity models.TokenEntity) error {
 
 // Update implements TokenRepository.
 func (repo *tokenRepositoryImpl) Update(tokenID uuid.UUID, updatedToken models.TokenEntity) error {
-	result := repo.db.Model(&models.RoleEntity{}).
+	result := repo.db.Model(&models.TokenEntity{}).
 		Where("id = ?", tokenID).
 		Updates(updatedToken)
 	if result.Error != nil {
@@ -97,9 +125,34 @@ func (repo *tokenRepositoryImpl) Update(tokenID uuid.UUID, updatedToken models.T
 	return nil
 }
 
+// Rotate implements TokenRepository.
+func (repo *tokenRepositoryImpl) Rotate(tokenID uuid.UUID, newToken models.TokenEntity) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		// the condition on rotated_at makes concurrent rotations of the same token fail
+		result := tx.Model(&models.TokenEntity{}).
+			Where("id = ? AND blocked = ? AND rotated_at IS NULL", tokenID, false).
+			Update("rotated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(&newToken).Error
+	})
+}
+
+// BlockFamily implements TokenRepository.
+func (repo *tokenRepositoryImpl) BlockFamily(familyID uuid.UUID) error {
+	return repo.db.Model(&models.TokenEntity{}).
+		Where("family_id = ?", familyID).
+		Update("blocked", true).Error
+}
+
 // Delete implements TokenRepository.
 func (repo *tokenRepositoryImpl) Delete(tokenID uuid.UUID) error {
-	return repo.db.Delete(&models.RoleEntity{}, tokenID).Error
+	return repo.db.Delete(&models.TokenEntity{}, "id = ?", tokenID).Error
 }
 
 func NewTokenRepository(db *gorm.DB) TokenRepository {
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/utils/identity"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -20,9 +21,20 @@ type UserRepository interface {
 	FindByID(id uuid.UUID) (*models.UserEntity, error)
 
 	// FindByUsername retrieves a user by their username from the database, including the associated role.
+	// The match ignores case and Unicode representation differences.
 	// Returns a UserEntity pointer and an error if the user is not found or the operation fails.
 	FindByUsername(username string) (*models.UserEntity, error)
 
+	// FindByEmail retrieves a user by their email from the database, including the associated role.
+	// The match ignores case and Unicode representation differences.
+	// Returns a UserEntity pointer and an error if the user is not found or the operation fails.
+	FindByEmail(email string) (*models.UserEntity, error)
+
+	// FindByIdentifier retrieves a user by their email if the identifier is an email address,
+	// or by their username otherwise, including the associated role.
+	// Returns a UserEntity pointer and an error if the user is not found or the operation fails.
+	FindByIdentifier(identifier string) (*models.UserEntity, error)
+
 	// Create inserts a new user into the database.
 	// Returns the UUID of the newly created user and an error if the operation fails.
 	Create(newUser models.UserEntity) (uuid.UUID, error)
@@ -33,6 +45,19 @@ type UserRepository interface {
 	//	gorm.ErrRecordNotFound = "record not found error"
 	Update(userID uuid.UUID, updatedUser models.UserEntity) error
 
+	// SetPassword replaces the password hash of a user and clears MustChangePassword.
+	// Returns gorm.ErrRecordNotFound if the user does not exist.
+	SetPassword(userID uuid.UUID, passwordHash string) error
+
+	// SetTOTP stores the encrypted TOTP secret of a user and whether two-factor authentication is enabled.
+	// An empty secret removes it. Returns gorm.ErrRecordNotFound if the user does not exist.
+	SetTOTP(userID uuid.UUID, encryptedSecret string, enabled bool) error
+
+	// UseTOTPCounter records the time step of the last TOTP code accepted for a user.
+	// Returns gorm.ErrRecordNotFound if a code of the same or a later time step was already accepted,
+	// so a code cannot be used twice.
+	UseTOTPCounter(userID uuid.UUID, counter int64) error
+
 	// Delete removes a user from the database identified by userID.
 	// Returns an error if the deletion fails.
 	Delete(userID uuid.UUID) error
@@ -71,13 +96,30 @@ func (repo *userRepositoryImpl) FindByID(id uuid.UUID) (*models.UserEntity, erro
 func (repo *userRepositoryImpl) FindByUsername(username string) (*models.UserEntity, error) {
 	var user models.UserEntity
 	if err := repo.db.Preload("Role").
-		Where("username = ?", username).
+		Where("username_key = ?", identity.NormalizeUsername(username)).
 		First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+func (repo *userRepositoryImpl) FindByEmail(email string) (*models.UserEntity, error) {
+	var user models.UserEntity
+	if err := repo.db.Preload("Role").
+		Where("email_key = ?", identity.NormalizeEmail(email)).
+		First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (repo *userRepositoryImpl) FindByIdentifier(identifier string) (*models.UserEntity, error) {
+	if identity.IsEmail(identifier) {
+		return repo.FindByEmail(identifier)
+	}
+	return repo.FindByUsername(identifier)
+}
+
 func (repo *userRepositoryImpl) Create(newUser models.UserEntity) (uuid.UUID, error) {
 	result := repo.db.Create(&newUser)
 	if result.Error != nil {
@@ -87,6 +129,7 @@ func (repo *userRepositoryImpl) Create(newUser models.UserEntity) (uuid.UUID, er
 }
 
 func (repo *userRepositoryImpl) Update(userID uuid.UUID, updatedUser models.UserEntity) error {
+	updatedUser.SetIdentityKeys()
 	result := repo.db.Model(&models.UserEntity{}).
 		Where("id = ?", userID).
 		Updates(updatedUser)
@@ -100,6 +143,56 @@ func (repo *userRepositoryImpl) Update(userID uuid.UUID, updatedUser models.User
 	return nil
 }
 
+func (repo *userRepositoryImpl) SetPassword(userID uuid.UUID, passwordHash string) error {
+	// a map is used so that the zero values are written too
+	result := repo.db.Model(&models.UserEntity{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password":             passwordHash,
+			"must_change_password": false,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (repo *userRepositoryImpl) SetTOTP(userID uuid.UUID, encryptedSecret string, enabled bool) error {
+	// a map is used so that the zero values are written too
+	result := repo.db.Model(&models.UserEntity{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"totp_secret":  encryptedSecret,
+			"totp_enabled": enabled,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (repo *userRepositoryImpl) UseTOTPCounter(userID uuid.UUID, counter int64) error {
+	result := repo.db.Model(&models.UserEntity{}).
+		Where("id = ? AND totp_counter < ?", userID, counter).
+		Update("totp_counter", counter)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (repo *userRepositoryImpl) Delete(userID uuid.UUID) error {
 	return repo.db.Delete(&models.UserEntity{}, userID).Error
 }
@@ -4,7 +4,10 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Dialosoft/src/adapters/oauth"
 	"github.com/joho/godotenv"
 )
 
@@ -21,6 +24,72 @@ type GeneralConfig struct {
 	MailPassword string
 	FromAddress  string
 	JWTKey       string
+	AppURL       string
+
+	// JWTKeysDir is the directory of the keys signing the tokens with RS256 or EdDSA, one <kid>.pem file per key.
+	// When empty, the tokens are signed with JWTKey and HS256, and no public key is published.
+	JWTKeysDir string
+
+	// JWTAlgorithm is the algorithm of the keys generated in JWTKeysDir: "EdDSA" (default) or "RS256".
+	JWTAlgorithm string
+
+	// JWTKeyGracePeriod is how long a replaced key keeps verifying tokens, at least the lifetime of the refresh tokens.
+	JWTKeyGracePeriod time.Duration
+
+	// JWTKeyRotation is the age after which a new signing key is generated, zero leaves rotation to the operator.
+	JWTKeyRotation time.Duration
+
+	// JWTAcceptLegacy keeps accepting the tokens signed with JWTKey once JWTKeysDir is set.
+	JWTAcceptLegacy bool
+
+	// MailTransport selects how emails are delivered: "smtp" (default), "maildir" or "memory".
+	MailTransport string
+
+	// MaildirPath is the directory the "maildir" transport writes emails to.
+	MaildirPath string
+
+	// MailTemplatesDir is a directory with email templates that override the embedded ones.
+	MailTemplatesDir string
+
+	// RequireVerifiedEmail prevents users who have not confirmed their email from creating posts or comments.
+	RequireVerifiedEmail bool
+
+	// LoginAttemptsWindow is how long a failed login is remembered by the brute-force protection.
+	LoginAttemptsWindow time.Duration
+
+	// LoginDelayAfter is the number of failed logins of a username after which every new attempt has to wait.
+	LoginDelayAfter int
+
+	// LoginLockoutAfter is the number of failed logins of a username after which the account is locked.
+	LoginLockoutAfter int
+
+	// LoginLockoutDuration is how long an account or an IP stays locked.
+	LoginLockoutDuration time.Duration
+
+	// LoginIPLockoutAfter is the number of failed logins from an IP after which the IP is locked.
+	LoginIPLockoutAfter int
+
+	// PasswordMinLength and PasswordMaxLength bound the length of the passwords, bcrypt ignores what comes after 72 bytes.
+	PasswordMinLength int
+	PasswordMaxLength int
+
+	// PasswordRequireLetter, PasswordRequireLowercase, PasswordRequireUppercase, PasswordRequireDigit and
+	// PasswordRequireSymbol are the classes of characters every password must contain.
+	PasswordRequireLetter    bool
+	PasswordRequireLowercase bool
+	PasswordRequireUppercase bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+
+	// PasswordBreachedList is a file of SHA-1 hashes of passwords that are refused, in the format of the
+	// Pwned Passwords downloads. Empty uses the list bundled with the binary, "none" disables the check.
+	PasswordBreachedList string
+
+	// OAuthProviders are the OAuth2 and OpenID Connect providers users can log in with, listed in OAUTHPROVIDERS.
+	OAuthProviders []oauth.ProviderConfig
+
+	// OAuthFakeProvider is the address a fake OpenID Connect provider listens on for development, disabled if empty.
+	OAuthFakeProvider string
 }
 
 func GetGeneralConfig() GeneralConfig {
@@ -41,6 +110,8 @@ func GetGeneralConfig() GeneralConfig {
 		SSLMode = "disable"
 	}
 
+	appURL := strings.TrimSuffix(os.Getenv("APPURL"), "/")
+
 	jwtKey := os.Getenv("JWTKEY")
 	if jwtKey == "" {
 		log.Fatal("JWT key is missing")
@@ -59,5 +130,99 @@ func GetGeneralConfig() GeneralConfig {
 		Port:         port,
 		SSLMode:      SSLMode,
 		JWTKey:       jwtKey,
+		AppURL:       appURL,
+
+		JWTKeysDir:        os.Getenv("JWTKEYSDIR"),
+		JWTAlgorithm:      os.Getenv("JWTALGORITHM"),
+		JWTKeyGracePeriod: getEnvDuration("JWTKEYGRACEPERIOD", 30*24*time.Hour),
+		JWTKeyRotation:    getEnvDuration("JWTKEYROTATION", 0),
+		JWTAcceptLegacy:   getEnvBool("JWTACCEPTLEGACY", true),
+
+		MailTransport:        os.Getenv("MAILTRANSPORT"),
+		MaildirPath:          os.Getenv("MAILDIR"),
+		MailTemplatesDir:     os.Getenv("MAILTEMPLATESDIR"),
+		RequireVerifiedEmail: os.Getenv("REQUIREVERIFIEDEMAIL") == "true",
+
+		LoginAttemptsWindow:  getEnvDuration("LOGINATTEMPTSWINDOW", 15*time.Minute),
+		LoginDelayAfter:      getEnvInt("LOGINDELAYAFTER", 3),
+		LoginLockoutAfter:    getEnvInt("LOGINLOCKOUTAFTER", 10),
+		LoginLockoutDuration: getEnvDuration("LOGINLOCKOUTDURATION", 15*time.Minute),
+		LoginIPLockoutAfter:  getEnvInt("LOGINIPLOCKOUTAFTER", 50),
+
+		PasswordMinLength:        getEnvInt("PASSWORDMINLENGTH", 8),
+		PasswordMaxLength:        getEnvInt("PASSWORDMAXLENGTH", 72),
+		PasswordRequireLetter:    getEnvBool("PASSWORDREQUIRELETTER", true),
+		PasswordRequireLowercase: getEnvBool("PASSWORDREQUIRELOWERCASE", false),
+		PasswordRequireUppercase: getEnvBool("PASSWORDREQUIREUPPERCASE", false),
+		PasswordRequireDigit:     getEnvBool("PASSWORDREQUIREDIGIT", true),
+		PasswordRequireSymbol:    getEnvBool("PASSWORDREQUIRESYMBOL", false),
+		PasswordBreachedList:     os.Getenv("PASSWORDBREACHEDLIST"),
+
+		OAuthProviders:    getOAuthProviders(appURL),
+		OAuthFakeProvider: os.Getenv("OAUTHFAKEPROVIDER"),
+	}
+}
+
+// getOAuthProviders returns the settings of the providers listed in OAUTHPROVIDERS (e.g. "github,google"),
+// each read from the variables OAUTH_<NAME>_CLIENTID, _CLIENTSECRET, _KIND, _ISSUER, _AUTHURL, _TOKENURL,
+// _USERINFOURL, _SCOPES and _REDIRECTURL. The redirect URL defaults to <APPURL>/oauth/<name>/callback.
+func getOAuthProviders(appURL string) []oauth.ProviderConfig {
+	var providers []oauth.ProviderConfig
+	for _, name := range strings.Split(os.Getenv("OAUTHPROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		redirectURL := os.Getenv(prefix + "REDIRECTURL")
+		if redirectURL == "" {
+			redirectURL = appURL + "/oauth/" + name + "/callback"
+		}
+
+		scopes := strings.FieldsFunc(os.Getenv(prefix+"SCOPES"), func(r rune) bool { return r == ',' || r == ' ' })
+
+		providers = append(providers, oauth.ProviderConfig{
+			Name:         name,
+			Kind:         os.Getenv(prefix + "KIND"),
+			ClientID:     os.Getenv(prefix + "CLIENTID"),
+			ClientSecret: os.Getenv(prefix + "CLIENTSECRET"),
+			RedirectURL:  redirectURL,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			AuthURL:      os.Getenv(prefix + "AUTHURL"),
+			TokenURL:     os.Getenv(prefix + "TOKENURL"),
+			UserInfoURL:  os.Getenv(prefix + "USERINFOURL"),
+			Scopes:       scopes,
+		})
+	}
+	return providers
+}
+
+// getEnvInt returns the integer value of an environment variable, or def if it is unset or invalid.
+func getEnvInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvBool returns the value of an environment variable parsed as a boolean ("true", "false", "1", "0"...),
+// or def if it is unset or invalid.
+func getEnvBool(key string, def bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvDuration returns the value of an environment variable parsed as a duration (e.g. "15m"),
+// or def if it is unset or invalid.
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
 	}
+	return value
 }
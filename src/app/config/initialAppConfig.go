@@ -1,12 +1,25 @@
 package config
 
 import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/email"
 	"github.com/Dialosoft/src/adapters/http/controller"
 	"github.com/Dialosoft/src/adapters/http/middleware"
 	"github.com/Dialosoft/src/adapters/http/router"
+	"github.com/Dialosoft/src/adapters/oauth"
 	"github.com/Dialosoft/src/adapters/repository"
 	"github.com/Dialosoft/src/domain/services"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/Dialosoft/src/pkg/passwords"
+	"github.com/Dialosoft/src/pkg/utils/jsonWebToken"
+	"github.com/Dialosoft/src/pkg/utils/security"
+	"github.com/Dialosoft/src/pkg/utils/validation"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -15,9 +28,17 @@ import (
 // Setup for the api
 //
 // repositories -> services -> controllers -> routers -> Setups for routes
-func SetupAPI(db *gorm.DB, redisConn *redis.Client, generalConfig GeneralConfig, defaultRoles map[string]uuid.UUID) *fiber.App {
+func SetupAPI(ctx context.Context, db *gorm.DB, redisConn *redis.Client, generalConfig GeneralConfig, defaultRoles map[string]uuid.UUID) *fiber.App {
+
+	// Checked both when binding the requests and by the services setting a password
+	passwordPolicy := newPasswordPolicy(generalConfig)
+
+	app := fiber.New(fiber.Config{
+		StructValidator: validation.NewStructValidator(passwordPolicy),
+	})
 
-	app := fiber.New(fiber.Config{})
+	// Request IDs tie the audit log entries to the logs of the request
+	app.Use(requestid.New())
 
 	api := app.Group("/dialosoft-api/v1")
 
@@ -31,51 +52,236 @@ func SetupAPI(db *gorm.DB, redisConn *redis.Client, generalConfig GeneralConfig,
 	postRepository := repository.NewPostRepository(db)
 	postLikesRepository := repository.NewPostLikesRepository(db)
 	rolePermissionsRepository := repository.NewRolePermissionsRepository(db)
+	commentRepository := repository.NewCommentRepository(db)
+	commentVotesRepository := repository.NewCommentVotesRepository(db)
+	actionTokenRepository := repository.NewActionTokenRepository(db)
+	outboxRepository := repository.NewOutboxRepository(db)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(db)
+	identityRepository := repository.NewIdentityRepository(db)
+	personalTokenRepository := repository.NewPersonalTokenRepository(db)
+	moderatorRepository := repository.NewModeratorRepository(db)
+	banRepository := repository.NewBanRepository(db)
+	reportRepository := repository.NewReportRepository(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+
+	// Signing keys of the tokens
+	jwtKeys := newJWTKeySet(generalConfig)
 
 	// Services
 	cacheService := services.NewCacheService(cacheRepository)
+	auditService := services.NewAuditService(auditLogRepository)
+	emailService := services.NewEmailService(outboxRepository, newMailTransport(generalConfig),
+		mails.NewRenderer(generalConfig.MailTemplatesDir))
 	userService := services.NewUserService(userRepository, roleRepository)
-	authService := services.NewAuthService(userRepository, roleRepository, tokenRepository, cacheService, generalConfig.JWTKey)
+	roleService := services.NewRoleRepository(roleRepository, rolePermissionsRepository)
+	banService := services.NewBanService(banRepository, userRepository, cacheRepository, cacheService, emailService, roleService)
+	loginThrottleService := services.NewLoginThrottleService(cacheRepository, services.LoginThrottleConfig{
+		Window:          generalConfig.LoginAttemptsWindow,
+		DelayAfter:      generalConfig.LoginDelayAfter,
+		LockoutAfter:    generalConfig.LoginLockoutAfter,
+		LockoutDuration: generalConfig.LoginLockoutDuration,
+		IPLockoutAfter:  generalConfig.LoginIPLockoutAfter,
+	})
+	mfaService := services.NewMFAService(userRepository, recoveryCodeRepository, cacheService,
+		security.DeriveKey(generalConfig.JWTKey, "totp-secrets"))
+	authService := services.NewAuthService(userRepository, roleRepository, tokenRepository, actionTokenRepository,
+		personalTokenRepository, cacheService, emailService, mfaService, loginThrottleService, banService, passwordPolicy,
+		jwtKeys, generalConfig.AppURL)
+	oauthService := services.NewOAuthService(newOAuthProviders(generalConfig), userRepository, roleRepository,
+		identityRepository, cacheRepository, authService)
+	personalTokenService := services.NewPersonalTokenService(personalTokenRepository, userRepository, cacheService)
 	forumService := services.NewForumService(forumRepository, categoryRepository)
 	categoryService := services.NewCategoryService(categoryRepository, roleRepository)
-	roleService := services.NewRoleRepository(roleRepository, rolePermissionsRepository)
-	postService := services.NewPostService(postRepository, postLikesRepository, userRepository)
+	moderatorService := services.NewModeratorService(moderatorRepository, forumRepository, categoryRepository,
+		userRepository, roleRepository, roleService)
+	postService := services.NewPostService(postRepository, postLikesRepository, userRepository, forumRepository, moderatorService)
+	commentService := services.NewCommentService(commentRepository, commentVotesRepository, postRepository, userRepository, moderatorService)
+	reportService := services.NewReportService(reportRepository, postRepository, commentRepository, userRepository,
+		postService, commentService, moderatorService, banService, roleService, emailService)
+
+	// Background workers
+	go emailService.StartOutboxWorker(ctx, 10*time.Second)
+	go jwtKeys.StartReloader(ctx, time.Minute)
+	go banService.StartExpiryWorker(ctx, time.Minute)
+	go authService.StartPasswordResetWorker(ctx)
 
 	// Middlewares
-	securityMiddleware := middleware.NewSecurityMiddleware(authService, cacheService, generalConfig.JWTKey)
-	permissionMiddleware := middleware.NewPermissionMiddleware(authService, cacheService, roleService, generalConfig.JWTKey)
+	securityMiddleware := middleware.NewSecurityMiddleware(authService, cacheService, personalTokenService, banService, jwtKeys, generalConfig.RequireVerifiedEmail)
+	permissionMiddleware := middleware.NewPermissionMiddleware(roleService)
 
 	// Controllers
-	userController := controller.NewUserController(userService)
+	userController := controller.NewUserController(userService, auditService)
 	authController := controller.NewAuthController(authService)
-	forumController := controller.NewForumController(forumService)
-	categoryController := controller.NewCategoryController(categoryService)
-	roleController := controller.NewRoleController(roleService)
-	postController := controller.NewPostController(postService)
+	mfaController := controller.NewMFAController(mfaService, authService)
+	oauthController := controller.NewOAuthController(oauthService)
+	wellKnownController := controller.NewWellKnownController(jwtKeys)
+	personalTokenController := controller.NewPersonalTokenController(personalTokenService)
+	forumController := controller.NewForumController(forumService, auditService)
+	categoryController := controller.NewCategoryController(categoryService, auditService)
+	roleController := controller.NewRoleController(roleService, auditService)
+	postController := controller.NewPostController(postService, auditService)
+	commentController := controller.NewCommentController(commentService, auditService)
+	moderatorController := controller.NewModeratorController(moderatorService, auditService)
+	reportController := controller.NewReportController(reportService, auditService)
+	auditLogController := controller.NewAuditLogController(auditService)
 	managementController := controller.NewManagamentController(
 		forumService,
 		categoryService,
 		roleService,
 		userService,
 		authService,
-		cacheService)
+		cacheService,
+		loginThrottleService,
+		banService,
+		auditService)
 
 	// Routers
 	userRouter := router.NewUserRouter(userController)
 	authRouter := router.NewAuthRouter(authController)
+	mfaRouter := router.NewMFARouter(mfaController)
+	oauthRouter := router.NewOAuthRouter(oauthController)
+	wellKnownRouter := router.NewWellKnownRouter(wellKnownController)
+	personalTokenRouter := router.NewPersonalTokenRouter(personalTokenController)
 	forumRouter := router.NewForumRouter(forumController)
 	categoryRouter := router.NewCategoryRouter(categoryController)
 	roleRouter := router.NewRoleRouter(roleController)
 	managementRouter := router.NewManagementRouter(managementController)
 	postRouter := router.NewPostRouter(postController)
+	commentRouter := router.NewCommentRouter(commentController)
+	moderatorRouter := router.NewModeratorRouter(moderatorController)
+	reportRouter := router.NewReportRouter(reportController)
+	auditLogRouter := router.NewAuditLogRouter(auditLogController)
 
-	userRouter.SetupUserRoutes(api, securityMiddleware, defaultRoles)
+	wellKnownRouter.SetupWellKnownRoutes(app)
+	userRouter.SetupUserRoutes(api, securityMiddleware, permissionMiddleware)
 	authRouter.SetupAuthRoutes(api, securityMiddleware)
+	mfaRouter.SetupMFARoutes(api, securityMiddleware)
+	oauthRouter.SetupOAuthRoutes(api, securityMiddleware)
+	personalTokenRouter.SetupPersonalTokenRoutes(api, securityMiddleware)
 	forumRouter.SetupForumRoutes(api, securityMiddleware, permissionMiddleware)
 	categoryRouter.SetupCategoryRoutes(api, securityMiddleware, permissionMiddleware)
-	roleRouter.SetupRoleRouter(api, securityMiddleware, defaultRoles)
-	managementRouter.SetupManagementRoutes(api, securityMiddleware, defaultRoles)
-	postRouter.SetupPostRoutes(api, securityMiddleware, defaultRoles)
+	roleRouter.SetupRoleRouter(api, securityMiddleware, permissionMiddleware)
+	managementRouter.SetupManagementRoutes(api, securityMiddleware, permissionMiddleware)
+	postRouter.SetupPostRoutes(api, securityMiddleware, permissionMiddleware)
+	commentRouter.SetupCommentRoutes(api, securityMiddleware, permissionMiddleware)
+	moderatorRouter.SetupModeratorRoutes(api, securityMiddleware, permissionMiddleware)
+	reportRouter.SetupReportRoutes(api, securityMiddleware)
+	auditLogRouter.SetupAuditLogRoutes(api, securityMiddleware, permissionMiddleware)
 
 	return app
 }
+
+// newPasswordPolicy returns the password policy described by the GeneralConfig.
+func newPasswordPolicy(generalConfig GeneralConfig) passwords.Policy {
+	policy := passwords.Policy{
+		MinLength:        generalConfig.PasswordMinLength,
+		MaxLength:        generalConfig.PasswordMaxLength,
+		RequireLetter:    generalConfig.PasswordRequireLetter,
+		RequireLowercase: generalConfig.PasswordRequireLowercase,
+		RequireUppercase: generalConfig.PasswordRequireUppercase,
+		RequireDigit:     generalConfig.PasswordRequireDigit,
+		RequireSymbol:    generalConfig.PasswordRequireSymbol,
+	}
+
+	var err error
+	switch generalConfig.PasswordBreachedList {
+	case "none":
+	case "":
+		policy.Breached, err = passwords.DefaultBreachedList()
+	default:
+		policy.Breached, err = passwords.LoadBreachedList(generalConfig.PasswordBreachedList)
+	}
+	if err != nil {
+		log.Fatalf("failed to load the breached passwords list: %v", err)
+	}
+
+	log.Printf("password policy: %d to %d characters, %d breached passwords refused",
+		policy.MinLength, policy.MaxLength, policy.Breached.Size())
+	return policy
+}
+
+// newJWTKeySet returns the keys signing the tokens described by the GeneralConfig.
+func newJWTKeySet(generalConfig GeneralConfig) *jsonWebToken.KeySet {
+	keys, err := jsonWebToken.NewKeySet(jsonWebToken.KeySetConfig{
+		Dir:          generalConfig.JWTKeysDir,
+		Algorithm:    generalConfig.JWTAlgorithm,
+		GracePeriod:  generalConfig.JWTKeyGracePeriod,
+		RotateAfter:  generalConfig.JWTKeyRotation,
+		LegacySecret: generalConfig.JWTKey,
+		AcceptLegacy: generalConfig.JWTAcceptLegacy,
+	})
+	if err != nil {
+		log.Fatalf("failed to load the signing keys: %v", err)
+	}
+
+	if generalConfig.JWTKeysDir == "" {
+		log.Printf("WARNING: the tokens are signed with JWTKEY (HS256), set JWTKEYSDIR to sign them with keys other services can verify")
+	} else {
+		log.Printf("tokens signed with the keys of %s, %d public keys published", generalConfig.JWTKeysDir, len(keys.JWKS().Keys))
+	}
+	return keys
+}
+
+// newOAuthProviders returns the providers users can log in with, by name.
+// With GeneralConfig.OAuthFakeProvider set, a fake OpenID Connect provider is started and offered as "fake".
+func newOAuthProviders(generalConfig GeneralConfig) map[string]oauth.Provider {
+	configs := generalConfig.OAuthProviders
+
+	if addr := generalConfig.OAuthFakeProvider; addr != "" {
+		issuer := "http://" + addr
+		go func() {
+			if err := http.ListenAndServe(addr, oauth.NewFakeProvider(issuer)); err != nil {
+				log.Printf("the fake OAuth provider stopped: %v", err)
+			}
+		}()
+		log.Printf("WARNING: the fake OAuth provider is listening on %s, anyone can log in with it, never enable it in production", issuer)
+
+		configs = append(configs, oauth.ProviderConfig{
+			Name:        "fake",
+			Kind:        oauth.KindOIDC,
+			ClientID:    "dialosoft-dev",
+			RedirectURL: generalConfig.AppURL + "/oauth/fake/callback",
+			Issuer:      issuer,
+		})
+	}
+
+	providers := make(map[string]oauth.Provider, len(configs))
+	for _, config := range configs {
+		provider, err := oauth.NewProvider(config)
+		if err != nil {
+			log.Fatalf("failed to configure the OAuth provider %s: %v", config.Name, err)
+		}
+		providers[config.Name] = provider
+	}
+
+	return providers
+}
+
+// newMailTransport returns the email transport selected by GeneralConfig.MailTransport.
+func newMailTransport(generalConfig GeneralConfig) email.Transport {
+	switch generalConfig.MailTransport {
+	case "", "smtp":
+		return email.NewSMTPTransport(email.SMTPConfig{
+			Host:        generalConfig.SMTPHost,
+			Port:        generalConfig.SMTPPort,
+			Username:    generalConfig.MailUsername,
+			Password:    generalConfig.MailPassword,
+			FromAddress: generalConfig.FromAddress,
+		})
+	case "maildir":
+		dir := generalConfig.MaildirPath
+		if dir == "" {
+			dir = "./maildir"
+		}
+		transport, err := email.NewMaildirTransport(dir, generalConfig.FromAddress)
+		if err != nil {
+			log.Fatalf("failed to create the maildir %s: %v", dir, err)
+		}
+		return transport
+	case "memory":
+		return email.NewMemoryTransport()
+	default:
+		log.Fatalf("unknown mail transport %q", generalConfig.MailTransport)
+		return nil
+	}
+}
@@ -0,0 +1,26 @@
+package database
+
+import "gorm.io/gorm"
+
+// protectAuditLog makes the audit log append-only, the database refuses to update or delete its entries,
+// even when asked by the application.
+func protectAuditLog(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'the audit log is append-only';
+END;
+$$ LANGUAGE plpgsql`).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs").Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_logs
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`).Error
+	})
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyRolePermissionsTable held the four flags roles had before the permission registry existed.
+const legacyRolePermissionsTable = "role_permissions"
+
+// defaultRoleTypes are the roles created by createDefaultRoles, their permissions follow the registry defaults.
+var defaultRoleTypes = []string{"user", "moderator", "administrator"}
+
+type legacyRolePermissions struct {
+	RoleID              string
+	CanManageCategories bool
+	CanManageForums     bool
+	CanManageRoles      bool
+	CanManageUsers      bool
+}
+
+// migrateLegacyRolePermissions grants the roles created by the administrators the defaults of their
+// flags plus the permissions matching the flags of role_permissions, then drops that table.
+// The default roles are left to syncPermissions.
+func migrateLegacyRolePermissions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var legacy []legacyRolePermissions
+		if err := tx.Table(legacyRolePermissionsTable).Find(&legacy).Error; err != nil {
+			return err
+		}
+		legacyByRole := make(map[string]legacyRolePermissions, len(legacy))
+		for _, flags := range legacy {
+			legacyByRole[flags.RoleID] = flags
+		}
+
+		var roles []models.RoleEntity
+		if err := tx.Unscoped().Where("role_type NOT IN ?", defaultRoleTypes).Find(&roles).Error; err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			granted := permissions.Defaults(role.AdminRole, role.ModRole)
+
+			flags := legacyByRole[role.ID.String()]
+			for permission, flag := range map[string]bool{
+				permissions.CategoryManage: flags.CanManageCategories,
+				permissions.ForumManage:    flags.CanManageForums,
+				permissions.RoleManage:     flags.CanManageRoles,
+				permissions.UserManage:     flags.CanManageUsers,
+			} {
+				if flag && !slices.Contains(granted, permission) {
+					granted = append(granted, permission)
+				}
+			}
+
+			if err := grantPermissions(tx, role, granted); err != nil {
+				return err
+			}
+			logger.Info("Role permissions migrated to the permission registry", map[string]interface{}{
+				"roleID":      role.ID,
+				"roleType":    role.RoleType,
+				"permissions": granted,
+			})
+		}
+
+		if err := tx.Migrator().DropTable(legacyRolePermissionsTable); err != nil {
+			return fmt.Errorf("failed to drop %s: %w", legacyRolePermissionsTable, err)
+		}
+		return nil
+	})
+}
+
+// syncPermissions records the permissions of the registry that were not seen before and grants them
+// to the default roles, the administrator roles and the moderator roles whose defaults include them.
+// Permissions revoked from these roles by the administrators are not granted again on the next start.
+func syncPermissions(db *gorm.DB) error {
+	var known []string
+	if err := db.Model(&models.PermissionEntity{}).Pluck("name", &known).Error; err != nil {
+		return err
+	}
+
+	var added []string
+	for _, name := range permissions.Names() {
+		if !slices.Contains(known, name) {
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var roles []models.RoleEntity
+		if err := tx.Where("role_type IN ? OR admin_role = ? OR mod_role = ?", defaultRoleTypes, true, true).Find(&roles).Error; err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			defaults := permissions.Defaults(role.AdminRole, role.ModRole)
+
+			var granted []string
+			for _, name := range added {
+				if slices.Contains(defaults, name) {
+					granted = append(granted, name)
+				}
+			}
+			if err := grantPermissions(tx, role, granted); err != nil {
+				return err
+			}
+		}
+
+		entities := make([]models.PermissionEntity, len(added))
+		for i, name := range added {
+			entities[i] = models.PermissionEntity{Name: name}
+		}
+		if err := tx.Create(&entities).Error; err != nil {
+			return err
+		}
+
+		logger.Info("New permissions added to the registry", map[string]interface{}{"permissions": added})
+		return nil
+	})
+}
+
+func grantPermissions(tx *gorm.DB, role models.RoleEntity, granted []string) error {
+	if len(granted) == 0 {
+		return nil
+	}
+
+	grants := make([]models.RolePermissionEntity, len(granted))
+	for i, permission := range granted {
+		grants[i] = models.RolePermissionEntity{RoleID: role.ID, Permission: permission}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&grants).Error; err != nil {
+		return fmt.Errorf("failed to grant permissions to role %s: %w", role.RoleType, err)
+	}
+	return nil
+}
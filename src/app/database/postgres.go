@@ -28,6 +28,13 @@ func ConnectToDatabase(conf config.GeneralConfig) (Connection, error) {
 		return Connection{}, err
 	}
 
+	// accounts created before email verification existed are trusted as verified
+	verifyExistingUsers := db.Migrator().HasTable(&models.UserEntity{}) &&
+		!db.Migrator().HasColumn(&models.UserEntity{}, "EmailVerified")
+
+	// roles created before the permission registry existed have their flags converted to permissions
+	migrateRolePermissions := db.Migrator().HasTable(legacyRolePermissionsTable)
+
 	err = db.AutoMigrate(
 		models.UserEntity{},
 		models.RoleEntity{},
@@ -38,17 +45,60 @@ func ConnectToDatabase(conf config.GeneralConfig) (Connection, error) {
 		models.Comment{},
 		models.PostLikes{},
 		models.CommentVotes{},
-		models.RolePermissions{},
+		models.PermissionEntity{},
+		models.RolePermissionEntity{},
+		models.ActionTokenEntity{},
+		models.OutboxEmailEntity{},
+		models.RecoveryCodeEntity{},
+		models.IdentityEntity{},
+		models.PersonalTokenEntity{},
+		models.ModeratorEntity{},
+		models.BanEntity{},
+		models.ReportEntity{},
+		models.ReportEntryEntity{},
+		models.AuditLogEntity{},
 	)
 	if err != nil {
 		return Connection{}, err
 	}
 
+	if verifyExistingUsers {
+		if err := db.Model(&models.UserEntity{}).Where("1 = 1").Update("email_verified", true).Error; err != nil {
+			return Connection{}, err
+		}
+	}
+
+	if err := protectAuditLog(db); err != nil {
+		return Connection{}, err
+	}
+
+	if err := backfillTokenFamilies(db); err != nil {
+		return Connection{}, err
+	}
+
+	if err := backfillIdentityKeys(db); err != nil {
+		return Connection{}, err
+	}
+
+	if err := backfillLegacyBans(db); err != nil {
+		return Connection{}, err
+	}
+
+	if migrateRolePermissions {
+		if err := migrateLegacyRolePermissions(db); err != nil {
+			return Connection{}, err
+		}
+	}
+
 	defaultRoles, err := createDefaultRoles(db)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return Connection{}, err
 	}
 
+	if err := syncPermissions(db); err != nil {
+		return Connection{}, err
+	}
+
 	return Connection{
 		Gorm:            db,
 		DefaultRolesIDs: defaultRoles,
@@ -104,16 +154,13 @@ func createDefaultRoles(db *gorm.DB) (map[string]uuid.UUID, error) {
 				roleMap[role.RoleType] = role.ID
 			} else {
 				role.ID = existingRole.ID
+				// settings chosen by the administrators are kept
+				role.RequireMFA = existingRole.RequireMFA
 				if err := tx.Save(&role).Error; err != nil {
 					return fmt.Errorf("failed to update role %s: %w", role.RoleType, err)
 				}
 				roleMap[role.RoleType] = existingRole.ID
 			}
-
-			rolePermissions := getRolePermissions(role.RoleType, role.ID)
-			if err := tx.Where("role_id = ?", role.ID).Assign(rolePermissions).FirstOrCreate(&models.RolePermissions{}).Error; err != nil {
-				return fmt.Errorf("failed to create or update role permissions for %s: %w", role.RoleType, err)
-			}
 		}
 		return nil
 	})
@@ -124,40 +171,64 @@ func createDefaultRoles(db *gorm.DB) (map[string]uuid.UUID, error) {
 	return roleMap, nil
 }
 
-func getRolePermissions(roleType string, roleID uuid.UUID) models.RolePermissions {
-	switch roleType {
-	case "user":
-		return models.RolePermissions{
-			RoleID:              roleID,
-			CanManageCategories: false,
-			CanManageForums:     false,
-			CanManageRoles:      false,
-			CanManageUsers:      false,
-		}
-	case "moderator":
-		return models.RolePermissions{
-			RoleID:              roleID,
-			CanManageCategories: false,
-			CanManageForums:     false,
-			CanManageRoles:      false,
-			CanManageUsers:      true,
-		}
-	case "administrator":
-		return models.RolePermissions{
-			RoleID:              roleID,
-			CanManageCategories: true,
-			CanManageForums:     true,
-			CanManageRoles:      true,
-			CanManageUsers:      true,
+// backfillTokenFamilies makes every token created before token families existed its own session.
+func backfillTokenFamilies(db *gorm.DB) error {
+	return db.Model(&models.TokenEntity{}).
+		Where("family_id IS NULL").
+		Update("family_id", gorm.Expr("id")).Error
+}
+
+// backfillIdentityKeys fills the normalised username and email of the users created before they existed.
+// Users that collide with another account once normalised are left without them and reported,
+// an administrator has to rename them.
+func backfillIdentityKeys(db *gorm.DB) error {
+	var users []models.UserEntity
+	if err := db.Unscoped().
+		Select("id", "username", "email").
+		Where("username_key IS NULL OR email_key IS NULL").
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		user.SetIdentityKeys()
+		err := db.Unscoped().Model(&models.UserEntity{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"username_key": user.UsernameKey,
+				"email_key":    user.EmailKey,
+			}).Error
+		if err != nil {
+			logger.Warn("User collides with another account once normalised, it must be renamed", map[string]interface{}{
+				"userID":   user.ID,
+				"username": user.Username,
+				"error":    err.Error(),
+			})
 		}
-	default:
-		return models.RolePermissions{}
 	}
+
+	return nil
+}
+
+// backfillLegacyBans gives the users banned before the ban records existed a ban that lasts until it is lifted,
+// otherwise the ban expiry worker would find no active ban and unban them.
+func backfillLegacyBans(db *gorm.DB) error {
+	now := time.Now()
+	return db.Exec(`INSERT INTO bans (user_id, reason, issued_by, starts_at, created_at)
+		SELECT users.id, ?, ?, ?, ? FROM users
+		WHERE users.banned = true AND NOT EXISTS (
+			SELECT 1 FROM bans WHERE bans.user_id = users.id AND bans.lifted_at IS NULL AND bans.starts_at <= ?
+			AND (bans.expires_at IS NULL OR bans.expires_at > ?))`,
+		models.LegacyBanReason, uuid.Nil, now, now, now, now).Error
 }
 
 func checkOldAndBlockedTokens(db *gorm.DB) {
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
 
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.ActionTokenEntity{}).Error; err != nil {
+		logger.CaptureError(err, "error deleting expired action tokens", nil)
+	}
+
 	var tokens []models.TokenEntity
 
 	err := db.Where("blocked = ? OR created_at < ?", true, thirtyDaysAgo).Find(&tokens).Error
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ActionPasswordReset is the purpose of the tokens sent to reset a forgotten password.
+	ActionPasswordReset = "password_reset"
+
+	// ActionVerifyEmail is the purpose of the tokens sent to confirm the email address of an account.
+	ActionVerifyEmail = "verify_email"
+
+	// ActionChangeEmail is the purpose of the tokens sent to confirm a new email address, which is kept in the payload.
+	ActionChangeEmail = "change_email"
+)
+
+// ActionTokenEntity is a single-use token sent to a user to confirm an action, such as a password reset.
+// Only the SHA-256 hash of the token is stored.
+type ActionTokenEntity struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID  `json:"userID" gorm:"type:uuid;not null;index"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(50);not null"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);unique;not null"`
+	Payload   string     `json:"payload" gorm:"type:text"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (ActionTokenEntity) TableName() string {
+	return "action_tokens"
+}
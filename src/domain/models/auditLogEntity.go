@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditUserRoleChange  = "user.role.change"
+	AuditUserDelete      = "user.delete"
+	AuditUserRestore     = "user.restore"
+	AuditUserLoginUnlock = "user.login.unlock"
+	AuditUserBan         = "user.ban"
+	AuditUserUnban       = "user.unban"
+
+	AuditRoleCreate         = "role.create"
+	AuditRoleUpdate         = "role.update"
+	AuditRolePermissionsSet = "role.permissions.set"
+	AuditRoleRequireMFASet  = "role.require_mfa.set"
+	AuditRoleDelete         = "role.delete"
+	AuditRoleRestore        = "role.restore"
+
+	AuditForumCreate  = "forum.create"
+	AuditForumUpdate  = "forum.update"
+	AuditForumDelete  = "forum.delete"
+	AuditForumRestore = "forum.restore"
+
+	AuditCategoryCreate  = "category.create"
+	AuditCategoryUpdate  = "category.update"
+	AuditCategoryDelete  = "category.delete"
+	AuditCategoryRestore = "category.restore"
+
+	AuditPostLock       = "post.lock"
+	AuditPostUnlock     = "post.unlock"
+	AuditPostPin        = "post.pin"
+	AuditPostUnpin      = "post.unpin"
+	AuditPostAnnounce   = "post.announce"
+	AuditPostUnannounce = "post.unannounce"
+	AuditPostMove       = "post.move"
+	AuditPostMerge      = "post.merge"
+
+	AuditCommentDelete  = "comment.delete"
+	AuditCommentRestore = "comment.restore"
+	AuditCommentBest    = "comment.best"
+	AuditCommentUnbest  = "comment.unbest"
+
+	AuditModeratorAdd    = "moderator.add"
+	AuditModeratorRemove = "moderator.remove"
+
+	AuditReportResolve = "report.resolve"
+)
+
+// Types of the targets of the audit log entries.
+const (
+	AuditTargetUser      = "user"
+	AuditTargetIP        = "ip"
+	AuditTargetRole      = "role"
+	AuditTargetForum     = "forum"
+	AuditTargetCategory  = "category"
+	AuditTargetPost      = "post"
+	AuditTargetComment   = "comment"
+	AuditTargetModerator = "moderator"
+	AuditTargetReport    = "report"
+)
+
+// AuditLogEntity records a privileged action, who took it, from where, and the target before and after it.
+// The table is append-only, the database refuses to update or delete its rows.
+type AuditLogEntity struct {
+	ID uint64 `json:"id" gorm:"primaryKey;autoIncrement"`
+
+	// ActorID is nil for the actions taken by the application itself.
+	ActorID    *uuid.UUID `json:"actorID" gorm:"type:uuid;index"`
+	Action     string     `json:"action" gorm:"type:varchar(100);not null;index"`
+	TargetType string     `json:"targetType" gorm:"type:varchar(50);not null;index:idx_audit_logs_target"`
+	TargetID   string     `json:"targetID" gorm:"type:varchar(100);index:idx_audit_logs_target"`
+
+	// Before and After are JSON snapshots of the target, nil when it did not exist before or after the action.
+	Before json.RawMessage `json:"before" gorm:"type:jsonb"`
+	After  json.RawMessage `json:"after" gorm:"type:jsonb"`
+
+	IPAddress string    `json:"ipAddress" gorm:"type:varchar(45)"`
+	RequestID string    `json:"requestID" gorm:"type:varchar(100)"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime;index"`
+}
+
+func (AuditLogEntity) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LegacyBanReason is the reason of the bans given to the users banned before the ban records existed.
+const LegacyBanReason = "Banned before ban reasons were recorded"
+
+// BanEntity records a ban of a user by a moderator, lasting until ExpiresAt, or until it is lifted if nil.
+// A user has at most one active ban, issuing a new one lifts the previous.
+type BanEntity struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID  `json:"userID" gorm:"type:uuid;not null;index"`
+	Reason    string     `json:"reason" gorm:"type:varchar(500);not null"`
+	IssuedBy  uuid.UUID  `json:"issuedBy" gorm:"type:uuid;not null"`
+	StartsAt  time.Time  `json:"startsAt" gorm:"not null"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	LiftedAt  *time.Time `json:"liftedAt"`
+	LiftedBy  *uuid.UUID `json:"liftedBy" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (BanEntity) TableName() string {
+	return "bans"
+}
+
+// Active reports whether the ban applies at the given time.
+func (ban *BanEntity) Active(at time.Time) bool {
+	return ban.LiftedAt == nil && !ban.StartsAt.After(at) && (ban.ExpiresAt == nil || ban.ExpiresAt.After(at))
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentityEntity links a user to their account at an external OAuth2 or OpenID Connect provider.
+// A provider account belongs to a single user, and a user has at most one account per provider.
+type IdentityEntity struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID      uuid.UUID  `json:"userID" gorm:"type:uuid;not null;index;uniqueIndex:idx_identities_user_provider"`
+	Provider    string     `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_identities_provider_subject;uniqueIndex:idx_identities_user_provider"`
+	Subject     string     `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_identities_provider_subject"`
+	Email       string     `json:"email" gorm:"type:varchar(100)"`
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (IdentityEntity) TableName() string {
+	return "identities"
+}
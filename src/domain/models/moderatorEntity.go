@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModeratorEntity makes a user, or every user of a role, moderator of a forum or of every forum of a category.
+// Exactly one of ForumID and CategoryID is set, and exactly one of UserID and RoleID.
+type ModeratorEntity struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	ForumID    *uuid.UUID `json:"forumID" gorm:"type:uuid;index"`
+	CategoryID *uuid.UUID `json:"categoryID" gorm:"type:uuid;index"`
+	UserID     *uuid.UUID `json:"userID" gorm:"type:uuid;index"`
+	RoleID     *uuid.UUID `json:"roleID" gorm:"type:uuid;index"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (ModeratorEntity) TableName() string {
+	return "moderators"
+}
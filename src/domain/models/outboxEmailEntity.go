@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status of the emails in the outbox.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// OutboxEmailEntity is an email waiting in the outbox to be delivered by the outbox worker.
+// The email is rendered when it is queued, so retries always send the same content.
+type OutboxEmailEntity struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Recipient     string     `json:"recipient" gorm:"type:varchar(255);not null"`
+	Template      string     `json:"template" gorm:"type:varchar(50)"`
+	Subject       string     `json:"subject" gorm:"type:text;not null"`
+	TextBody      string     `json:"textBody" gorm:"type:text"`
+	HTMLBody      string     `json:"htmlBody" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_outbox_due,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"not null;index:idx_outbox_due,priority:2"`
+	LastError     string     `json:"lastError" gorm:"type:text"`
+	SentAt        *time.Time `json:"sentAt"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+func (OutboxEmailEntity) TableName() string {
+	return "email_outbox"
+}
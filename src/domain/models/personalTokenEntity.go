@@ -0,0 +1,66 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ScopeRead lets a personal access token make GET requests.
+	ScopeRead = "read"
+
+	// ScopeWrite lets a personal access token make the requests that change something (POST, PUT, DELETE...).
+	ScopeWrite = "write"
+
+	// ScopeAdmin lets a personal access token reach the routes restricted to a role, if the user has that role.
+	ScopeAdmin = "admin"
+)
+
+// PersonalTokenScopes are the scopes a personal access token can be given.
+var PersonalTokenScopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// PersonalTokenEntity is a long-lived token a user creates for a bot or an integration, used instead of
+// logging in. It acts as its user with the role the user has, within its scopes.
+// Only the SHA-256 hash of the token is stored, the token itself is shown once when it is created.
+type PersonalTokenEntity struct {
+	ID     uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID uuid.UUID `json:"userID" gorm:"type:uuid;not null;index;uniqueIndex:idx_personal_tokens_user_name"`
+	Name   string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_personal_tokens_user_name"`
+
+	// Prefix is the beginning of the token, enough for the user to recognise it in the list.
+	Prefix    string `json:"prefix" gorm:"type:varchar(20);not null"`
+	TokenHash string `json:"-" gorm:"type:varchar(64);unique;not null"`
+
+	// Scopes is the comma-separated list of the scopes of the token.
+	Scopes string `json:"scopes" gorm:"type:varchar(100);not null"`
+
+	// ExpiresAt is nil for the tokens that never expire.
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (PersonalTokenEntity) TableName() string {
+	return "personal_tokens"
+}
+
+// ScopeList returns the scopes of the token.
+func (token *PersonalTokenEntity) ScopeList() []string {
+	if token.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(token.Scopes, ",")
+}
+
+// HasScope reports whether the token has been given a scope.
+func (token *PersonalTokenEntity) HasScope(scope string) bool {
+	return slices.Contains(token.ScopeList(), scope)
+}
+
+// Expired reports whether the token can no longer be used.
+func (token *PersonalTokenEntity) Expired() bool {
+	return token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt)
+}
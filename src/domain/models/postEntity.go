@@ -8,15 +8,29 @@ import (
 )
 
 type Post struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	User      UserEntity     `gorm:"foreignKey:UserID" json:"user"`
-	ForumID   uuid.UUID      `gorm:"type:uuid;not null" json:"forum_id"`
-	Forum     Forum          `gorm:"foreignKey:ForumID" json:"forum"`
-	Title     string         `gorm:"type:varchar(255)" json:"title"`
-	Content   string         `gorm:"type:text" json:"content"`
-	Views     uint32         `json:"views"`
-	Comments  uint32         `json:"comments"`
+	ID       uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID   uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	User     UserEntity `gorm:"foreignKey:UserID" json:"user"`
+	ForumID  uuid.UUID  `gorm:"type:uuid;not null" json:"forum_id"`
+	Forum    Forum      `gorm:"foreignKey:ForumID" json:"forum"`
+	Title    string     `gorm:"type:varchar(255)" json:"title"`
+	Content  string     `gorm:"type:text" json:"content"`
+	Views    uint32     `json:"views"`
+	Comments uint32     `json:"comments"`
+
+	// Locked posts accept no new comments, except from those who can lock them.
+	Locked bool `gorm:"not null;default:false" json:"locked"`
+
+	// PinnedAt is set while the post is pinned to the top of the listings of its forum.
+	PinnedAt *time.Time `json:"pinnedAt"`
+
+	// Announcement posts are listed first in every forum, not only in their own.
+	Announcement bool `gorm:"not null;default:false;index" json:"announcement"`
+
+	// RedirectToID is set on the redirect stubs left behind when a post is moved or merged,
+	// it points to where the thread is now. Stubs have no comments of their own.
+	RedirectToID *uuid.UUID `gorm:"type:uuid;index" json:"redirectToID"`
+
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `json:"deletedAt"`
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ReportTargetPost, ReportTargetComment and ReportTargetUser are what a report can be about.
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+
+	// ReportStatusOpen reports wait in the moderation queue, ReportStatusClaimed reports are being acted on
+	// by a moderator, ReportStatusResolved reports have been acted on.
+	ReportStatusOpen     = "open"
+	ReportStatusClaimed  = "claimed"
+	ReportStatusResolved = "resolved"
+
+	// Actions a moderator can resolve a report with.
+	ReportActionDismiss = "dismiss"
+	ReportActionDelete  = "delete"
+	ReportActionWarn    = "warn"
+	ReportActionBan     = "ban"
+)
+
+// ReportTargets are the types of report targets.
+var ReportTargets = []string{ReportTargetPost, ReportTargetComment, ReportTargetUser}
+
+// ReportEntity is an entry of the moderation queue about a post, a comment or a user.
+// Every user reporting the same target while its report is open adds a ReportEntryEntity to it,
+// so a target has at most one open report.
+type ReportEntity struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	TargetType string    `json:"targetType" gorm:"type:varchar(20);not null;uniqueIndex:idx_reports_open_target,where:status = 'open'"`
+	TargetID   uuid.UUID `json:"targetID" gorm:"type:uuid;not null;index;uniqueIndex:idx_reports_open_target,where:status = 'open'"`
+
+	// TargetUserID is the author of the reported post or comment, or the reported user.
+	TargetUserID uuid.UUID `json:"targetUserID" gorm:"type:uuid;not null;index"`
+
+	// ForumID is the forum of the reported post or comment, nil for the reports of users.
+	ForumID *uuid.UUID `json:"forumID" gorm:"type:uuid;index"`
+
+	Status      string `json:"status" gorm:"type:varchar(20);not null;default:'open';index"`
+	ReportCount int    `json:"reportCount" gorm:"not null;default:1"`
+	Action      string `json:"action" gorm:"type:varchar(20)"`
+	ActionNote  string `json:"actionNote" gorm:"type:varchar(500)"`
+	// ResolvedBy is the moderator who claimed the report, then resolved it.
+	ResolvedBy *uuid.UUID          `json:"resolvedBy" gorm:"type:uuid"`
+	ClaimedAt  *time.Time          `json:"claimedAt"`
+	ResolvedAt *time.Time          `json:"resolvedAt"`
+	Entries    []ReportEntryEntity `json:"entries" gorm:"foreignKey:ReportID"`
+	CreatedAt  time.Time           `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time           `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+func (ReportEntity) TableName() string {
+	return "reports"
+}
+
+// ReportEntryEntity is the report of a user, a user adds at most one to a report.
+type ReportEntryEntity struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	ReportID   uuid.UUID `json:"reportID" gorm:"type:uuid;not null;uniqueIndex:idx_report_entries_reporter"`
+	ReporterID uuid.UUID `json:"reporterID" gorm:"type:uuid;not null;uniqueIndex:idx_report_entries_reporter"`
+	Reason     string    `json:"reason" gorm:"type:varchar(20);not null"`
+	Details    string    `json:"details" gorm:"type:varchar(1000)"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (ReportEntryEntity) TableName() string {
+	return "report_entries"
+}
@@ -14,6 +14,7 @@ type RoleEntity struct {
 	AdminRole  bool           `json:"adminRole"`
 	ModRole    bool           `json:"modRole"`
 	UserRole   bool           `json:"userRole"`
+	RequireMFA bool           `json:"requireMFA" gorm:"column:require_mfa;default:false"`
 	CreatedAt  time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt  time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -1,15 +1,29 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
 
-type RolePermissions struct {
-	RoleID              uuid.UUID `gorm:"type:uuid;primaryKey"`
-	CanManageCategories bool      `gorm:"type:bool"`
-	CanManageForums     bool      `gorm:"type:bool"`
-	CanManageRoles      bool      `gorm:"type:bool"`
-	CanManageUsers      bool      `gorm:"type:bool"`
+	"github.com/google/uuid"
+)
+
+// PermissionEntity records a permission of the registry once it has been seen, so the permissions
+// added by an upgrade can be granted to the default roles a single time.
+type PermissionEntity struct {
+	Name      string    `json:"name" gorm:"type:varchar(100);primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PermissionEntity) TableName() string {
+	return "permissions"
+}
+
+// RolePermissionEntity grants a permission of the registry to a role.
+type RolePermissionEntity struct {
+	RoleID     uuid.UUID `json:"roleID" gorm:"type:uuid;primaryKey"`
+	Permission string    `json:"permission" gorm:"type:varchar(100);primaryKey"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
-func (RolePermissions) TableName() string {
-	return "role_permissions"
+func (RolePermissionEntity) TableName() string {
+	return "role_permission_grants"
 }
@@ -6,14 +6,22 @@ import (
 	"github.com/google/uuid"
 )
 
+// TokenEntity is a refresh token. Every login opens a session identified by FamilyID,
+// and each refresh retires the current token (RotatedAt) in favour of a new one of the same family.
 type TokenEntity struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
-	Token     string    `json:"token" gorm:"type:text;not null"`
-	UserID    uuid.UUID `json:"userID" gorm:"type:uuid;not null"`
-	Blocked   bool      `json:"status" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Token      string     `json:"token" gorm:"type:text;not null"`
+	UserID     uuid.UUID  `json:"userID" gorm:"type:uuid;not null"`
+	FamilyID   uuid.UUID  `json:"familyID" gorm:"type:uuid;index"`
+	Blocked    bool       `json:"status" gorm:"not null"`
+	DeviceName string     `json:"device_name" gorm:"type:varchar(100)"`
+	UserAgent  string     `json:"user_agent" gorm:"type:text"`
+	IPAddress  string     `json:"ip_address" gorm:"type:varchar(45)"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RotatedAt  *time.Time `json:"rotated_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
 }
 
 func (TokenEntity) TableName() string {
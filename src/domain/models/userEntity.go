@@ -3,25 +3,53 @@ package models
 import (
 	"time"
 
+	"github.com/Dialosoft/src/pkg/utils/identity"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type UserEntity struct {
-	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	Username    string         `json:"username" gorm:"type:varchar(100);unique;not null"`
-	Email       string         `json:"email" gorm:"type:varchar(100);unique;not null"`
-	Password    string         `json:"password" gorm:"type:varchar(255);not null"`
-	Name        string         `json:"name" gorm:"type:varchar(255)"`
-	Description string         `json:"description" gorm:"type:text"`
-	Banned      bool           `json:"banned" gorm:"type:boolean;default:false"`
-	RoleID      uuid.UUID      `json:"roleID" gorm:"type:uuid"`
-	Role        RoleEntity     `json:"role" gorm:"foreignKey:RoleID"`
-	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID                 uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Username           string         `json:"username" gorm:"type:varchar(100);unique;not null"`
+	UsernameKey        *string        `json:"-" gorm:"type:varchar(255);uniqueIndex"`
+	Email              string         `json:"email" gorm:"type:varchar(100);unique;not null"`
+	EmailKey           *string        `json:"-" gorm:"type:varchar(255);uniqueIndex"`
+	Password           string         `json:"password" gorm:"type:varchar(255);not null"`
+	MustChangePassword bool           `json:"must_change_password" gorm:"column:must_change_password;type:boolean;default:false"`
+	Name               string         `json:"name" gorm:"type:varchar(255)"`
+	Description        string         `json:"description" gorm:"type:text"`
+	Banned             bool           `json:"banned" gorm:"type:boolean;default:false"`
+	EmailVerified      bool           `json:"email_verified" gorm:"type:boolean;default:false"`
+	Locale             string         `json:"locale" gorm:"type:varchar(10);default:'en'"`
+	TOTPSecret         string         `json:"-" gorm:"column:totp_secret;type:varchar(255)"`
+	TOTPEnabled        bool           `json:"totp_enabled" gorm:"column:totp_enabled;type:boolean;default:false"`
+	TOTPCounter        int64          `json:"-" gorm:"column:totp_counter;default:0"`
+	RoleID             uuid.UUID      `json:"roleID" gorm:"type:uuid"`
+	Role               RoleEntity     `json:"role" gorm:"foreignKey:RoleID"`
+	CreatedAt          time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt          gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 func (UserEntity) TableName() string {
 	return "users"
 }
+
+// BeforeCreate fills the normalised forms of the username and email, which make them unique
+// regardless of case and Unicode representation.
+func (user *UserEntity) BeforeCreate(tx *gorm.DB) error {
+	user.SetIdentityKeys()
+	return nil
+}
+
+// SetIdentityKeys computes UsernameKey and EmailKey from Username and Email, if they are set.
+func (user *UserEntity) SetIdentityKeys() {
+	if user.Username != "" {
+		usernameKey := identity.NormalizeUsername(user.Username)
+		user.UsernameKey = &usernameKey
+	}
+	if user.Email != "" {
+		emailKey := identity.NormalizeEmail(user.Email)
+		user.EmailKey = &emailKey
+	}
+}
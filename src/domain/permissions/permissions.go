@@ -0,0 +1,146 @@
+// Package permissions is the registry of the capabilities roles can grant.
+package permissions
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Permissions of the registry. The ".own" permissions apply to what the user created,
+// the ".any" permissions to what anybody created.
+const (
+	PostCreate    = "post.create"
+	PostEditOwn   = "post.edit.own"
+	PostEditAny   = "post.edit.any"
+	PostDeleteOwn = "post.delete.own"
+	PostDeleteAny = "post.delete.any"
+	PostLock      = "post.lock"
+	PostPin       = "post.pin"
+	PostAnnounce  = "post.announce"
+	PostMove      = "post.move"
+
+	CommentCreate    = "comment.create"
+	CommentEditOwn   = "comment.edit.own"
+	CommentEditAny   = "comment.edit.any"
+	CommentDeleteOwn = "comment.delete.own"
+	CommentDeleteAny = "comment.delete.any"
+	CommentBestAny   = "comment.best.any"
+
+	CategoryManage = "category.manage"
+	ForumManage    = "forum.manage"
+	RoleManage     = "role.manage"
+	UserManage     = "user.manage"
+	UserBan        = "user.ban"
+	ReportReview   = "report.review"
+	AuditRead      = "audit.read"
+)
+
+// Permission is a capability a role can grant.
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Privileged permissions act on what other users created or on the forum itself.
+	// Personal access tokens need the admin scope to use them.
+	Privileged bool `json:"privileged"`
+}
+
+var registry = []Permission{
+	{Name: PostCreate, Description: "Create posts"},
+	{Name: PostEditOwn, Description: "Edit their own posts"},
+	{Name: PostEditAny, Description: "Edit the posts of anybody", Privileged: true},
+	{Name: PostDeleteOwn, Description: "Delete and restore their own posts"},
+	{Name: PostDeleteAny, Description: "Delete and restore the posts of anybody", Privileged: true},
+	{Name: PostLock, Description: "Lock and unlock threads", Privileged: true},
+	{Name: PostPin, Description: "Pin and unpin threads", Privileged: true},
+	{Name: PostAnnounce, Description: "Mark and unmark the announcements shown in every forum", Privileged: true},
+	{Name: PostMove, Description: "Move threads to other forums and merge them", Privileged: true},
+
+	{Name: CommentCreate, Description: "Comment on posts"},
+	{Name: CommentEditOwn, Description: "Edit their own comments"},
+	{Name: CommentEditAny, Description: "Edit the comments of anybody", Privileged: true},
+	{Name: CommentDeleteOwn, Description: "Delete and restore their own comments"},
+	{Name: CommentDeleteAny, Description: "Delete and restore the comments of anybody", Privileged: true},
+	{Name: CommentBestAny, Description: "Mark the best answer of the posts of anybody", Privileged: true},
+
+	{Name: CategoryManage, Description: "Create, update and delete categories", Privileged: true},
+	{Name: ForumManage, Description: "Create, update and delete forums", Privileged: true},
+	{Name: RoleManage, Description: "Create and update roles, their permissions, and assign them to users", Privileged: true},
+	{Name: UserManage, Description: "Delete and restore users and unlock their logins", Privileged: true},
+	{Name: UserBan, Description: "Ban and unban users", Privileged: true},
+	{Name: ReportReview, Description: "Review and resolve the reports of the users", Privileged: true},
+	{Name: AuditRead, Description: "Read and export the audit log of the privileged actions", Privileged: true},
+}
+
+// moderatorPermissions are granted to the moderator role on top of the permissions of every user.
+var moderatorPermissions = []string{
+	PostDeleteAny, PostLock, PostPin, PostAnnounce, PostMove,
+	CommentDeleteAny, CommentBestAny,
+	UserBan, ReportReview,
+}
+
+// forumModeratorPermissions are granted to the moderators of a forum or category, in its forums only.
+var forumModeratorPermissions = []string{
+	PostDeleteAny, PostLock, PostPin, PostMove,
+	CommentDeleteAny, CommentBestAny,
+	ReportReview,
+}
+
+// All returns the permissions of the registry.
+func All() []Permission {
+	return slices.Clone(registry)
+}
+
+// Names returns the names of the permissions of the registry.
+func Names() []string {
+	names := make([]string, len(registry))
+	for i, permission := range registry {
+		names[i] = permission.Name
+	}
+	return names
+}
+
+// Get returns a permission of the registry.
+func Get(name string) (Permission, bool) {
+	for _, permission := range registry {
+		if permission.Name == name {
+			return permission, true
+		}
+	}
+	return Permission{}, false
+}
+
+// Exists reports whether a permission is in the registry.
+func Exists(name string) bool {
+	_, ok := Get(name)
+	return ok
+}
+
+// MustExist panics if a permission is not in the registry, to catch typos in the routes when they are set up.
+func MustExist(name string) {
+	if !Exists(name) {
+		panic(fmt.Sprintf("permission %q is not in the registry", name))
+	}
+}
+
+// GrantedToForumModerators reports whether the moderators of a forum have a permission in that forum.
+func GrantedToForumModerators(name string) bool {
+	return slices.Contains(forumModeratorPermissions, name)
+}
+
+// Defaults returns the permissions a role is given when it is created, by its flags:
+// administrator roles get every permission, moderator roles can moderate the content and ban users,
+// and the other roles get the permissions every user has on their own content.
+func Defaults(adminRole bool, modRole bool) []string {
+	if adminRole {
+		return Names()
+	}
+
+	var defaults []string
+	for _, permission := range registry {
+		if !permission.Privileged || (modRole && slices.Contains(moderatorPermissions, permission.Name)) {
+			defaults = append(defaults, permission.Name)
+		}
+	}
+	return defaults
+}
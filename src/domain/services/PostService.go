@@ -1,11 +1,14 @@
 package services
 
 import (
+	"github.com/Dialosoft/src/adapters/dto"
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/http/response"
 	"github.com/Dialosoft/src/adapters/mapper"
 	"github.com/Dialosoft/src/adapters/repository"
 	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,12 +20,14 @@ type PostService interface {
 	GetAllPosts(limit, offset int) ([]response.PostResponse, error)
 
 	// GetPostByID fetches a post based on its unique postID.
+	// The ID of a redirect stub fetches the post it redirects to.
 	GetPostByID(postID uuid.UUID) (*response.PostResponse, error)
 
 	// GetPostsByUserID fetches all posts created by a specific user.
 	GetPostsByUserID(userID uuid.UUID) ([]response.PostResponse, error)
 
 	// GetAllPostsByForum retrieves posts from a specific forum with pagination options.
+	// The announcements of every forum come first, then the pinned posts, then the most recent posts.
 	GetAllPostsByForum(forumID uuid.UUID, limit, offset int) ([]response.PostResponse, error)
 
 	// GetAllPostsAndReturnSimpleResponse retrieves posts with simplified response data and pagination options.
@@ -54,12 +59,37 @@ type PostService interface {
 
 	// GetPostLikesByUserID retrieves a list of post IDs that a user has liked.
 	GetPostLikesByUserID(userID uuid.UUID) ([]uuid.UUID, error)
+
+	// SetPostLocked locks or unlocks a post, a locked post accepts no new comments.
+	// It needs the post.lock permission in the forum of the post.
+	SetPostLocked(postID uuid.UUID, actor dto.Actor, locked bool) error
+
+	// SetPostPinned pins a post to the top of the listings of its forum or unpins it.
+	// Pinning a pinned post keeps its place. It needs the post.pin permission in the forum of the post.
+	SetPostPinned(postID uuid.UUID, actor dto.Actor, pinned bool) error
+
+	// SetPostAnnouncement marks a post as an announcement shown in every forum or unmarks it.
+	// It needs the post.announce permission.
+	SetPostAnnouncement(postID uuid.UUID, actor dto.Actor, announcement bool) error
+
+	// MovePost moves a post to another forum, leaving a redirect stub in its previous forum if req.LeaveRedirect is set.
+	// It needs the post.move permission in both forums.
+	// Returns errorsUtils.ErrPostSameForum, errorsUtils.ErrPostForumNotFound or errorsUtils.ErrPostIsRedirect.
+	MovePost(postID uuid.UUID, actor dto.Actor, req request.MovePost) error
+
+	// MergePosts merges a post into the thread of req.TargetID: its comments join those of the target
+	// in the order they were posted, and the post becomes a redirect stub to the target.
+	// It needs the post.move permission in the forums of both posts.
+	// Returns errorsUtils.ErrPostMergeSelf or errorsUtils.ErrPostIsRedirect.
+	MergePosts(postID uuid.UUID, actor dto.Actor, req request.MergePosts) error
 }
 
 type postServiceImpl struct {
-	postRepository repository.PostRepository
-	postLikesRepo  repository.PostLikesRepository
-	userRepository repository.UserRepository
+	postRepository   repository.PostRepository
+	postLikesRepo    repository.PostLikesRepository
+	userRepository   repository.UserRepository
+	forumRepository  repository.ForumRepository
+	moderatorService ModeratorService
 }
 
 // CreateNewPost implements PostService.
@@ -125,6 +155,13 @@ func (service *postServiceImpl) GetPostByID(postID uuid.UUID) (*response.PostRes
 		return nil, err
 	}
 
+	// stubs are re-pointed when their post is merged, a single hop always reaches the thread
+	if postModel.RedirectToID != nil {
+		if postModel, err = service.postRepository.FindByID(*postModel.RedirectToID); err != nil {
+			return nil, err
+		}
+	}
+
 	postResponse := mapper.PostEntityToPostResponse(postModel)
 
 	return &postResponse, nil
@@ -238,6 +275,145 @@ func (service *postServiceImpl) GetPostLikesByUserID(userID uuid.UUID) ([]uuid.U
 	return postsIDs, nil
 }
 
-func NewPostService(postRepository repository.PostRepository, postLikesRepo repository.PostLikesRepository, userRepository repository.UserRepository) PostService {
-	return &postServiceImpl{postRepository: postRepository, postLikesRepo: postLikesRepo, userRepository: userRepository}
+// SetPostLocked implements PostService.
+func (service *postServiceImpl) SetPostLocked(postID uuid.UUID, actor dto.Actor, locked bool) error {
+	postEntity, err := service.findPostToModerate(postID, actor, permissions.PostLock)
+	if err != nil {
+		return err
+	}
+	if postEntity.Locked == locked {
+		return nil
+	}
+
+	return service.postRepository.SetLocked(postID, locked)
+}
+
+// SetPostPinned implements PostService.
+func (service *postServiceImpl) SetPostPinned(postID uuid.UUID, actor dto.Actor, pinned bool) error {
+	postEntity, err := service.findPostToModerate(postID, actor, permissions.PostPin)
+	if err != nil {
+		return err
+	}
+	if (postEntity.PinnedAt != nil) == pinned {
+		return nil
+	}
+
+	return service.postRepository.SetPinned(postID, pinned)
+}
+
+// SetPostAnnouncement implements PostService.
+func (service *postServiceImpl) SetPostAnnouncement(postID uuid.UUID, actor dto.Actor, announcement bool) error {
+	postEntity, err := service.findPostToModerate(postID, actor, permissions.PostAnnounce)
+	if err != nil {
+		return err
+	}
+	if postEntity.Announcement == announcement {
+		return nil
+	}
+
+	return service.postRepository.SetAnnouncement(postID, announcement)
+}
+
+// MovePost implements PostService.
+func (service *postServiceImpl) MovePost(postID uuid.UUID, actor dto.Actor, req request.MovePost) error {
+	postEntity, err := service.findPostToModerate(postID, actor, permissions.PostMove)
+	if err != nil {
+		return err
+	}
+	if postEntity.RedirectToID != nil {
+		return errorsUtils.ErrPostIsRedirect
+	}
+
+	forumUUID, err := uuid.Parse(req.ForumID)
+	if err != nil {
+		return errorsUtils.ErrInvalidUUID
+	}
+	if forumUUID == postEntity.ForumID {
+		return errorsUtils.ErrPostSameForum
+	}
+
+	forum, err := service.forumRepository.FindByID(forumUUID)
+	if err != nil {
+		return err
+	}
+	if forum == nil {
+		return errorsUtils.ErrPostForumNotFound
+	}
+	if err := service.checkPermission(actor, forumUUID, permissions.PostMove); err != nil {
+		return err
+	}
+
+	var stub *models.Post
+	if req.LeaveRedirect {
+		stub = &models.Post{
+			UserID:       postEntity.UserID,
+			ForumID:      postEntity.ForumID,
+			Title:        postEntity.Title,
+			Locked:       true,
+			RedirectToID: &postEntity.ID,
+			CreatedAt:    postEntity.CreatedAt,
+		}
+	}
+
+	return service.postRepository.Move(postID, forumUUID, stub)
+}
+
+// MergePosts implements PostService.
+func (service *postServiceImpl) MergePosts(postID uuid.UUID, actor dto.Actor, req request.MergePosts) error {
+	targetUUID, err := uuid.Parse(req.TargetID)
+	if err != nil {
+		return errorsUtils.ErrInvalidUUID
+	}
+	if targetUUID == postID {
+		return errorsUtils.ErrPostMergeSelf
+	}
+
+	source, err := service.findPostToModerate(postID, actor, permissions.PostMove)
+	if err != nil {
+		return err
+	}
+	target, err := service.findPostToModerate(targetUUID, actor, permissions.PostMove)
+	if err != nil {
+		return err
+	}
+	if source.RedirectToID != nil || target.RedirectToID != nil {
+		return errorsUtils.ErrPostIsRedirect
+	}
+
+	return service.postRepository.Merge(*source, target.ID)
+}
+
+// findPostToModerate retrieves a post, or returns errorsUtils.ErrUserUnauthorized
+// unless the actor has the permission in the forum of the post.
+func (service *postServiceImpl) findPostToModerate(postID uuid.UUID, actor dto.Actor, permission string) (*models.Post, error) {
+	postEntity, err := service.postRepository.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.checkPermission(actor, postEntity.ForumID, permission); err != nil {
+		return nil, err
+	}
+
+	return postEntity, nil
+}
+
+// checkPermission returns errorsUtils.ErrUserUnauthorized unless the actor has the permission in the forum.
+func (service *postServiceImpl) checkPermission(actor dto.Actor, forumID uuid.UUID, permission string) error {
+	granted, err := service.moderatorService.HasPermissionInForum(actor, forumID, permission)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return errorsUtils.ErrUserUnauthorized
+	}
+
+	return nil
+}
+
+func NewPostService(postRepository repository.PostRepository, postLikesRepo repository.PostLikesRepository,
+	userRepository repository.UserRepository, forumRepository repository.ForumRepository,
+	moderatorService ModeratorService) PostService {
+	return &postServiceImpl{postRepository: postRepository, postLikesRepo: postLikesRepo,
+		userRepository: userRepository, forumRepository: forumRepository, moderatorService: moderatorService}
 }
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/google/uuid"
+)
+
+// AuditService defines the methods to record the privileged actions in the audit log and to read it.
+type AuditService interface {
+
+	// Record appends an action to the audit log. before and after are snapshots of the target stored as JSON,
+	// nil when it did not exist before or after the action.
+	// The action has already been taken, so failing to record it is logged instead of returned.
+	Record(auditContext dto.AuditContext, action string, targetType string, targetID string, before interface{}, after interface{})
+
+	// GetEntries retrieves a page of the entries matching query, the most recent first.
+	GetEntries(query request.AuditLogQuery) (response.AuditLogPageResponse, error)
+
+	// Export writes every entry matching query to w, the oldest first, as CSV or as a JSON array
+	// depending on query.Format.
+	Export(query request.AuditLogQuery, w io.Writer) error
+}
+
+const (
+	// AuditExportCSV and AuditExportJSON are the formats the audit log is exported in.
+	AuditExportCSV  = "csv"
+	AuditExportJSON = "json"
+
+	// auditExportBatchSize is how many entries are read at a time while exporting.
+	auditExportBatchSize = 500
+)
+
+// auditCSVHeader names the columns of the CSV export.
+var auditCSVHeader = []string{
+	"id", "created_at", "actor_id", "action", "target_type", "target_id",
+	"ip_address", "request_id", "before", "after",
+}
+
+type auditServiceImpl struct {
+	auditLogRepository repository.AuditLogRepository
+}
+
+// Record implements AuditService.
+func (service *auditServiceImpl) Record(auditContext dto.AuditContext, action string, targetType string, targetID string, before interface{}, after interface{}) {
+	entry := models.AuditLogEntity{
+		ActorID:    auditContext.ActorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		IPAddress:  auditContext.IPAddress,
+		RequestID:  auditContext.RequestID,
+	}
+
+	if err := service.auditLogRepository.Create(entry); err != nil {
+		logger.CaptureError(err, "Error recording an action in the audit log", map[string]interface{}{
+			"actorID":    auditContext.ActorID,
+			"action":     action,
+			"targetType": targetType,
+			"targetID":   targetID,
+			"requestID":  auditContext.RequestID,
+		})
+	}
+}
+
+// GetEntries implements AuditService.
+func (service *auditServiceImpl) GetEntries(query request.AuditLogQuery) (response.AuditLogPageResponse, error) {
+	filter, err := auditLogFilterOf(query)
+	if err != nil {
+		return response.AuditLogPageResponse{}, err
+	}
+
+	entries, total, err := service.auditLogRepository.FindAll(filter, query.Limit, query.Offset)
+	if err != nil {
+		return response.AuditLogPageResponse{}, err
+	}
+
+	page := response.AuditLogPageResponse{
+		Total:   total,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+		Entries: make([]response.AuditLogResponse, 0, len(entries)),
+	}
+	for i := range entries {
+		page.Entries = append(page.Entries, mapper.AuditLogEntityToAuditLogResponse(&entries[i]))
+	}
+
+	return page, nil
+}
+
+// Export implements AuditService.
+func (service *auditServiceImpl) Export(query request.AuditLogQuery, w io.Writer) error {
+	filter, err := auditLogFilterOf(query)
+	if err != nil {
+		return err
+	}
+
+	if query.Format == AuditExportJSON {
+		return service.exportJSON(filter, w)
+	}
+	return service.exportCSV(filter, w)
+}
+
+func (service *auditServiceImpl) exportCSV(filter repository.AuditLogFilter, w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(auditCSVHeader); err != nil {
+		return err
+	}
+
+	err := service.auditLogRepository.FindInBatches(filter, auditExportBatchSize, func(entries []models.AuditLogEntity) error {
+		for _, entry := range entries {
+			actorID := ""
+			if entry.ActorID != nil {
+				actorID = entry.ActorID.String()
+			}
+
+			err := csvWriter.Write([]string{
+				strconv.FormatUint(entry.ID, 10),
+				entry.CreatedAt.UTC().Format(time.RFC3339),
+				actorID,
+				csvCell(entry.Action),
+				csvCell(entry.TargetType),
+				csvCell(entry.TargetID),
+				csvCell(entry.IPAddress),
+				csvCell(entry.RequestID),
+				csvCell(string(entry.Before)),
+				csvCell(string(entry.After)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		csvWriter.Flush()
+		return csvWriter.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// csvCell escapes a value of the CSV export that a spreadsheet would run as a formula,
+// such as a request ID sent by a client, by prefixing it with a quote.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (service *auditServiceImpl) exportJSON(filter repository.AuditLogFilter, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := service.auditLogRepository.FindInBatches(filter, auditExportBatchSize, func(entries []models.AuditLogEntity) error {
+		for i := range entries {
+			entryJSON, err := json.Marshal(mapper.AuditLogEntityToAuditLogResponse(&entries[i]))
+			if err != nil {
+				return err
+			}
+
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+
+			if _, err := w.Write(entryJSON); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// auditLogFilterOf converts the query of the audit log to a filter of the repository.
+// The query has been validated, the IDs and times parse.
+func auditLogFilterOf(query request.AuditLogQuery) (repository.AuditLogFilter, error) {
+	filter := repository.AuditLogFilter{
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+	}
+
+	if query.ActorID != "" {
+		actorID, err := uuid.Parse(query.ActorID)
+		if err != nil {
+			return repository.AuditLogFilter{}, err
+		}
+		filter.ActorID = &actorID
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(query.From); err != nil {
+		return repository.AuditLogFilter{}, err
+	}
+	if filter.To, err = parseAuditTime(query.To); err != nil {
+		return repository.AuditLogFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// parseAuditTime parses a bound of the audit log query, nil if it is empty.
+func parseAuditTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+// auditSnapshot marshals a snapshot of a target to JSON, nil for nil snapshots or those that cannot be marshalled.
+func auditSnapshot(snapshot interface{}) json.RawMessage {
+	if snapshot == nil {
+		return nil
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		logger.CaptureError(err, "Error marshalling a snapshot for the audit log", nil)
+		return nil
+	}
+	if bytes.Equal(snapshotJSON, []byte("null")) {
+		return nil
+	}
+
+	return snapshotJSON
+}
+
+func NewAuditService(auditLogRepository repository.AuditLogRepository) AuditService {
+	return &auditServiceImpl{auditLogRepository: auditLogRepository}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+)
+
+// auditEntries serves a fixed list of entries to the export.
+type auditEntries struct {
+	repository.AuditLogRepository
+	entries []models.AuditLogEntity
+}
+
+func (repo auditEntries) FindInBatches(filter repository.AuditLogFilter, batchSize int, fn func(entries []models.AuditLogEntity) error) error {
+	return fn(repo.entries)
+}
+
+func TestExportCSVEscapesFormulas(t *testing.T) {
+	service := NewAuditService(auditEntries{entries: []models.AuditLogEntity{{
+		ID:         7,
+		Action:     models.AuditCommentDelete,
+		TargetType: models.AuditTargetComment,
+		TargetID:   "-1+1",
+		IPAddress:  "203.0.113.9",
+		RequestID:  `=HYPERLINK("http://attacker.test","open")`,
+		Before:     []byte(`{"content":"@everyone"}`),
+		CreatedAt:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}}})
+
+	var out strings.Builder
+	if err := service.Export(request.AuditLogQuery{Format: AuditExportCSV}, &out); err != nil {
+		t.Fatalf("Export() = %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("the export is not valid CSV: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("%d rows exported, want the header and 1 entry", len(rows))
+	}
+
+	row := rows[1]
+	want := map[string]string{
+		"action":     models.AuditCommentDelete,
+		"target_id":  "'-1+1",
+		"ip_address": "203.0.113.9",
+		"request_id": `'=HYPERLINK("http://attacker.test","open")`,
+		"before":     `{"content":"@everyone"}`,
+	}
+	for i, column := range auditCSVHeader {
+		if value, ok := want[column]; ok && row[i] != value {
+			t.Errorf("%s = %q, want %q", column, row[i], value)
+		}
+	}
+}
@@ -1,14 +1,26 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/response"
 	"github.com/Dialosoft/src/adapters/mapper"
 	"github.com/Dialosoft/src/adapters/repository"
 	"github.com/Dialosoft/src/domain/models"
 	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/Dialosoft/src/pkg/passwords"
+	"github.com/Dialosoft/src/pkg/utils/identity"
 	"github.com/Dialosoft/src/pkg/utils/jsonWebToken"
 	"github.com/Dialosoft/src/pkg/utils/logger"
 	"github.com/Dialosoft/src/pkg/utils/security"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,29 +29,144 @@ import (
 // such as user registration, login, token refresh, and token invalidation.
 type AuthService interface {
 
-	// Register registers a new user based on the provided UserDto.
+	// Register registers a new user based on the provided UserDto and opens a session
+	// for the device described by the SessionDto.
+	// Returns errorsUtils.ErrUsernameReserved or errorsUtils.ErrUsernameInvalid if the username cannot be registered,
+	// or a *passwords.Violation if the password does not satisfy the policy.
 	// Returns the UUID of the created user, an access token, a refresh token, and an error if the operation fails.
-	Register(user dto.UserDto) (uuid.UUID, string, string, error)
+	Register(user dto.UserDto, session dto.SessionDto) (uuid.UUID, string, string, error)
+
+	// Login authenticates a user with the provided username or email and password, opening a new session
+	// for the device described by the SessionDto.
+	// If the user uses two-factor authentication, or their role requires it, no session is opened:
+	// the result holds an MFA token to exchange with VerifyMFA instead.
+	// Failed attempts are counted by username and IP: once too many have failed, an errorsUtils.LoginBlockedError
+	// is returned without checking the password.
+	// Returns an errorsUtils.BannedError if the user is banned.
+	// Returns the tokens of the session, or the MFA token, and an error if authentication fails.
+	Login(identifier, password string, session dto.SessionDto) (dto.LoginResultDto, error)
+
+	// LoginUser completes the login of a user authenticated by other means than their password, such as a
+	// login provider: a second factor is asked for if the user needs one, otherwise a session is opened.
+	// Returns an errorsUtils.BannedError if the user is banned.
+	LoginUser(userEntity *models.UserEntity, session dto.SessionDto) (dto.LoginResultDto, error)
+
+	// SetupMFA generates the TOTP secret of a user that has to enroll an authenticator before logging in,
+	// identified by the MFA token returned by Login.
+	// Returns errorsUtils.ErrMFATokenInvalid if the token is invalid or expired.
+	SetupMFA(mfaToken string) (response.MFASetupResponse, error)
+
+	// VerifyMFA exchanges the MFA token returned by Login and a valid TOTP or recovery code for the tokens
+	// of a new session. When the user was enrolling, two-factor authentication is enabled and the result
+	// also holds the recovery codes.
+	// Returns errorsUtils.ErrMFATokenInvalid or errorsUtils.ErrMFACodeInvalid if the token or the code are not valid,
+	// or an errorsUtils.BannedError if the user was banned in the meantime.
+	VerifyMFA(mfaToken string, code string, session dto.SessionDto) (dto.LoginResultDto, error)
+
+	// RefreshToken rotates the provided refresh token: the token is retired and a new one of the same session is issued.
+	// Presenting a retired token again revokes the whole session and returns errorsUtils.ErrRefreshTokenReused.
+	// Returns errorsUtils.ErrMFAEnrollmentRequired if the role of the user requires two-factor authentication
+	// and the user has not enabled it, or an errorsUtils.BannedError if the user is banned.
+	// Returns a new access token, a new refresh token, and an error if the operation fails.
+	RefreshToken(refreshToken string) (string, string, error)
+
+	// Logout revokes the session of the provided refresh token. The refresh token is blocked
+	// and blacklisted, and the access tokens issued for it stop being accepted.
+	// Returns an error if the refresh token is not valid or the operation fails.
+	Logout(refreshToken string) error
+
+	// LogoutAll revokes every active session of a user and deletes their personal access tokens,
+	// which would otherwise keep working for whoever holds them.
+	// Returns an error if the operation fails.
+	LogoutAll(userID uuid.UUID) error
+
+	// GetSessions retrieves the active sessions of a user, flagging the one identified by currentSessionID.
+	// Returns a slice of SessionResponse and an error if the operation fails.
+	GetSessions(userID uuid.UUID, currentSessionID uuid.UUID) ([]response.SessionResponse, error)
+
+	// RevokeSession revokes a single session of a user.
+	// Returns gorm.ErrRecordNotFound if the session does not exist, is not active or belongs to another user.
+	RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error
+
+	// ForgotPassword emails a single-use password reset link to the user registered with the given email.
+	// The user is looked up and the link issued by the password reset worker: it returns at once, doing the same
+	// work whether an account has that email or not, so callers cannot tell whether one exists.
+	ForgotPassword(email string) error
+
+	// StartPasswordResetWorker sends the password reset links requested with ForgotPassword until ctx is done.
+	StartPasswordResetWorker(ctx context.Context)
 
-	// Login authenticates a user with the provided username and password.
-	// Returns an access token, a refresh token, and an error if authentication fails.
-	Login(username, password string) (string, string, error)
+	// ResetPassword sets a new password using a token sent by ForgotPassword and revokes every session
+	// and personal access token of the user, see LogoutAll.
+	// Returns errorsUtils.ErrActionTokenInvalid if the token does not exist, has expired or has already been used,
+	// or a *passwords.Violation if the new password does not satisfy the policy, leaving the token usable.
+	ResetPassword(token string, newPassword string) error
 
-	// RefreshToken refreshes the access token using the provided refresh token.
-	// Returns a new access token and an error if the operation fails.
-	RefreshToken(refreshToken string) (string, error)
+	// ChangePassword replaces the password of a user who gives their current one, notifies them by email
+	// and revokes every session of the user but currentSessionID.
+	// Returns errorsUtils.ErrCurrentPasswordInvalid if the current password is not valid,
+	// or a *passwords.Violation if the new password does not satisfy the policy.
+	ChangePassword(userID uuid.UUID, currentSessionID uuid.UUID, currentPassword string, newPassword string) error
+
+	// ChangeEmail emails a link to confirm a new email address to a user who gives their current password,
+	// notifies the current address and revokes every session of the user but currentSessionID.
+	// The email address only changes once the link is used, see ConfirmEmailChange.
+	// Returns errorsUtils.ErrCurrentPasswordInvalid, errorsUtils.ErrEmailUnchanged or errorsUtils.ErrEmailInUse.
+	ChangeEmail(userID uuid.UUID, currentSessionID uuid.UUID, currentPassword string, newEmail string) error
+
+	// ConfirmEmailChange replaces the email address of a user with the one confirmed by a token sent by ChangeEmail.
+	// Returns errorsUtils.ErrActionTokenInvalid if the token does not exist, has expired or has already been used,
+	// or errorsUtils.ErrEmailInUse if another account took the address in the meantime.
+	ConfirmEmailChange(token string) error
+
+	// VerifyEmail marks the email address of a user as verified using a token sent on registration.
+	// Returns errorsUtils.ErrActionTokenInvalid if the token does not exist, has expired or has already been used.
+	VerifyEmail(token string) error
+
+	// ResendVerificationEmail sends a new verification email to a user, invalidating the previous ones.
+	// Returns errorsUtils.ErrEmailAlreadyVerified if the email address is already verified.
+	ResendVerificationEmail(userID uuid.UUID) error
+
+	// IsEmailVerified reports whether a user has confirmed their email address.
+	IsEmailVerified(userID uuid.UUID) (bool, error)
 
 	// GetRoleInformationByRoleID retrieves role information based on the provided role ID.
 	// Returns the role information as a string and an error if the retrieval fails.
 	GetRoleInformationByRoleID(roleID string) (string, error)
 }
 
+const (
+	// passwordResetTokenDuration is how long a password reset link stays valid.
+	passwordResetTokenDuration = time.Hour
+
+	// passwordChangeTokenDuration is how long a user who must change their password has to do it after logging in.
+	passwordChangeTokenDuration = time.Minute * 15
+
+	// verifyEmailTokenDuration is how long an email verification link stays valid.
+	verifyEmailTokenDuration = time.Hour * 48
+
+	// changeEmailTokenDuration is how long the link confirming a new email address stays valid.
+	changeEmailTokenDuration = time.Hour * 24
+
+	// passwordResetQueueSize is how many password resets may wait for the worker, more are dropped.
+	passwordResetQueueSize = 100
+)
+
 type authServiceImpl struct {
-	userRepository  repository.UserRepository
-	roleRepository  repository.RoleRepository
-	tokenRepository repository.TokenRepository
-	cacheService    CacheService
-	jwtKey          string
+	userRepository          repository.UserRepository
+	roleRepository          repository.RoleRepository
+	tokenRepository         repository.TokenRepository
+	actionTokenRepository   repository.ActionTokenRepository
+	personalTokenRepository repository.PersonalTokenRepository
+	cacheService            CacheService
+	emailService            EmailService
+	mfaService              MFAService
+	loginThrottleService    LoginThrottleService
+	banService              BanService
+	passwordPolicy          passwords.Policy
+	jwtKeys                 *jsonWebToken.KeySet
+	appURL                  string
+	passwordResets          chan string
 }
 
 // GetRoleInformationByRoleID implements AuthService.
@@ -48,7 +175,18 @@ func (service *authServiceImpl) GetRoleInformationByRoleID(roleID string) (strin
 }
 
 // Register implements AuthService.
-func (service *authServiceImpl) Register(user dto.UserDto) (uuid.UUID, string, string, error) {
+func (service *authServiceImpl) Register(user dto.UserDto, session dto.SessionDto) (uuid.UUID, string, string, error) {
+	if identity.IsEmail(user.Username) {
+		return uuid.UUID{}, "", "", errorsUtils.ErrUsernameInvalid
+	}
+	if identity.IsReservedUsername(user.Username) {
+		return uuid.UUID{}, "", "", errorsUtils.ErrUsernameReserved
+	}
+
+	if err := service.passwordPolicy.Check(user.Password); err != nil {
+		return uuid.UUID{}, "", "", err
+	}
+
 	roleEntity, err := service.roleRepository.FindByType("user")
 	if err != nil {
 		return uuid.UUID{}, "", "", err
@@ -64,18 +202,30 @@ func (service *authServiceImpl) Register(user dto.UserDto) (uuid.UUID, string, s
 
 	userEntity.RoleID = roleEntity.ID
 	userEntity.Role = *roleEntity
+	userEntity.Locale = mails.ParseLocale(user.Locale)
+	if userEntity.Locale == "" {
+		userEntity.Locale = mails.DefaultLocale
+	}
 
 	userID, err := service.userRepository.Create(*userEntity)
 	if err != nil {
 		return uuid.UUID{}, "", "", err
 	}
+	userEntity.ID = userID
 
-	token, err := jsonWebToken.GenerateAccessJWT(service.jwtKey, userID, userEntity.RoleID)
+	// the account is usable right away, a failed email can be sent again later
+	if err := service.sendVerificationEmail(userEntity); err != nil {
+		logger.CaptureError(err, "Error preparing verification email", map[string]interface{}{
+			"userID": userID,
+		})
+	}
+
+	refreshToken, sessionID, err := service.createSession(userID, session)
 	if err != nil {
 		return uuid.UUID{}, "", "", err
 	}
 
-	refreshToken, err := service.getOrSaveRefreshToken(userID)
+	token, err := jsonWebToken.GenerateAccessJWT(service.jwtKeys, userID, userEntity.RoleID, sessionID)
 	if err != nil {
 		return uuid.UUID{}, "", "", err
 	}
@@ -84,182 +234,881 @@ func (service *authServiceImpl) Register(user dto.UserDto) (uuid.UUID, string, s
 }
 
 // Login implements AuthService.
-func (service *authServiceImpl) Login(username string, password string) (string, string, error) {
+func (service *authServiceImpl) Login(identifier string, password string, session dto.SessionDto) (dto.LoginResultDto, error) {
+	userEntity, err := service.userRepository.FindByIdentifier(identifier)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return dto.LoginResultDto{}, err
+	}
 
-	userEntity, err := service.userRepository.FindByUsername(username)
-	if err != nil {
-		return "", "", err
+	// failed attempts are counted by account, whichever identifier is used
+	username := identifier
+	if userEntity != nil {
+		username = userEntity.Username
+	}
+
+	if err := service.loginThrottleService.Check(username, session.IPAddress); err != nil {
+		return dto.LoginResultDto{}, err
+	}
+
+	if userEntity == nil {
+		service.loginThrottleService.RegisterFailure(username, session.IPAddress)
+		return dto.LoginResultDto{}, gorm.ErrRecordNotFound
 	}
 
 	if !security.CheckPasswordHash(password, userEntity.Password) {
-		return "", "", errorsUtils.ErrUnauthorizedAcces
+		service.loginThrottleService.RegisterFailure(username, session.IPAddress)
+		return dto.LoginResultDto{}, errorsUtils.ErrUnauthorizedAcces
 	}
 
-	refreshToken, err := service.getOrSaveRefreshToken(userEntity.ID)
+	result, err := service.LoginUser(userEntity, session)
 	if err != nil {
-		return "", "", err
+		return dto.LoginResultDto{}, err
+	}
+
+	// with a second factor pending, the failures are only forgotten once it is given
+	if result.MFAToken == "" {
+		service.loginThrottleService.RegisterSuccess(username, session.IPAddress)
 	}
 
-	accesToken, err := jsonWebToken.GenerateAccessJWT(service.jwtKey, userEntity.ID, userEntity.RoleID)
+	return result, nil
+}
+
+// LoginUser implements AuthService.
+func (service *authServiceImpl) LoginUser(userEntity *models.UserEntity, session dto.SessionDto) (dto.LoginResultDto, error) {
+	if err := service.banService.CheckBan(userEntity.ID); err != nil {
+		return dto.LoginResultDto{}, err
+	}
+
+	if userEntity.TOTPEnabled || userEntity.Role.RequireMFA {
+		enroll := !userEntity.TOTPEnabled
+		mfaToken, err := jsonWebToken.GenerateMFAJWT(service.jwtKeys, userEntity.ID, enroll)
+		if err != nil {
+			return dto.LoginResultDto{}, err
+		}
+
+		return dto.LoginResultDto{MFAToken: mfaToken, MFAEnrollmentRequired: enroll}, nil
+	}
+
+	return service.openSession(userEntity, session)
+}
+
+// SetupMFA implements AuthService.
+func (service *authServiceImpl) SetupMFA(mfaToken string) (response.MFASetupResponse, error) {
+	userID, enroll, err := jsonWebToken.ValidateMFAJWT(mfaToken, service.jwtKeys)
 	if err != nil {
-		return "", "", nil
+		return response.MFASetupResponse{}, errorsUtils.ErrMFATokenInvalid
 	}
 
-	return accesToken, refreshToken, nil
+	if !enroll {
+		return response.MFASetupResponse{}, errorsUtils.ErrMFAAlreadyEnabled
+	}
+
+	return service.mfaService.Setup(userID)
 }
 
-// RefreshToken implements AuthService.
-func (service *authServiceImpl) RefreshToken(refreshToken string) (string, error) {
-	var userEntity *models.UserEntity
+// VerifyMFA implements AuthService.
+func (service *authServiceImpl) VerifyMFA(mfaToken string, code string, session dto.SessionDto) (dto.LoginResultDto, error) {
+	userID, enroll, err := jsonWebToken.ValidateMFAJWT(mfaToken, service.jwtKeys)
+	if err != nil {
+		return dto.LoginResultDto{}, errorsUtils.ErrMFATokenInvalid
+	}
+
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return dto.LoginResultDto{}, err
+	}
+
+	// guessing the code counts as guessing the password
+	if err := service.loginThrottleService.Check(userEntity.Username, session.IPAddress); err != nil {
+		return dto.LoginResultDto{}, err
+	}
+
+	if err := service.banService.CheckBan(userID); err != nil {
+		return dto.LoginResultDto{}, err
+	}
+
+	var recoveryCodes []string
+	if enroll && !userEntity.TOTPEnabled {
+		recoveryCodes, err = service.mfaService.Enable(userID, code)
+	} else {
+		err = service.mfaService.VerifyCode(userID, code)
+	}
+	if err != nil {
+		if err == errorsUtils.ErrMFACodeInvalid {
+			logger.Warn("Invalid two-factor authentication code", map[string]interface{}{
+				"userID": userID,
+			})
+			service.loginThrottleService.RegisterFailure(userEntity.Username, session.IPAddress)
+		}
+		return dto.LoginResultDto{}, err
+	}
+	service.loginThrottleService.RegisterSuccess(userEntity.Username, session.IPAddress)
 
-	claims, err := jsonWebToken.ValidateJWT(refreshToken, service.jwtKey)
+	result, err := service.openSession(userEntity, session)
 	if err != nil {
-		return "", errorsUtils.ErrRefreshTokenExpiredOrInvalid
+		return dto.LoginResultDto{}, err
 	}
+	result.RecoveryCodes = recoveryCodes
+
+	return result, nil
+}
+
+// RefreshToken implements AuthService.
+func (service *authServiceImpl) RefreshToken(refreshToken string) (string, string, error) {
+	var userEntity *models.UserEntity
 
-	if service.cacheService.IsTokenBlacklisted(refreshToken) {
-		return "", errorsUtils.ErrUnauthorizedAcces
+	claims, err := jsonWebToken.ValidateJWT(refreshToken, service.jwtKeys)
+	if err != nil {
+		return "", "", errorsUtils.ErrRefreshTokenExpiredOrInvalid
 	}
 
 	userID, err := claims.GetSubject()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return "", errorsUtils.ErrInvalidUUID
+		return "", "", errorsUtils.ErrInvalidUUID
+	}
+
+	tokenEntity, err := service.findRefreshTokenEntity(claims)
+	if err != nil {
+		return "", "", err
+	}
+	if tokenEntity.UserID != userUUID {
+		return "", "", errorsUtils.ErrUnauthorizedAcces
+	}
+
+	// A retired token must never come back, if it does it has probably been stolen
+	if tokenEntity.RotatedAt != nil {
+		return "", "", service.handleRefreshTokenReuse(tokenEntity)
+	}
+
+	if tokenEntity.Blocked || service.cacheService.IsTokenBlacklisted(refreshToken) {
+		return "", "", errorsUtils.ErrUnauthorizedAcces
 	}
 
 	userEntity, err = service.cacheService.GetUserInfoByID(userUUID)
 	if err != nil {
 		userEntity, err = service.userRepository.FindByID(userUUID)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 		err = service.cacheService.SetUserInfoByID(userUUID, userEntity)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 	}
 
-	if userEntity.Banned {
-		return "", errorsUtils.ErrUnauthorizedAcces
-	}
 	if userEntity.DeletedAt.Valid {
-		return "", gorm.ErrRecordNotFound
+		return "", "", gorm.ErrRecordNotFound
+	}
+	if err := service.banService.CheckBan(userUUID); err != nil {
+		return "", "", err
 	}
 
-	accessToken, err := jsonWebToken.GenerateAccessJWT(service.jwtKey, userUUID, userEntity.RoleID)
+	// the role is not taken from the cached user, so requiring 2FA applies to the sessions already open
+	roleEntity, err := service.roleRepository.FindByID(userEntity.RoleID)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	if roleEntity.RequireMFA && !userEntity.TOTPEnabled {
+		return "", "", errorsUtils.ErrMFAEnrollmentRequired
+	}
+
+	newRefreshToken, newTokenEntity, err := jsonWebToken.GenerateRefreshToken(service.jwtKeys, userUUID)
+	if err != nil {
+		return "", "", err
+	}
+
+	newTokenEntity.FamilyID = tokenEntity.FamilyID
+	newTokenEntity.DeviceName = tokenEntity.DeviceName
+	newTokenEntity.UserAgent = tokenEntity.UserAgent
+	newTokenEntity.IPAddress = tokenEntity.IPAddress
+	newTokenEntity.LastUsedAt = time.Now()
+
+	if err := service.tokenRepository.Rotate(tokenEntity.ID, newTokenEntity); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// the token was rotated by a concurrent request
+			return "", "", service.handleRefreshTokenReuse(tokenEntity)
+		}
+		return "", "", err
+	}
+
+	// the retired token is still a valid JWT, keep it away from VerifyRefreshToken
+	if err := service.cacheService.InvalidateRefreshToken(tokenEntity.Token); err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := jsonWebToken.GenerateAccessJWT(service.jwtKeys, userUUID, userEntity.RoleID, tokenEntity.FamilyID)
+	if err != nil {
+		return "", "", err
 	}
 
-	return accessToken, nil
+	return accessToken, newRefreshToken, nil
 }
 
-func (service *authServiceImpl) getOrSaveRefreshToken(userID uuid.UUID) (string, error) {
-	var refreshToken string
+// Logout implements AuthService.
+func (service *authServiceImpl) Logout(refreshToken string) error {
+	claims, err := jsonWebToken.ValidateJWT(refreshToken, service.jwtKeys)
+	if err != nil {
+		return errorsUtils.ErrRefreshTokenExpiredOrInvalid
+	}
 
-	refreshToken, err := service.cacheService.GetRefreshTokenByID(userID)
+	tokenEntity, err := service.findRefreshTokenEntity(claims)
 	if err != nil {
-		logger.CaptureError(err, "Error in cache: GetRefreshTokenByID", map[string]interface{}{
-			"userID": userID,
+		return err
+	}
+
+	if err := service.revokeSession(tokenEntity); err != nil {
+		return err
+	}
+
+	logger.Info("Session revoked", map[string]interface{}{
+		"userID":    tokenEntity.UserID,
+		"sessionID": tokenEntity.FamilyID,
+	})
+
+	return nil
+}
+
+// LogoutAll implements AuthService.
+func (service *authServiceImpl) LogoutAll(userID uuid.UUID) error {
+	tokens, err := service.tokenRepository.FindActiveTokensByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, tokenEntity := range tokens {
+		if err := service.revokeSession(tokenEntity); err != nil {
+			return err
+		}
+	}
+
+	personalTokens, err := service.personalTokenRepository.DeleteAllByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("All sessions revoked", map[string]interface{}{
+		"userID":         userID,
+		"sessions":       len(tokens),
+		"personalTokens": personalTokens,
+	})
+
+	return nil
+}
+
+// GetSessions implements AuthService.
+func (service *authServiceImpl) GetSessions(userID uuid.UUID, currentSessionID uuid.UUID) ([]response.SessionResponse, error) {
+	tokens, err := service.tokenRepository.FindActiveTokensByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]response.SessionResponse, 0, len(tokens))
+	for _, tokenEntity := range tokens {
+		session := mapper.TokenEntityToSessionResponse(tokenEntity)
+		session.Current = tokenEntity.FamilyID == currentSessionID
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession implements AuthService.
+func (service *authServiceImpl) RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error {
+	tokenEntity, err := service.tokenRepository.FindActiveTokenByFamilyID(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if tokenEntity.UserID != userID {
+		return gorm.ErrRecordNotFound
+	}
+
+	if err := service.revokeSession(tokenEntity); err != nil {
+		return err
+	}
+
+	logger.Info("Session revoked", map[string]interface{}{
+		"userID":    userID,
+		"sessionID": sessionID,
+	})
+
+	return nil
+}
+
+// ForgotPassword implements AuthService.
+func (service *authServiceImpl) ForgotPassword(email string) error {
+	select {
+	case service.passwordResets <- email:
+	default:
+		logger.Warn("Password reset dropped, too many are waiting", map[string]interface{}{
+			"email": email,
 		})
 	}
 
-	if refreshToken == "" {
-		tokenEntity, err := service.tokenRepository.FindTokenByUserID(userID)
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				_, newTokenEntity, err := jsonWebToken.GenerateRefreshToken(service.jwtKey, userID)
-				if err != nil {
-					return "", err
-				}
-
-				err = service.tokenRepository.Save(newTokenEntity)
-				if err != nil {
-					return "", err
-				}
-
-				err = service.cacheService.SetRefreshTokenByID(userID, newTokenEntity.Token)
-				if err != nil {
-					return "", err
-				}
-
-				refreshToken = newTokenEntity.Token
-
-			} else {
-				return "", err
-			}
-		} else {
-			err = service.cacheService.SetRefreshTokenByID(userID, tokenEntity.Token) // 5 days
-			if err != nil {
-				return "", err
+	return nil
+}
+
+// StartPasswordResetWorker implements AuthService.
+func (service *authServiceImpl) StartPasswordResetWorker(ctx context.Context) {
+	for {
+		select {
+		case email := <-service.passwordResets:
+			if err := service.sendPasswordReset(email); err != nil {
+				logger.CaptureError(err, "Error sending password reset link", nil)
 			}
-			refreshToken = tokenEntity.Token
+		case <-ctx.Done():
+			logger.Info("Stopping password reset worker", nil)
+			return
 		}
-	} else {
-		claims, validatErr := jsonWebToken.ValidateJWT(refreshToken, service.jwtKey)
+	}
+}
+
+// sendPasswordReset issues a password reset token to the user registered with email and queues the email
+// holding its link, nothing is sent if no user has that email.
+func (service *authServiceImpl) sendPasswordReset(email string) error {
+	userEntity, err := service.userRepository.FindByEmail(email)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logger.Info("Password reset requested for an unknown email", map[string]interface{}{
+				"email": email,
+			})
+			return nil
+		}
+		return err
+	}
+
+	token, err := service.issueActionToken(userEntity.ID, models.ActionPasswordReset, "", passwordResetTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", service.appURL, url.QueryEscape(token))
+	service.queueEmail(userEntity, mails.PasswordResetData{
+		Username:      userEntity.Username,
+		Link:          link,
+		ValidForHours: int(passwordResetTokenDuration.Hours()),
+	})
+
+	return nil
+}
+
+// ResetPassword implements AuthService.
+func (service *authServiceImpl) ResetPassword(token string, newPassword string) error {
+	if token == "" {
+		return errorsUtils.ErrActionTokenInvalid
+	}
+	if newPassword == "" {
+		return errorsUtils.ErrParameterCannotBeNull
+	}
+	// checked before consuming the token, which can be used again with a better password
+	if err := service.passwordPolicy.Check(newPassword); err != nil {
+		return err
+	}
+
+	actionToken, err := service.actionTokenRepository.FindValidByHash(models.ActionPasswordReset, security.HashToken(token))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrActionTokenInvalid
+		}
+		return err
+	}
+
+	if err := service.actionTokenRepository.Consume(actionToken.ID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrActionTokenInvalid
+		}
+		return err
+	}
+
+	passwordHashed, err := security.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := service.userRepository.SetPassword(actionToken.UserID, passwordHashed); err != nil {
+		return err
+	}
+
+	// the cached user would still require a password change
+	if err := service.cacheService.DeleteUserInfoByID(actionToken.UserID); err != nil {
+		return err
+	}
+
+	if err := service.LogoutAll(actionToken.UserID); err != nil {
+		return err
+	}
+
+	logger.Info("Password reset", map[string]interface{}{
+		"userID": actionToken.UserID,
+	})
+
+	return nil
+}
+
+// ChangePassword implements AuthService.
+func (service *authServiceImpl) ChangePassword(userID uuid.UUID, currentSessionID uuid.UUID, currentPassword string, newPassword string) error {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkCurrentPassword(userEntity, currentPassword); err != nil {
+		return err
+	}
+
+	if err := service.passwordPolicy.Check(newPassword); err != nil {
+		return err
+	}
+
+	passwordHashed, err := security.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := service.userRepository.SetPassword(userID, passwordHashed); err != nil {
+		return err
+	}
+
+	if err := service.cacheService.DeleteUserInfoByID(userID); err != nil {
+		return err
+	}
+
+	if err := service.revokeOtherSessions(userID, currentSessionID); err != nil {
+		return err
+	}
+
+	service.queueEmail(userEntity, mails.PasswordChangedData{
+		Username: userEntity.Username,
+		Link:     fmt.Sprintf("%s/forgot-password", service.appURL),
+	})
+
+	logger.Info("Password changed", map[string]interface{}{
+		"userID": userID,
+	})
+
+	return nil
+}
+
+// ChangeEmail implements AuthService.
+func (service *authServiceImpl) ChangeEmail(userID uuid.UUID, currentSessionID uuid.UUID, currentPassword string, newEmail string) error {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkCurrentPassword(userEntity, currentPassword); err != nil {
+		return err
+	}
+
+	if identity.NormalizeEmail(newEmail) == identity.NormalizeEmail(userEntity.Email) {
+		return errorsUtils.ErrEmailUnchanged
+	}
+
+	if _, err := service.userRepository.FindByEmail(newEmail); err == nil {
+		return errorsUtils.ErrEmailInUse
+	} else if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	token, err := service.issueActionToken(userID, models.ActionChangeEmail, newEmail, changeEmailTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	if err := service.revokeOtherSessions(userID, currentSessionID); err != nil {
+		return err
+	}
+
+	// the confirmation goes to the new address, the notice to the current one
+	link := fmt.Sprintf("%s/confirm-email-change?token=%s", service.appURL, url.QueryEscape(token))
+	err = service.emailService.Send(newEmail, userEntity.Locale, mails.EmailChangeData{
+		Username:      userEntity.Username,
+		NewEmail:      newEmail,
+		Link:          link,
+		ValidForHours: int(changeEmailTokenDuration.Hours()),
+	})
+	if err != nil {
+		logger.CaptureError(err, "Error queueing email", map[string]interface{}{
+			"userID":   userID,
+			"template": mails.TemplateEmailChange,
+		})
+	}
+
+	service.queueEmail(userEntity, mails.EmailChangeNoticeData{
+		Username: userEntity.Username,
+		NewEmail: newEmail,
+		Link:     fmt.Sprintf("%s/forgot-password", service.appURL),
+	})
+
+	logger.Info("Email change requested", map[string]interface{}{
+		"userID": userID,
+	})
+
+	return nil
+}
+
+// ConfirmEmailChange implements AuthService.
+func (service *authServiceImpl) ConfirmEmailChange(token string) error {
+	if token == "" {
+		return errorsUtils.ErrActionTokenInvalid
+	}
+
+	actionToken, err := service.actionTokenRepository.FindValidByHash(models.ActionChangeEmail, security.HashToken(token))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrActionTokenInvalid
+		}
+		return err
+	}
+
+	if err := service.actionTokenRepository.Consume(actionToken.ID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrActionTokenInvalid
+		}
+		return err
+	}
+
+	// the new address is verified by the link itself
+	err = service.userRepository.Update(actionToken.UserID, models.UserEntity{Email: actionToken.Payload, EmailVerified: true})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return errorsUtils.ErrEmailInUse
+		}
+		return err
+	}
+
+	// the links sent to the previous address must not verify the new one
+	if err := service.actionTokenRepository.InvalidateByUserID(actionToken.UserID, models.ActionVerifyEmail); err != nil {
+		return err
+	}
+
+	if err := service.cacheService.DeleteUserInfoByID(actionToken.UserID); err != nil {
+		return err
+	}
+
+	logger.Info("Email changed", map[string]interface{}{
+		"userID": actionToken.UserID,
+	})
+
+	return nil
+}
+
+// VerifyEmail implements AuthService.
+func (service *authServiceImpl) VerifyEmail(token string) error {
+	if token == "" {
+		return errorsUtils.ErrActionTokenInvalid
+	}
+
+	actionToken, err := service.actionTokenRepository.FindValidByHash(models.ActionVerifyEmail, security.HashToken(token))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrActionTokenInvalid
+		}
+		return err
+	}
+
+	if err := service.actionTokenRepository.Consume(actionToken.ID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrActionTokenInvalid
+		}
+		return err
+	}
+
+	if err := service.userRepository.Update(actionToken.UserID, models.UserEntity{EmailVerified: true}); err != nil {
+		return err
+	}
+
+	if err := service.cacheService.DeleteUserInfoByID(actionToken.UserID); err != nil {
+		return err
+	}
+
+	userEntity, err := service.userRepository.FindByID(actionToken.UserID)
+	if err != nil {
+		return err
+	}
+	service.queueEmail(userEntity, mails.WelcomeData{
+		Username: userEntity.Username,
+		Link:     service.appURL,
+	})
+
+	logger.Info("Email verified", map[string]interface{}{
+		"userID": actionToken.UserID,
+	})
+
+	return nil
+}
+
+// ResendVerificationEmail implements AuthService.
+func (service *authServiceImpl) ResendVerificationEmail(userID uuid.UUID) error {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if userEntity.EmailVerified {
+		return errorsUtils.ErrEmailAlreadyVerified
+	}
+
+	return service.sendVerificationEmail(userEntity)
+}
+
+// IsEmailVerified implements AuthService.
+func (service *authServiceImpl) IsEmailVerified(userID uuid.UUID) (bool, error) {
+	userEntity, err := service.cacheService.GetUserInfoByID(userID)
+	if err != nil {
+		userEntity, err = service.userRepository.FindByID(userID)
 		if err != nil {
+			return false, err
 		}
+		if err := service.cacheService.SetUserInfoByID(userID, userEntity); err != nil {
+			return false, err
+		}
+	}
 
-		if service.cacheService.IsTokenBlacklisted(refreshToken) || validatErr != nil {
-			_, newTokenEntity, err := jsonWebToken.GenerateRefreshToken(service.jwtKey, userID)
-			if err != nil {
-				return "", err
-			}
+	return userEntity.EmailVerified, nil
+}
 
-			jti, ok := claims["jti"].(string)
-			if !ok {
-				return "", errorsUtils.ErrInternalServer
-			}
+// sendVerificationEmail issues a new email verification token for a user and emails them the link to use it.
+func (service *authServiceImpl) sendVerificationEmail(userEntity *models.UserEntity) error {
+	token, err := service.issueActionToken(userEntity.ID, models.ActionVerifyEmail, "", verifyEmailTokenDuration)
+	if err != nil {
+		return err
+	}
 
-			jtiUUID, err := uuid.Parse(jti)
-			if err != nil {
-				return "", errorsUtils.ErrInvalidUUID
-			}
+	link := fmt.Sprintf("%s/verify-email?token=%s", service.appURL, url.QueryEscape(token))
+	service.queueEmail(userEntity, mails.VerifyEmailData{
+		Username:      userEntity.Username,
+		Link:          link,
+		ValidForHours: int(verifyEmailTokenDuration.Hours()),
+	})
 
-			{
-				//delete
+	return nil
+}
 
-				err = service.tokenRepository.Delete(jtiUUID)
-				if err != nil {
-					return "", err
-				}
+// checkCurrentPassword verifies the password a user gives to confirm a sensitive change.
+// Wrong passwords count as failed logins, so the check cannot be used to guess the password.
+// Returns errorsUtils.ErrCurrentPasswordInvalid, or a errorsUtils.LoginBlockedError if too many attempts failed.
+func (service *authServiceImpl) checkCurrentPassword(userEntity *models.UserEntity, password string) error {
+	if err := service.loginThrottleService.Check(userEntity.Username, ""); err != nil {
+		return err
+	}
 
-				err = service.cacheService.DeleteRefreshTokenByID(userID)
-				if err != nil {
-					return "", err
-				}
-			}
+	if !security.CheckPasswordHash(password, userEntity.Password) {
+		service.loginThrottleService.RegisterFailure(userEntity.Username, "")
+		return errorsUtils.ErrCurrentPasswordInvalid
+	}
 
-			err = service.tokenRepository.Save(newTokenEntity)
-			if err != nil {
-				return "", err
-			}
+	return nil
+}
 
-			err = service.cacheService.SetRefreshTokenByID(userID, newTokenEntity.Token) // 5 days
-			if err != nil {
-				return "", err
-			}
+// revokeOtherSessions revokes every active session of a user but the one identified by keepSessionID.
+func (service *authServiceImpl) revokeOtherSessions(userID uuid.UUID, keepSessionID uuid.UUID) error {
+	tokens, err := service.tokenRepository.FindActiveTokensByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	revoked := 0
+	for _, tokenEntity := range tokens {
+		if tokenEntity.FamilyID == keepSessionID {
+			continue
+		}
+		if err := service.revokeSession(tokenEntity); err != nil {
+			return err
+		}
+		revoked++
+	}
+
+	logger.Info("Other sessions revoked", map[string]interface{}{
+		"userID":   userID,
+		"sessions": revoked,
+	})
+
+	return nil
+}
+
+// issueActionToken generates a single-use token for a user and stores its hash, along with the payload of the action.
+// Previous unused tokens of the user for the same purpose are invalidated, so only the latest one works.
+// Returns the token in clear, to be sent to the user.
+func (service *authServiceImpl) issueActionToken(userID uuid.UUID, purpose string, payload string, validFor time.Duration) (string, error) {
+	if err := service.actionTokenRepository.InvalidateByUserID(userID, purpose); err != nil {
+		return "", err
+	}
+
+	token, err := security.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = service.actionTokenRepository.Save(models.ActionTokenEntity{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: security.HashToken(token),
+		Payload:   payload,
+		ExpiresAt: time.Now().Add(validFor),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// queueEmail queues an email to a user in their locale, errors are logged.
+func (service *authServiceImpl) queueEmail(userEntity *models.UserEntity, data mails.Data) {
+	if err := service.emailService.Send(userEntity.Email, userEntity.Locale, data); err != nil {
+		logger.CaptureError(err, "Error queueing email", map[string]interface{}{
+			"userID":   userEntity.ID,
+			"template": data.TemplateName(),
+		})
+	}
+}
+
+// findRefreshTokenEntity returns the TokenEntity referenced by the jti claim of a refresh token.
+func (service *authServiceImpl) findRefreshTokenEntity(claims jwt.MapClaims) (*models.TokenEntity, error) {
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return nil, errorsUtils.ErrRefreshTokenExpiredOrInvalid
+	}
+
+	jtiUUID, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, errorsUtils.ErrRefreshTokenExpiredOrInvalid
+	}
+
+	tokenEntity, err := service.tokenRepository.FindTokenByID(jtiUUID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errorsUtils.ErrRefreshTokenExpiredOrInvalid
+		}
+		return nil, err
+	}
+
+	return tokenEntity, nil
+}
+
+// handleRefreshTokenReuse revokes the whole session of a refresh token that was presented
+// after being rotated, forcing the user to log in again.
+// Returns errorsUtils.ErrRefreshTokenReused, or the error of the revocation if it fails.
+func (service *authServiceImpl) handleRefreshTokenReuse(tokenEntity *models.TokenEntity) error {
+	logger.Warn("Rotated refresh token reused, revoking its session", map[string]interface{}{
+		"userID":    tokenEntity.UserID,
+		"sessionID": tokenEntity.FamilyID,
+		"tokenID":   tokenEntity.ID,
+	})
+
+	if err := service.revokeSession(tokenEntity); err != nil {
+		return err
+	}
+
+	return errorsUtils.ErrRefreshTokenReused
+}
+
+// revokeSession blocks every refresh token of the session a token belongs to, blacklists
+// its current token in the cache and revokes the session so the access tokens issued for it stop working.
+func (service *authServiceImpl) revokeSession(tokenEntity *models.TokenEntity) error {
+	currentToken, err := service.tokenRepository.FindActiveTokenByFamilyID(tokenEntity.FamilyID)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		currentToken = tokenEntity
+	}
+
+	if err := service.tokenRepository.BlockFamily(tokenEntity.FamilyID); err != nil {
+		return err
+	}
+
+	if err := service.cacheService.InvalidateRefreshToken(currentToken.Token); err != nil {
+		return err
+	}
 
-			refreshToken = newTokenEntity.Token
+	return service.cacheService.RevokeSession(tokenEntity.FamilyID)
+}
+
+// openSession opens a new session for a user that has been fully authenticated.
+// Returns the access token and refresh token of the session, or only a password reset token
+// if the user must change their password first.
+func (service *authServiceImpl) openSession(userEntity *models.UserEntity, session dto.SessionDto) (dto.LoginResultDto, error) {
+	if userEntity.MustChangePassword {
+		token, err := service.issueActionToken(userEntity.ID, models.ActionPasswordReset, "", passwordChangeTokenDuration)
+		if err != nil {
+			return dto.LoginResultDto{}, err
 		}
+
+		logger.Info("Login pending a password change", map[string]interface{}{
+			"userID": userEntity.ID,
+		})
+
+		return dto.LoginResultDto{PasswordResetToken: token}, nil
+	}
+
+	refreshToken, sessionID, err := service.createSession(userEntity.ID, session)
+	if err != nil {
+		return dto.LoginResultDto{}, err
+	}
+
+	accessToken, err := jsonWebToken.GenerateAccessJWT(service.jwtKeys, userEntity.ID, userEntity.RoleID, sessionID)
+	if err != nil {
+		return dto.LoginResultDto{}, err
+	}
+
+	return dto.LoginResultDto{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// createSession generates a new refresh token for a user and stores it as the first token of a new session
+// for the device described by the SessionDto.
+// Returns the refresh token and the ID of the session.
+func (service *authServiceImpl) createSession(userID uuid.UUID, session dto.SessionDto) (string, uuid.UUID, error) {
+	refreshToken, tokenEntity, err := jsonWebToken.GenerateRefreshToken(service.jwtKeys, userID)
+	if err != nil {
+		return "", uuid.UUID{}, err
+	}
+
+	tokenEntity.FamilyID = tokenEntity.ID
+	tokenEntity.DeviceName = session.DeviceName
+	tokenEntity.UserAgent = session.UserAgent
+	tokenEntity.IPAddress = session.IPAddress
+	tokenEntity.LastUsedAt = time.Now()
+
+	if err := service.tokenRepository.Save(tokenEntity); err != nil {
+		return "", uuid.UUID{}, err
 	}
 
-	return refreshToken, nil
+	return refreshToken, tokenEntity.FamilyID, nil
 }
 
 func NewAuthService(userRepository repository.UserRepository,
 	roleRepository repository.RoleRepository,
 	tokenRepository repository.TokenRepository,
+	actionTokenRepository repository.ActionTokenRepository,
+	personalTokenRepository repository.PersonalTokenRepository,
 	cacheService CacheService,
-	jwtKey string) AuthService {
+	emailService EmailService,
+	mfaService MFAService,
+	loginThrottleService LoginThrottleService,
+	banService BanService,
+	passwordPolicy passwords.Policy,
+	jwtKeys *jsonWebToken.KeySet,
+	appURL string) AuthService {
 	return &authServiceImpl{
-		userRepository:  userRepository,
-		roleRepository:  roleRepository,
-		tokenRepository: tokenRepository,
-		cacheService:    cacheService,
-		jwtKey:          jwtKey}
+		userRepository:          userRepository,
+		roleRepository:          roleRepository,
+		tokenRepository:         tokenRepository,
+		actionTokenRepository:   actionTokenRepository,
+		personalTokenRepository: personalTokenRepository,
+		cacheService:            cacheService,
+		emailService:            emailService,
+		mfaService:              mfaService,
+		loginThrottleService:    loginThrottleService,
+		banService:              banService,
+		passwordPolicy:          passwordPolicy,
+		jwtKeys:                 jwtKeys,
+		appURL:                  appURL,
+		passwordResets:          make(chan string, passwordResetQueueSize)}
 }
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/passwords"
+	"github.com/Dialosoft/src/pkg/utils/jsonWebToken"
+	"github.com/Dialosoft/src/pkg/utils/security"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// newRefreshTestService returns an AuthService able to refresh the sessions of user,
+// and the refresh token of a new session of the user.
+func newRefreshTestService(t *testing.T, user models.UserEntity) (AuthService, *fakeTokenRepository, *fakeCacheService, string) {
+	t.Helper()
+	keys, err := jsonWebToken.NewKeySet(jsonWebToken.KeySetConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewKeySet() = %v", err)
+	}
+
+	refreshToken, tokenEntity, err := jsonWebToken.GenerateRefreshToken(keys, user.ID)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() = %v", err)
+	}
+	tokenEntity.FamilyID = tokenEntity.ID
+
+	tokenRepository := newFakeTokenRepository(tokenEntity)
+	cacheService := &fakeCacheService{}
+	service := NewAuthService(newFakeUserRepository(user), &fakeRoleRepository{}, tokenRepository, nil, nil,
+		cacheService, nil, nil, nil, &fakeBanService{}, passwords.Policy{}, keys, "")
+
+	return service, tokenRepository, cacheService, refreshToken
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	user := models.UserEntity{ID: uuid.New(), Username: "alice", Email: "alice@example.com", RoleID: uuid.New()}
+	service, tokenRepository, cacheService, retired := newRefreshTestService(t, user)
+
+	_, current, err := service.RefreshToken(retired)
+	if err != nil {
+		t.Fatalf("RefreshToken() = %v", err)
+	}
+	if current == retired {
+		t.Fatal("the refresh token was not rotated")
+	}
+
+	if _, _, err := service.RefreshToken(retired); err != errorsUtils.ErrRefreshTokenReused {
+		t.Fatalf("RefreshToken() with the retired token = %v, want ErrRefreshTokenReused", err)
+	}
+
+	for _, token := range tokenRepository.tokens {
+		if !token.Blocked {
+			t.Errorf("token %v of the session is not blocked", token.ID)
+		}
+	}
+	if len(cacheService.revokedSessions) != 1 {
+		t.Errorf("%d sessions revoked, want 1", len(cacheService.revokedSessions))
+	}
+
+	if _, _, err := service.RefreshToken(current); err == nil {
+		t.Error("the current token of the revoked session still refreshes")
+	}
+}
+
+// personalTokensOf keeps the names of the personal access tokens of the users, by user.
+type personalTokensOf struct {
+	repository.PersonalTokenRepository
+	names map[uuid.UUID][]string
+}
+
+func (tokens personalTokensOf) DeleteAllByUserID(userID uuid.UUID) (int64, error) {
+	deleted := len(tokens.names[userID])
+	delete(tokens.names, userID)
+	return int64(deleted), nil
+}
+
+func TestLogoutAllDeletesPersonalTokens(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+	session := models.TokenEntity{ID: uuid.New(), UserID: userID, Token: "refresh"}
+	session.FamilyID = session.ID
+	tokenRepository := newFakeTokenRepository(session)
+	personalTokens := personalTokensOf{names: map[uuid.UUID][]string{
+		userID:  {"deploy bot", "backup script"},
+		otherID: {"ci"},
+	}}
+	service := NewAuthService(nil, nil, tokenRepository, nil, personalTokens,
+		&fakeCacheService{}, nil, nil, nil, nil, passwords.Policy{}, nil, "")
+
+	if err := service.LogoutAll(userID); err != nil {
+		t.Fatalf("LogoutAll() = %v", err)
+	}
+
+	if !tokenRepository.tokens[session.ID].Blocked {
+		t.Error("the session was not revoked")
+	}
+	if _, ok := personalTokens.names[userID]; ok {
+		t.Error("the personal access tokens of the user were kept")
+	}
+	if len(personalTokens.names[otherID]) != 1 {
+		t.Error("the personal access tokens of another user were deleted")
+	}
+}
+
+// resetTokens holds the password reset tokens of TestResetPasswordRefusedByPolicy and the ones consumed.
+type resetTokens struct {
+	repository.ActionTokenRepository
+	byHash   map[string]models.ActionTokenEntity
+	consumed []uuid.UUID
+}
+
+func (tokens *resetTokens) FindValidByHash(purpose string, tokenHash string) (*models.ActionTokenEntity, error) {
+	token, ok := tokens.byHash[tokenHash]
+	if !ok || token.Purpose != purpose || slices.Contains(tokens.consumed, token.ID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+func (tokens *resetTokens) InvalidateByUserID(userID uuid.UUID, purpose string) error {
+	return nil
+}
+
+func (tokens *resetTokens) Save(actionToken models.ActionTokenEntity) error {
+	actionToken.ID = uuid.New()
+	tokens.byHash[actionToken.TokenHash] = actionToken
+	return nil
+}
+
+func (tokens *resetTokens) Consume(actionTokenID uuid.UUID) error {
+	tokens.consumed = append(tokens.consumed, actionTokenID)
+	return nil
+}
+
+func TestResetPasswordRefusedByPolicy(t *testing.T) {
+	tokens := &resetTokens{byHash: map[string]models.ActionTokenEntity{
+		security.HashToken("reset-me"): {ID: uuid.New(), UserID: uuid.New(), Purpose: models.ActionPasswordReset},
+	}}
+	policy := passwords.Policy{MinLength: 10, RequireDigit: true}
+	service := NewAuthService(nil, nil, nil, tokens, nil, nil, nil, nil, nil, nil, policy, nil, "")
+
+	for _, password := range []string{"short1", "no digits at all"} {
+		err := service.ResetPassword("reset-me", password)
+		var violation *passwords.Violation
+		if !errors.As(err, &violation) {
+			t.Errorf("ResetPassword(%q) = %v, want a policy violation", password, err)
+		}
+	}
+
+	if len(tokens.consumed) != 0 {
+		t.Errorf("the reset token was consumed by a refused password")
+	}
+}
+
+func TestForgotPasswordHandsOffToTheWorker(t *testing.T) {
+	user := models.UserEntity{ID: uuid.New(), Username: "carol", Email: "carol@dialosoft.test", Locale: "en"}
+	users := newFakeUserRepository(user)
+	tokens := &resetTokens{byHash: map[string]models.ActionTokenEntity{}}
+	emails := &fakeEmailService{}
+	service := NewAuthService(users, nil, nil, tokens, nil, nil, emails, nil, nil, nil, passwords.Policy{}, nil, "")
+
+	// nothing is looked up until the worker runs, for the known and the unknown emails alike
+	for _, email := range []string{"Carol@Dialosoft.test", "nobody@dialosoft.test"} {
+		if err := service.ForgotPassword(email); err != nil {
+			t.Fatalf("ForgotPassword(%q) = %v", email, err)
+		}
+	}
+	if len(tokens.byHash) != 0 || len(emails.sent) != 0 {
+		t.Fatal("ForgotPassword did the work of the worker")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		service.StartPasswordResetWorker(ctx)
+		close(stopped)
+	}()
+	// once the queue is empty, the worker finishes the request it holds before it stops
+	deadline := time.Now().Add(5 * time.Second)
+	for len(service.(*authServiceImpl).passwordResets) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-stopped
+
+	emails.mu.Lock()
+	defer emails.mu.Unlock()
+	if !slices.Equal(emails.sent, []string{user.Email}) {
+		t.Errorf("reset links sent to %v, want only %s", emails.sent, user.Email)
+	}
+	if len(tokens.byHash) != 1 {
+		t.Errorf("%d reset tokens issued, want 1", len(tokens.byHash))
+	}
+}
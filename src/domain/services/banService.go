@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BanService defines the methods to ban users, temporarily or until lifted, and to enforce the bans.
+type BanService interface {
+
+	// Ban bans a user for the given duration, or until the ban is lifted if it is zero,
+	// replacing the active ban of the user if any. The user is told the reason by email.
+	// Returns gorm.ErrRecordNotFound if the user does not exist, errorsUtils.ErrBanSelf,
+	// or errorsUtils.ErrBanNotAllowed if the role of the user can ban users.
+	Ban(userID uuid.UUID, issuedBy uuid.UUID, reason string, duration time.Duration) (response.BanResponse, error)
+
+	// Unban lifts the active ban of a user.
+	// Returns gorm.ErrRecordNotFound if the user is not banned.
+	Unban(userID uuid.UUID, liftedBy uuid.UUID) error
+
+	// GetBans retrieves every ban of a user, the most recent first.
+	GetBans(userID uuid.UUID) ([]response.BanResponse, error)
+
+	// CheckBan returns an errorsUtils.BannedError holding the reason and the end of the ban if the user is banned.
+	// The bans are cached, so it can be called on every request.
+	CheckBan(userID uuid.UUID) error
+
+	// StartExpiryWorker clears the Banned flag of the users whose bans have expired every interval until ctx is done.
+	StartExpiryWorker(ctx context.Context, interval time.Duration)
+}
+
+const (
+	// banCacheDuration is how long a user without any ban is remembered, bans are cached until they expire.
+	// Banning and unbanning clear the cache, so this only bounds its size.
+	banCacheDuration = time.Hour
+
+	banCacheNone = "none"
+)
+
+// cachedBan is what is cached of an active ban.
+type cachedBan struct {
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type banServiceImpl struct {
+	banRepository   repository.BanRepository
+	userRepository  repository.UserRepository
+	cacheRepository repository.RedisRepository
+	cacheService    CacheService
+	emailService    EmailService
+	roleService     RoleService
+}
+
+// Ban implements BanService.
+func (service *banServiceImpl) Ban(userID uuid.UUID, issuedBy uuid.UUID, reason string, duration time.Duration) (response.BanResponse, error) {
+	if userID == issuedBy {
+		return response.BanResponse{}, errorsUtils.ErrBanSelf
+	}
+
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return response.BanResponse{}, err
+	}
+
+	canBan, err := service.roleService.HasPermission(userEntity.RoleID, permissions.UserBan)
+	if err != nil {
+		return response.BanResponse{}, err
+	}
+	if canBan {
+		return response.BanResponse{}, errorsUtils.ErrBanNotAllowed
+	}
+
+	ban := models.BanEntity{
+		UserID:   userID,
+		Reason:   reason,
+		IssuedBy: issuedBy,
+		StartsAt: time.Now(),
+	}
+	if duration > 0 {
+		expiresAt := ban.StartsAt.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	ban.ID, err = service.banRepository.Create(ban)
+	if err != nil {
+		return response.BanResponse{}, err
+	}
+	service.forget(userID)
+
+	err = service.emailService.Send(userEntity.Email, userEntity.Locale, mails.BanNoticeData{
+		Username:  userEntity.Username,
+		Reason:    reason,
+		ExpiresAt: ban.ExpiresAt,
+	})
+	if err != nil {
+		logger.CaptureError(err, "Error queueing email", map[string]interface{}{
+			"userID":   userID,
+			"template": mails.TemplateBanNotice,
+		})
+	}
+
+	logger.Info("User banned", map[string]interface{}{
+		"userID":    userID,
+		"issuedBy":  issuedBy,
+		"banID":     ban.ID,
+		"expiresAt": ban.ExpiresAt,
+	})
+
+	return mapper.BanEntityToBanResponse(&ban), nil
+}
+
+// Unban implements BanService.
+func (service *banServiceImpl) Unban(userID uuid.UUID, liftedBy uuid.UUID) error {
+	if err := service.banRepository.Lift(userID, liftedBy, time.Now()); err != nil {
+		return err
+	}
+	service.forget(userID)
+
+	logger.Info("User ban lifted", map[string]interface{}{
+		"userID":   userID,
+		"liftedBy": liftedBy,
+	})
+
+	return nil
+}
+
+// GetBans implements BanService.
+func (service *banServiceImpl) GetBans(userID uuid.UUID) ([]response.BanResponse, error) {
+	bans, err := service.banRepository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bansResponse := make([]response.BanResponse, 0, len(bans))
+	for i := range bans {
+		bansResponse = append(bansResponse, mapper.BanEntityToBanResponse(&bans[i]))
+	}
+
+	return bansResponse, nil
+}
+
+// CheckBan implements BanService.
+func (service *banServiceImpl) CheckBan(userID uuid.UUID) error {
+	ctx := context.Background()
+	cacheKey := banCacheKey(userID)
+	now := time.Now()
+
+	if cached, err := service.cacheRepository.Get(ctx, cacheKey); err == nil {
+		if cached == banCacheNone {
+			return nil
+		}
+		var ban cachedBan
+		if err := json.Unmarshal([]byte(cached), &ban); err == nil {
+			if ban.ExpiresAt != nil && !ban.ExpiresAt.After(now) {
+				return nil
+			}
+			return &errorsUtils.BannedError{Reason: ban.Reason, ExpiresAt: ban.ExpiresAt}
+		}
+	}
+
+	banEntity, err := service.banRepository.FindActive(userID, now)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if err := service.cacheRepository.Set(ctx, cacheKey, banCacheNone, banCacheDuration); err != nil {
+			logger.CaptureError(err, "Error caching the bans of a user", map[string]interface{}{"userID": userID})
+		}
+		return nil
+	}
+
+	ban := cachedBan{Reason: banEntity.Reason, ExpiresAt: banEntity.ExpiresAt}
+	expiration := banCacheDuration
+	if ban.ExpiresAt != nil && ban.ExpiresAt.Sub(now) < expiration {
+		expiration = ban.ExpiresAt.Sub(now)
+	}
+	if cached, err := json.Marshal(ban); err == nil {
+		if err := service.cacheRepository.Set(ctx, cacheKey, string(cached), expiration); err != nil {
+			logger.CaptureError(err, "Error caching the bans of a user", map[string]interface{}{"userID": userID})
+		}
+	}
+
+	return &errorsUtils.BannedError{Reason: ban.Reason, ExpiresAt: ban.ExpiresAt}
+}
+
+// StartExpiryWorker implements BanService.
+func (service *banServiceImpl) StartExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			service.clearExpiredBans()
+		case <-ctx.Done():
+			logger.Info("Stopping ban expiry worker", nil)
+			return
+		}
+	}
+}
+
+// clearExpiredBans clears the Banned flag of the users whose bans have expired.
+func (service *banServiceImpl) clearExpiredBans() {
+	userIDs, err := service.banRepository.ClearExpired(time.Now())
+	if err != nil {
+		logger.CaptureError(err, "Error clearing expired bans", nil)
+		return
+	}
+
+	for _, userID := range userIDs {
+		service.forget(userID)
+		logger.Info("User ban expired", map[string]interface{}{
+			"userID": userID,
+		})
+	}
+}
+
+// forget removes the cached bans and information of a user after they changed.
+func (service *banServiceImpl) forget(userID uuid.UUID) {
+	if err := service.cacheRepository.Delete(context.Background(), banCacheKey(userID)); err != nil {
+		logger.CaptureError(err, "Error removing the cached bans of a user", map[string]interface{}{"userID": userID})
+	}
+	if err := service.cacheService.DeleteUserInfoByID(userID); err != nil {
+		logger.CaptureError(err, "Error removing the cached user", map[string]interface{}{"userID": userID})
+	}
+}
+
+func banCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("ban:%s", userID.String())
+}
+
+func NewBanService(banRepository repository.BanRepository,
+	userRepository repository.UserRepository,
+	cacheRepository repository.RedisRepository,
+	cacheService CacheService,
+	emailService EmailService,
+	roleService RoleService) BanService {
+	return &banServiceImpl{
+		banRepository:   banRepository,
+		userRepository:  userRepository,
+		cacheRepository: cacheRepository,
+		cacheService:    cacheService,
+		emailService:    emailService,
+		roleService:     roleService}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/google/uuid"
+)
+
+func TestCheckBan(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	expiredAt := now.Add(-time.Minute)
+
+	banned := uuid.New()
+	expired := uuid.New()
+	lifted := uuid.New()
+	legacy := uuid.New()
+	notBanned := uuid.New()
+
+	banRepository := &fakeBanRepository{bans: []models.BanEntity{
+		{UserID: banned, Reason: "spam", StartsAt: now.Add(-time.Hour), ExpiresAt: &expiresAt},
+		{UserID: expired, Reason: "spam", StartsAt: now.Add(-time.Hour), ExpiresAt: &expiredAt},
+		{UserID: lifted, Reason: "spam", StartsAt: now.Add(-time.Hour), LiftedAt: &expiredAt},
+		{UserID: legacy, Reason: models.LegacyBanReason, StartsAt: now.Add(-time.Hour)},
+	}}
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		banned    bool
+		reason    string
+		expiresAt *time.Time
+	}{
+		{"temporary ban", banned, true, "spam", &expiresAt},
+		{"expired ban", expired, false, "", nil},
+		{"lifted ban", lifted, false, "", nil},
+		{"ban until lifted", legacy, true, models.LegacyBanReason, nil},
+		{"never banned", notBanned, false, "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := NewBanService(banRepository, nil, newFakeCacheRepository(), &fakeCacheService{}, nil, nil)
+
+			// the second call is answered by the cache
+			for i := 0; i < 2; i++ {
+				err := service.CheckBan(test.userID)
+				if !test.banned {
+					if err != nil {
+						t.Fatalf("CheckBan() = %v, want nil", err)
+					}
+					continue
+				}
+
+				var bannedErr *errorsUtils.BannedError
+				if !errors.As(err, &bannedErr) {
+					t.Fatalf("CheckBan() = %v, want a BannedError", err)
+				}
+				if bannedErr.Reason != test.reason {
+					t.Errorf("reason = %q, want %q", bannedErr.Reason, test.reason)
+				}
+				if (bannedErr.ExpiresAt == nil) != (test.expiresAt == nil) ||
+					(test.expiresAt != nil && !bannedErr.ExpiresAt.Equal(*test.expiresAt)) {
+					t.Errorf("expiresAt = %v, want %v", bannedErr.ExpiresAt, test.expiresAt)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckBanCachedBanExpires(t *testing.T) {
+	userID := uuid.New()
+	expiresAt := time.Now().Add(50 * time.Millisecond)
+
+	banRepository := &fakeBanRepository{bans: []models.BanEntity{
+		{UserID: userID, Reason: "spam", StartsAt: time.Now().Add(-time.Hour), ExpiresAt: &expiresAt},
+	}}
+	cacheRepository := newFakeCacheRepository()
+	service := NewBanService(banRepository, nil, cacheRepository, &fakeCacheService{}, nil, nil)
+
+	if err := service.CheckBan(userID); !errors.Is(err, errorsUtils.ErrUserBanned) {
+		t.Fatalf("CheckBan() = %v, want ErrUserBanned", err)
+	}
+	if _, ok := cacheRepository.values[banCacheKey(userID)]; !ok {
+		t.Fatal("the ban was not cached")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := service.CheckBan(userID); err != nil {
+		t.Fatalf("CheckBan() after the ban expired = %v, want nil", err)
+	}
+}
+
+func TestClearExpiredBans(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	expiredAt := now.Add(-time.Minute)
+
+	expired := models.UserEntity{ID: uuid.New(), Username: "expired", Email: "expired@example.com", Banned: true}
+	active := models.UserEntity{ID: uuid.New(), Username: "active", Email: "active@example.com", Banned: true}
+	legacy := models.UserEntity{ID: uuid.New(), Username: "legacy", Email: "legacy@example.com", Banned: true}
+
+	userRepository := newFakeUserRepository(expired, active, legacy)
+	banRepository := &fakeBanRepository{users: userRepository, bans: []models.BanEntity{
+		{UserID: expired.ID, Reason: "spam", StartsAt: now.Add(-time.Hour), ExpiresAt: &expiredAt},
+		{UserID: active.ID, Reason: "spam", StartsAt: now.Add(-time.Hour), ExpiresAt: &expiresAt},
+		{UserID: legacy.ID, Reason: models.LegacyBanReason, StartsAt: now.Add(-time.Hour)},
+	}}
+	cacheRepository := newFakeCacheRepository()
+	cacheRepository.values[banCacheKey(expired.ID)] = `{"reason":"spam"}`
+	cacheService := &fakeCacheService{}
+
+	service := NewBanService(banRepository, userRepository, cacheRepository, cacheService, nil, nil).(*banServiceImpl)
+	service.clearExpiredBans()
+
+	if userRepository.users[expired.ID].Banned {
+		t.Error("the user whose ban expired is still banned")
+	}
+	if _, ok := cacheRepository.values[banCacheKey(expired.ID)]; ok {
+		t.Error("the expired ban is still cached")
+	}
+	if !slices.Equal(cacheService.forgotten, []uuid.UUID{expired.ID}) {
+		t.Errorf("forgotten users = %v, want %v", cacheService.forgotten, []uuid.UUID{expired.ID})
+	}
+
+	// the legacy ban is the one backfilled for the users banned before the ban records existed
+	for _, user := range []models.UserEntity{active, legacy} {
+		if !userRepository.users[user.ID].Banned {
+			t.Errorf("%s was unbanned", user.Username)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Dialosoft/src/adapters/repository"
 	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/utils/jsonWebToken"
 	"github.com/google/uuid"
 )
 
@@ -19,22 +20,22 @@ type CacheService interface {
 	// IsTokenBlacklisted checks if the given token has been blacklisted and is no longer valid.
 	IsTokenBlacklisted(token string) bool
 
+	// RevokeSession marks a session as revoked, so every access token issued for it
+	// stops being accepted before its natural expiration.
+	RevokeSession(sessionID uuid.UUID) error
+
+	// IsSessionRevoked checks if the session an access token was issued for has been revoked.
+	IsSessionRevoked(sessionID string) bool
+
 	// SetUserInfoByID stores user information in the cache associated with the given user ID.
 	SetUserInfoByID(userID uuid.UUID, userEntity *models.UserEntity) error
 
+	// DeleteUserInfoByID removes the user information cached for the given user ID.
+	DeleteUserInfoByID(userID uuid.UUID) error
+
 	// GetUserInfoByID retrieves user information from the cache based on the provided user ID.
 	// Returns the user entity or an error if not found.
 	GetUserInfoByID(userID uuid.UUID) (*models.UserEntity, error)
-
-	// SetRefreshTokenByID stores a refresh token in the cache, associated with the given user ID.
-	SetRefreshTokenByID(userID uuid.UUID, token string) error
-
-	// GetRefreshTokenByID retrieves a refresh token from the cache based on the provided user ID.
-	// Returns the token or an error if not found.
-	GetRefreshTokenByID(userID uuid.UUID) (string, error)
-
-	// DeleteRefreshTokenByID removes the refresh token from the cache associated with the given user ID.
-	DeleteRefreshTokenByID(userID uuid.UUID) error
 }
 
 type cacheServiceImpl struct {
@@ -94,25 +95,26 @@ func (service *cacheServiceImpl) IsTokenBlacklisted(token string) bool {
 	return is
 }
 
-// SetRefreshTokenByID implements CacheService.
-func (service *cacheServiceImpl) SetRefreshTokenByID(userID uuid.UUID, token string) error {
-	cacheKey := fmt.Sprintf("refreshToken:%s", userID.String())
-	return service.cacheRepository.Set(context.Background(), cacheKey, token, time.Hour*120) // 5 days
+// RevokeSession implements CacheService.
+func (service *cacheServiceImpl) RevokeSession(sessionID uuid.UUID) error {
+	cacheKey := fmt.Sprintf("revokedSession:%s", sessionID.String())
+	// access tokens of the session cannot outlive this key
+	return service.cacheRepository.Set(context.Background(), cacheKey, "true", jsonWebToken.AccessTokenDuration)
 }
 
-// GetRefreshTokenByID implements CacheService.
-func (service *cacheServiceImpl) GetRefreshTokenByID(userID uuid.UUID) (string, error) {
-	cacheKey := fmt.Sprintf("refreshToken:%s", userID.String())
-	refreshToken, err := service.cacheRepository.Get(context.Background(), cacheKey)
+// IsSessionRevoked implements CacheService.
+func (service *cacheServiceImpl) IsSessionRevoked(sessionID string) bool {
+	cacheKey := fmt.Sprintf("revokedSession:%s", sessionID)
+	is, err := service.cacheRepository.Exists(context.Background(), cacheKey)
 	if err != nil {
-		return "", err
+		return false
 	}
-
-	return refreshToken, nil
+	return is
 }
 
-func (service *cacheServiceImpl) DeleteRefreshTokenByID(userID uuid.UUID) error {
-	cacheKey := fmt.Sprintf("refreshToken:%s", userID.String())
+// DeleteUserInfoByID implements CacheService.
+func (service *cacheServiceImpl) DeleteUserInfoByID(userID uuid.UUID) error {
+	cacheKey := fmt.Sprintf("user:%s", userID.String())
 	return service.cacheRepository.Delete(context.Background(), cacheKey)
 }
 
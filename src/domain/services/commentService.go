@@ -0,0 +1,438 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CommentService provides an interface for managing the comment threads of posts.
+type CommentService interface {
+	// GetCommentThread retrieves the top level comments of a post with pagination options,
+	// each of them with its whole tree of replies nested under it.
+	// viewerID is used to flag the comments the viewer has voted, uuid.Nil for anonymous viewers.
+	// The thread of a redirect stub is the thread of the post it redirects to.
+	GetCommentThread(postID uuid.UUID, viewerID uuid.UUID, limit, offset int) (*response.CommentThreadResponse, error)
+
+	// GetCommentByID fetches a single comment based on its unique commentID.
+	GetCommentByID(commentID uuid.UUID, viewerID uuid.UUID) (*response.CommentResponse, error)
+
+	// GetCommentSnapshot fetches the state of a single comment for the audit log, even if it is deleted,
+	// without loading its replies.
+	GetCommentSnapshot(commentID uuid.UUID) (*dto.CommentSnapshotDto, error)
+
+	// CreateComment creates a new comment on a post, or a reply when req.ParentID is set.
+	// Returns errorsUtils.ErrPostLocked if the post is locked, unless the user can lock it.
+	// Comments on a redirect stub go to the post it redirects to.
+	CreateComment(postID uuid.UUID, actor dto.Actor, req request.NewComment) (response.CommentResponse, error)
+
+	// UpdateComment updates the content of a comment.
+	// Its author needs the comment.edit.own permission, anybody else comment.edit.any.
+	UpdateComment(commentID uuid.UUID, actor dto.Actor, content string) error
+
+	// DeleteComment deletes a comment and its replies.
+	// Its author needs the comment.delete.own permission, anybody else comment.delete.any,
+	// which the moderators of the forum of the post also have.
+	DeleteComment(commentID uuid.UUID, actor dto.Actor) error
+
+	// RestoreComment restores a previously deleted comment and the replies deleted with it.
+	// It needs the same permissions as DeleteComment.
+	RestoreComment(commentID uuid.UUID, actor dto.Actor) error
+
+	// VoteComment adds the vote of a user to a comment.
+	VoteComment(commentID uuid.UUID, userID uuid.UUID) error
+
+	// UnvoteComment removes the vote of a user from a comment.
+	UnvoteComment(commentID uuid.UUID, userID uuid.UUID) error
+
+	// MarkBestComment marks a comment as the best answer of its post.
+	// The author of the post can do it, anybody else needs the comment.best.any permission.
+	MarkBestComment(commentID uuid.UUID, actor dto.Actor) error
+
+	// UnmarkBestComment removes the best answer mark from a comment.
+	// It needs the same permissions as MarkBestComment.
+	UnmarkBestComment(commentID uuid.UUID, actor dto.Actor) error
+}
+
+type commentServiceImpl struct {
+	commentRepository      repository.CommentRepository
+	commentVotesRepository repository.CommentVotesRepository
+	postRepository         repository.PostRepository
+	userRepository         repository.UserRepository
+	moderatorService       ModeratorService
+}
+
+// GetCommentThread implements CommentService.
+func (service *commentServiceImpl) GetCommentThread(postID uuid.UUID, viewerID uuid.UUID, limit, offset int) (*response.CommentThreadResponse, error) {
+	postEntity, err := service.findPost(postID)
+	if err != nil {
+		return nil, err
+	}
+	postID = postEntity.ID
+
+	total, err := service.commentRepository.CountRootsByPostID(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := service.commentRepository.FindRootsByPostID(postID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := service.buildThread(roots, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.CommentThreadResponse{
+		PostID:   postID,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+		Comments: comments,
+	}, nil
+}
+
+// GetCommentByID implements CommentService.
+func (service *commentServiceImpl) GetCommentByID(commentID uuid.UUID, viewerID uuid.UUID) (*response.CommentResponse, error) {
+	commentEntity, err := service.commentRepository.FindByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := service.buildThread([]*models.Comment{commentEntity}, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comments[0], nil
+}
+
+// GetCommentSnapshot implements CommentService.
+func (service *commentServiceImpl) GetCommentSnapshot(commentID uuid.UUID) (*dto.CommentSnapshotDto, error) {
+	commentEntity, err := service.commentRepository.FindByIDWithDeleted(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &dto.CommentSnapshotDto{
+		ID:       commentEntity.ID,
+		AuthorID: commentEntity.UserID,
+		Content:  commentEntity.Content,
+		IsBest:   commentEntity.IsBest,
+	}
+	if commentEntity.DeletedAt.Valid {
+		snapshot.DeletedAt = &commentEntity.DeletedAt.Time
+	}
+
+	postEntity, err := service.postRepository.FindByID(commentEntity.PostID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.PostAuthorID = postEntity.UserID
+
+	return snapshot, nil
+}
+
+// CreateComment implements CommentService.
+func (service *commentServiceImpl) CreateComment(postID uuid.UUID, actor dto.Actor, req request.NewComment) (response.CommentResponse, error) {
+	if strings.TrimSpace(req.Content) == "" {
+		return response.CommentResponse{}, errorsUtils.ErrCommentContentInvalid
+	}
+
+	userEntity, err := service.userRepository.FindByID(actor.UserID)
+	if err != nil {
+		return response.CommentResponse{}, err
+	}
+
+	postEntity, err := service.findPost(postID)
+	if err != nil {
+		return response.CommentResponse{}, err
+	}
+	postID = postEntity.ID
+
+	// those who can lock the post can still comment on it, to explain why it was locked
+	if postEntity.Locked {
+		if err := service.checkPermission(actor, postEntity.ForumID, permissions.PostLock); err != nil {
+			if err == errorsUtils.ErrUserUnauthorized {
+				return response.CommentResponse{}, errorsUtils.ErrPostLocked
+			}
+			return response.CommentResponse{}, err
+		}
+	}
+
+	commentEntity := models.Comment{
+		UserID:  userEntity.ID,
+		PostID:  postID,
+		Content: req.Content,
+	}
+
+	if req.ParentID != nil && *req.ParentID != "" {
+		parentUUID, err := uuid.Parse(*req.ParentID)
+		if err != nil {
+			return response.CommentResponse{}, errorsUtils.ErrInvalidUUID
+		}
+
+		parent, err := service.commentRepository.FindByID(parentUUID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return response.CommentResponse{}, errorsUtils.ErrCommentNotFound
+			}
+			return response.CommentResponse{}, err
+		}
+
+		if parent.PostID != postID {
+			return response.CommentResponse{}, errorsUtils.ErrCommentParentMismatch
+		}
+
+		commentEntity.CommentID = &parent.ID
+	}
+
+	newComment, err := service.commentRepository.Create(commentEntity)
+	if err != nil {
+		return response.CommentResponse{}, err
+	}
+	newComment.User = *userEntity
+
+	return mapper.CommentEntityToCommentResponse(newComment), nil
+}
+
+// UpdateComment implements CommentService.
+func (service *commentServiceImpl) UpdateComment(commentID uuid.UUID, actor dto.Actor, content string) error {
+	if strings.TrimSpace(content) == "" {
+		return errorsUtils.ErrCommentContentInvalid
+	}
+
+	commentEntity, err := service.commentRepository.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkOwnOrAny(commentEntity, actor, permissions.CommentEditOwn, permissions.CommentEditAny); err != nil {
+		return err
+	}
+
+	return service.commentRepository.Update(commentID, content)
+}
+
+// DeleteComment implements CommentService.
+func (service *commentServiceImpl) DeleteComment(commentID uuid.UUID, actor dto.Actor) error {
+	commentEntity, err := service.commentRepository.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkOwnOrAny(commentEntity, actor, permissions.CommentDeleteOwn, permissions.CommentDeleteAny); err != nil {
+		return err
+	}
+
+	return service.commentRepository.Delete(commentID)
+}
+
+// RestoreComment implements CommentService.
+func (service *commentServiceImpl) RestoreComment(commentID uuid.UUID, actor dto.Actor) error {
+	commentEntity, err := service.commentRepository.FindByIDWithDeleted(commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkOwnOrAny(commentEntity, actor, permissions.CommentDeleteOwn, permissions.CommentDeleteAny); err != nil {
+		return err
+	}
+
+	if commentEntity.CommentID != nil {
+		if _, err := service.commentRepository.FindByID(*commentEntity.CommentID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errorsUtils.ErrCommentParentDeleted
+			}
+			return err
+		}
+	}
+
+	return service.commentRepository.Restore(commentID)
+}
+
+// VoteComment implements CommentService.
+func (service *commentServiceImpl) VoteComment(commentID uuid.UUID, userID uuid.UUID) error {
+	if _, err := service.commentRepository.FindByID(commentID); err != nil {
+		return err
+	}
+
+	return service.commentVotesRepository.Save(commentID, userID)
+}
+
+// UnvoteComment implements CommentService.
+func (service *commentServiceImpl) UnvoteComment(commentID uuid.UUID, userID uuid.UUID) error {
+	return service.commentVotesRepository.Remove(commentID, userID)
+}
+
+// MarkBestComment implements CommentService.
+func (service *commentServiceImpl) MarkBestComment(commentID uuid.UUID, actor dto.Actor) error {
+	commentEntity, err := service.commentRepository.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkPostAuthorOrPermission(commentEntity.PostID, actor); err != nil {
+		return err
+	}
+
+	return service.commentRepository.MarkBest(commentEntity.PostID, commentID)
+}
+
+// UnmarkBestComment implements CommentService.
+func (service *commentServiceImpl) UnmarkBestComment(commentID uuid.UUID, actor dto.Actor) error {
+	commentEntity, err := service.commentRepository.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkPostAuthorOrPermission(commentEntity.PostID, actor); err != nil {
+		return err
+	}
+
+	return service.commentRepository.UnmarkBest(commentID)
+}
+
+// findPost retrieves a post, or the post it redirects to if it is a redirect stub.
+func (service *commentServiceImpl) findPost(postID uuid.UUID) (*models.Post, error) {
+	postEntity, err := service.postRepository.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if postEntity.RedirectToID != nil {
+		return service.postRepository.FindByID(*postEntity.RedirectToID)
+	}
+
+	return postEntity, nil
+}
+
+// checkPostAuthorOrPermission returns errorsUtils.ErrUserUnauthorized unless the actor
+// is the author of the post or has the comment.best.any permission in its forum.
+func (service *commentServiceImpl) checkPostAuthorOrPermission(postID uuid.UUID, actor dto.Actor) error {
+	postEntity, err := service.postRepository.FindByID(postID)
+	if err != nil {
+		return err
+	}
+
+	if postEntity.UserID == actor.UserID {
+		return nil
+	}
+
+	return service.checkPermission(actor, postEntity.ForumID, permissions.CommentBestAny)
+}
+
+// checkOwnOrAny returns errorsUtils.ErrUserUnauthorized unless the actor has the own permission
+// and is the author of the comment, or has the any permission in the forum of the comment.
+func (service *commentServiceImpl) checkOwnOrAny(commentEntity *models.Comment, actor dto.Actor, ownPermission string, anyPermission string) error {
+	postEntity, err := service.postRepository.FindByID(commentEntity.PostID)
+	if err != nil {
+		return err
+	}
+
+	if commentEntity.UserID == actor.UserID {
+		if err := service.checkPermission(actor, postEntity.ForumID, ownPermission); err != errorsUtils.ErrUserUnauthorized {
+			return err
+		}
+	}
+
+	return service.checkPermission(actor, postEntity.ForumID, anyPermission)
+}
+
+// checkPermission returns errorsUtils.ErrUserUnauthorized unless the actor has the permission in the forum.
+func (service *commentServiceImpl) checkPermission(actor dto.Actor, forumID uuid.UUID, permission string) error {
+	granted, err := service.moderatorService.HasPermissionInForum(actor, forumID, permission)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return errorsUtils.ErrUserUnauthorized
+	}
+
+	return nil
+}
+
+// buildThread loads the replies of the given comments level by level and
+// returns the comments with their replies nested, keeping the given order.
+// Every comment carries its vote count and whether viewerID has voted it.
+func (service *commentServiceImpl) buildThread(roots []*models.Comment, viewerID uuid.UUID) ([]response.CommentResponse, error) {
+	childrenByParent := make(map[uuid.UUID][]*models.Comment)
+
+	parentIDs := make([]uuid.UUID, 0, len(roots))
+	for _, root := range roots {
+		parentIDs = append(parentIDs, root.ID)
+	}
+	commentIDs := append([]uuid.UUID{}, parentIDs...)
+
+	for len(parentIDs) > 0 {
+		replies, err := service.commentRepository.FindRepliesByParentIDs(parentIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		var nextParentIDs []uuid.UUID
+		for _, reply := range replies {
+			childrenByParent[*reply.CommentID] = append(childrenByParent[*reply.CommentID], reply)
+			nextParentIDs = append(nextParentIDs, reply.ID)
+		}
+		commentIDs = append(commentIDs, nextParentIDs...)
+		parentIDs = nextParentIDs
+	}
+
+	votes, err := service.commentVotesRepository.CountByCommentIDs(commentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	voted := make(map[uuid.UUID]bool)
+	if viewerID != uuid.Nil {
+		votedIDs, err := service.commentVotesRepository.FindCommentIDsVotedByUser(viewerID, commentIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, votedID := range votedIDs {
+			voted[votedID] = true
+		}
+	}
+
+	var toResponse func(comment *models.Comment) response.CommentResponse
+	toResponse = func(comment *models.Comment) response.CommentResponse {
+		commentResponse := mapper.CommentEntityToCommentResponse(comment)
+		commentResponse.Votes = votes[comment.ID]
+		commentResponse.Voted = voted[comment.ID]
+		for _, child := range childrenByParent[comment.ID] {
+			commentResponse.Replies = append(commentResponse.Replies, toResponse(child))
+		}
+		return commentResponse
+	}
+
+	comments := make([]response.CommentResponse, 0, len(roots))
+	for _, root := range roots {
+		comments = append(comments, toResponse(root))
+	}
+
+	return comments, nil
+}
+
+func NewCommentService(commentRepository repository.CommentRepository,
+	commentVotesRepository repository.CommentVotesRepository,
+	postRepository repository.PostRepository,
+	userRepository repository.UserRepository,
+	moderatorService ModeratorService) CommentService {
+	return &commentServiceImpl{
+		commentRepository:      commentRepository,
+		commentVotesRepository: commentVotesRepository,
+		postRepository:         postRepository,
+		userRepository:         userRepository,
+		moderatorService:       moderatorService}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/email"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+)
+
+const (
+	// outboxBatchSize is the maximum number of emails claimed from the outbox at once.
+	outboxBatchSize = 20
+
+	// outboxLease is how long a claimed email is hidden from other workers while it is being sent.
+	outboxLease = time.Minute * 5
+
+	// outboxMaxAttempts is the number of deliveries tried before an email is marked as failed.
+	outboxMaxAttempts = 8
+
+	// outboxBaseBackoff and outboxMaxBackoff bound the wait between two delivery attempts,
+	// which doubles after every failure.
+	outboxBaseBackoff = time.Second * 30
+	outboxMaxBackoff  = time.Hour * 6
+)
+
+// EmailService defines the methods for sending emails to users.
+// Emails are stored in a persistent outbox and delivered in the background by the outbox worker.
+type EmailService interface {
+	// Send renders the template of data in the given locale and queues the email in the outbox.
+	// Returns an error if the template cannot be rendered or the email cannot be queued.
+	Send(to string, locale string, data mails.Data) error
+
+	// StartOutboxWorker delivers the emails waiting in the outbox every interval until ctx is done.
+	// Failed deliveries are retried with exponential backoff.
+	StartOutboxWorker(ctx context.Context, interval time.Duration)
+}
+
+type emailServiceImpl struct {
+	outboxRepository repository.OutboxRepository
+	transport        email.Transport
+	renderer         *mails.Renderer
+}
+
+// Send implements EmailService.
+func (service *emailServiceImpl) Send(to string, locale string, data mails.Data) error {
+	message, err := service.renderer.Render(locale, data)
+	if err != nil {
+		return err
+	}
+
+	return service.outboxRepository.Enqueue(models.OutboxEmailEntity{
+		Recipient: to,
+		Template:  data.TemplateName(),
+		Subject:   message.Subject,
+		TextBody:  message.Text,
+		HTMLBody:  message.HTML,
+	})
+}
+
+// StartOutboxWorker implements EmailService.
+func (service *emailServiceImpl) StartOutboxWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			service.processOutbox()
+		case <-ctx.Done():
+			logger.Info("Stopping email outbox worker", nil)
+			return
+		}
+	}
+}
+
+// processOutbox delivers every email of the outbox that is due, batch after batch.
+func (service *emailServiceImpl) processOutbox() {
+	for {
+		emails, err := service.outboxRepository.ClaimDue(outboxBatchSize, outboxLease)
+		if err != nil {
+			logger.CaptureError(err, "Error claiming emails from the outbox", nil)
+			return
+		}
+
+		for _, outboxEmail := range emails {
+			service.deliver(outboxEmail)
+		}
+
+		if len(emails) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends an email of the outbox and records the result of the attempt.
+func (service *emailServiceImpl) deliver(outboxEmail *models.OutboxEmailEntity) {
+	err := service.transport.Send(mails.Message{
+		To:      []string{outboxEmail.Recipient},
+		Subject: outboxEmail.Subject,
+		Text:    outboxEmail.TextBody,
+		HTML:    outboxEmail.HTMLBody,
+	})
+	if err == nil {
+		if err := service.outboxRepository.MarkSent(outboxEmail.ID); err != nil {
+			logger.CaptureError(err, "Error marking outbox email as sent", map[string]interface{}{
+				"emailID": outboxEmail.ID,
+			})
+		}
+		return
+	}
+
+	attempts := outboxEmail.Attempts + 1
+	if attempts >= outboxMaxAttempts {
+		logger.Error("Email delivery failed, giving up", map[string]interface{}{
+			"emailID":  outboxEmail.ID,
+			"template": outboxEmail.Template,
+			"attempts": attempts,
+			"error":    err.Error(),
+		})
+		if err := service.outboxRepository.MarkFailed(outboxEmail.ID, attempts, err.Error()); err != nil {
+			logger.CaptureError(err, "Error marking outbox email as failed", map[string]interface{}{
+				"emailID": outboxEmail.ID,
+			})
+		}
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(outboxBackoff(attempts))
+	logger.Warn("Email delivery failed, retrying later", map[string]interface{}{
+		"emailID":       outboxEmail.ID,
+		"template":      outboxEmail.Template,
+		"attempts":      attempts,
+		"nextAttemptAt": nextAttemptAt,
+		"error":         err.Error(),
+	})
+	if err := service.outboxRepository.MarkRetry(outboxEmail.ID, attempts, nextAttemptAt, err.Error()); err != nil {
+		logger.CaptureError(err, "Error scheduling outbox email retry", map[string]interface{}{
+			"emailID": outboxEmail.ID,
+		})
+	}
+}
+
+// outboxBackoff returns the wait before the next delivery attempt after the given number of failed attempts.
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+
+	return backoff
+}
+
+func NewEmailService(outboxRepository repository.OutboxRepository, transport email.Transport, renderer *mails.Renderer) EmailService {
+	return &emailServiceImpl{
+		outboxRepository: outboxRepository,
+		transport:        transport,
+		renderer:         renderer,
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/google/uuid"
+)
+
+// outboxStore is an outbox whose clock runs ahead of the real one by ahead, so the tests can make retries due.
+type outboxStore struct {
+	repository.OutboxRepository
+	emails []*models.OutboxEmailEntity
+	ahead  time.Duration
+
+	// delays are the waits before the retries that were scheduled
+	delays []time.Duration
+}
+
+func (store *outboxStore) now() time.Time {
+	return time.Now().Add(store.ahead)
+}
+
+func (store *outboxStore) find(emailID uuid.UUID) *models.OutboxEmailEntity {
+	for _, email := range store.emails {
+		if email.ID == emailID {
+			return email
+		}
+	}
+	return nil
+}
+
+func (store *outboxStore) Enqueue(email models.OutboxEmailEntity) error {
+	email.ID = uuid.New()
+	email.Status = models.OutboxPending
+	email.NextAttemptAt = store.now()
+	store.emails = append(store.emails, &email)
+	return nil
+}
+
+func (store *outboxStore) ClaimDue(limit int, lease time.Duration) ([]*models.OutboxEmailEntity, error) {
+	var due []*models.OutboxEmailEntity
+	for _, email := range store.emails {
+		if email.Status == models.OutboxPending && !email.NextAttemptAt.After(store.now()) && len(due) < limit {
+			claimed := *email
+			due = append(due, &claimed)
+			email.NextAttemptAt = store.now().Add(lease)
+		}
+	}
+	return due, nil
+}
+
+func (store *outboxStore) MarkSent(emailID uuid.UUID) error {
+	email := store.find(emailID)
+	email.Status = models.OutboxSent
+	email.Attempts++
+	return nil
+}
+
+func (store *outboxStore) MarkRetry(emailID uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	// the service schedules with the real clock
+	store.delays = append(store.delays, nextAttemptAt.Sub(time.Now()).Round(time.Second))
+
+	email := store.find(emailID)
+	email.Attempts = attempts
+	email.NextAttemptAt = nextAttemptAt.Add(store.ahead)
+	email.LastError = lastError
+	return nil
+}
+
+func (store *outboxStore) MarkFailed(emailID uuid.UUID, attempts int, lastError string) error {
+	email := store.find(emailID)
+	email.Status = models.OutboxFailed
+	email.Attempts = attempts
+	email.LastError = lastError
+	return nil
+}
+
+// downTransport fails the first failures deliveries.
+type downTransport struct {
+	failures  int
+	delivered []mails.Message
+	attempts  int
+}
+
+func (transport *downTransport) Send(message mails.Message) error {
+	transport.attempts++
+	if transport.attempts <= transport.failures {
+		return errors.New("421 service not available")
+	}
+	transport.delivered = append(transport.delivered, message)
+	return nil
+}
+
+func TestOutboxRetriesWithBackoff(t *testing.T) {
+	store := &outboxStore{}
+	transport := &downTransport{failures: 3}
+	service := NewEmailService(store, transport, mails.NewRenderer("")).(*emailServiceImpl)
+
+	err := service.Send("judy@dialosoft.test", "en", mails.PasswordResetData{Username: "judy", Link: "https://dialosoft.test/reset", ValidForHours: 1})
+	if err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	service.processOutbox()
+	// nothing is due before the backoff has passed
+	service.processOutbox()
+	if transport.attempts != 1 {
+		t.Fatalf("%d attempts before the backoff passed, want 1", transport.attempts)
+	}
+
+	for i := 0; transport.attempts < 4 && i < 10; i++ {
+		store.ahead += outboxBaseBackoff << (transport.attempts - 1)
+		service.processOutbox()
+	}
+
+	want := []time.Duration{outboxBaseBackoff, 2 * outboxBaseBackoff, 4 * outboxBaseBackoff}
+	if len(store.delays) != len(want) {
+		t.Fatalf("retries scheduled after %v, want %v", store.delays, want)
+	}
+	for i := range want {
+		if store.delays[i] != want[i] {
+			t.Errorf("retry %d scheduled after %v, want %v", i+1, store.delays[i], want[i])
+		}
+	}
+
+	email := store.emails[0]
+	if email.Status != models.OutboxSent || email.Attempts != 4 || len(transport.delivered) != 1 {
+		t.Errorf("email %s after %d attempts, %d delivered, want sent after 4", email.Status, email.Attempts, len(transport.delivered))
+	}
+}
+
+func TestOutboxGivesUp(t *testing.T) {
+	store := &outboxStore{}
+	transport := &downTransport{failures: outboxMaxAttempts + 10}
+	service := NewEmailService(store, transport, mails.NewRenderer("")).(*emailServiceImpl)
+
+	if err := service.Send("kim@dialosoft.test", "en", mails.VerifyEmailData{Username: "kim", Link: "https://dialosoft.test/verify", ValidForHours: 48}); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	for i := 0; i < outboxMaxAttempts+2; i++ {
+		service.processOutbox()
+		store.ahead += outboxMaxBackoff
+	}
+
+	email := store.emails[0]
+	if email.Status != models.OutboxFailed || email.Attempts != outboxMaxAttempts {
+		t.Errorf("email %s after %d attempts, want failed after %d", email.Status, email.Attempts, outboxMaxAttempts)
+	}
+	if transport.attempts != outboxMaxAttempts {
+		t.Errorf("%d deliveries tried, want %d", transport.attempts, outboxMaxAttempts)
+	}
+	if email.LastError == "" {
+		t.Error("the error of the last attempt was not kept")
+	}
+}
@@ -0,0 +1,465 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/Dialosoft/src/pkg/utils/identity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// The fakes keep their state in memory and implement the methods the tests reach,
+// the embedded interfaces are nil and panic on any other call.
+
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[uuid.UUID]*models.UserEntity
+}
+
+func newFakeUserRepository(users ...models.UserEntity) *fakeUserRepository {
+	repo := &fakeUserRepository{users: make(map[uuid.UUID]*models.UserEntity)}
+	for i := range users {
+		user := users[i]
+		user.SetIdentityKeys()
+		repo.users[user.ID] = &user
+	}
+	return repo
+}
+
+func (repo *fakeUserRepository) FindByID(id uuid.UUID) (*models.UserEntity, error) {
+	user, ok := repo.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (repo *fakeUserRepository) FindByUsername(username string) (*models.UserEntity, error) {
+	for _, user := range repo.users {
+		if *user.UsernameKey == identity.NormalizeUsername(username) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (repo *fakeUserRepository) FindByEmail(email string) (*models.UserEntity, error) {
+	for _, user := range repo.users {
+		if user.EmailKey != nil && *user.EmailKey == identity.NormalizeEmail(email) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (repo *fakeUserRepository) Update(userID uuid.UUID, updatedUser models.UserEntity) error {
+	user, ok := repo.users[userID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	updatedUser.SetIdentityKeys()
+	for id, other := range repo.users {
+		if id != userID && *other.UsernameKey == *updatedUser.UsernameKey {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	user.Username = updatedUser.Username
+	user.UsernameKey = updatedUser.UsernameKey
+	return nil
+}
+
+type fakeBanRepository struct {
+	repository.BanRepository
+	users *fakeUserRepository
+	bans  []models.BanEntity
+}
+
+func (repo *fakeBanRepository) FindActive(userID uuid.UUID, at time.Time) (*models.BanEntity, error) {
+	for i := range repo.bans {
+		if repo.bans[i].UserID == userID && repo.bans[i].Active(at) {
+			found := repo.bans[i]
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (repo *fakeBanRepository) ClearExpired(at time.Time) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	for id, user := range repo.users.users {
+		if !user.Banned {
+			continue
+		}
+		if _, err := repo.FindActive(id, at); err == gorm.ErrRecordNotFound {
+			user.Banned = false
+			userIDs = append(userIDs, id)
+		}
+	}
+	return userIDs, nil
+}
+
+type fakeCacheRepository struct {
+	repository.RedisRepository
+	values map[string]string
+}
+
+func newFakeCacheRepository() *fakeCacheRepository {
+	return &fakeCacheRepository{values: make(map[string]string)}
+}
+
+func (repo *fakeCacheRepository) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	repo.values[key] = fmt.Sprint(value)
+	return nil
+}
+
+func (repo *fakeCacheRepository) Get(ctx context.Context, key string) (string, error) {
+	value, ok := repo.values[key]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return value, nil
+}
+
+func (repo *fakeCacheRepository) GetDel(ctx context.Context, key string) (string, error) {
+	value, err := repo.Get(ctx, key)
+	delete(repo.values, key)
+	return value, err
+}
+
+func (repo *fakeCacheRepository) Delete(ctx context.Context, key string) error {
+	delete(repo.values, key)
+	return nil
+}
+
+type fakeCacheService struct {
+	CacheService
+	forgotten       []uuid.UUID
+	invalidated     []string
+	revokedSessions []uuid.UUID
+}
+
+func (service *fakeCacheService) DeleteUserInfoByID(userID uuid.UUID) error {
+	service.forgotten = append(service.forgotten, userID)
+	return nil
+}
+
+func (service *fakeCacheService) GetUserInfoByID(userID uuid.UUID) (*models.UserEntity, error) {
+	return nil, errors.New("redis: nil")
+}
+
+func (service *fakeCacheService) SetUserInfoByID(userID uuid.UUID, user *models.UserEntity) error {
+	return nil
+}
+
+func (service *fakeCacheService) InvalidateRefreshToken(token string) error {
+	service.invalidated = append(service.invalidated, token)
+	return nil
+}
+
+func (service *fakeCacheService) IsTokenBlacklisted(token string) bool {
+	return slices.Contains(service.invalidated, token)
+}
+
+func (service *fakeCacheService) RevokeSession(sessionID uuid.UUID) error {
+	service.revokedSessions = append(service.revokedSessions, sessionID)
+	return nil
+}
+
+type fakeReportRepository struct {
+	repository.ReportRepository
+	mu      sync.Mutex
+	reports map[uuid.UUID]*models.ReportEntity
+}
+
+func newFakeReportRepository() *fakeReportRepository {
+	return &fakeReportRepository{reports: make(map[uuid.UUID]*models.ReportEntity)}
+}
+
+func (repo *fakeReportRepository) AddEntry(report models.ReportEntity, entry models.ReportEntryEntity) (uuid.UUID, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var pending *models.ReportEntity
+	for _, existing := range repo.reports {
+		if existing.TargetType == report.TargetType && existing.TargetID == report.TargetID && existing.Status != models.ReportStatusResolved {
+			pending = existing
+		}
+	}
+	if pending == nil {
+		report.ID = uuid.New()
+		report.Status = models.ReportStatusOpen
+		pending = &report
+		repo.reports[report.ID] = pending
+	} else {
+		for _, existing := range pending.Entries {
+			if existing.ReporterID == entry.ReporterID {
+				return uuid.UUID{}, gorm.ErrDuplicatedKey
+			}
+		}
+	}
+
+	entry.ReportID = pending.ID
+	pending.Entries = append(pending.Entries, entry)
+	pending.ReportCount = len(pending.Entries)
+	return pending.ID, nil
+}
+
+func (repo *fakeReportRepository) FindByID(reportID uuid.UUID) (*models.ReportEntity, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	report, ok := repo.reports[reportID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *report
+	return &found, nil
+}
+
+func (repo *fakeReportRepository) Claim(reportID uuid.UUID, claimedBy uuid.UUID, at time.Time, staleAfter time.Duration) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	report, ok := repo.reports[reportID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stale := report.Status == models.ReportStatusClaimed && report.ClaimedAt.Before(at.Add(-staleAfter))
+	if report.Status != models.ReportStatusOpen && !stale {
+		return gorm.ErrRecordNotFound
+	}
+	report.Status = models.ReportStatusClaimed
+	report.ResolvedBy = &claimedBy
+	report.ClaimedAt = &at
+	return nil
+}
+
+func (repo *fakeReportRepository) ReleaseClaim(reportID uuid.UUID, claimedBy uuid.UUID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	report, ok := repo.reports[reportID]
+	if !ok || report.Status != models.ReportStatusClaimed || *report.ResolvedBy != claimedBy {
+		return gorm.ErrRecordNotFound
+	}
+	report.Status = models.ReportStatusOpen
+	report.ResolvedBy = nil
+	report.ClaimedAt = nil
+	return nil
+}
+
+func (repo *fakeReportRepository) Resolve(reportID uuid.UUID, action string, note string, resolvedBy uuid.UUID, at time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	report, ok := repo.reports[reportID]
+	if !ok || report.Status != models.ReportStatusClaimed || *report.ResolvedBy != resolvedBy {
+		return gorm.ErrRecordNotFound
+	}
+	report.Status = models.ReportStatusResolved
+	report.Action = action
+	report.ActionNote = note
+	report.ResolvedAt = &at
+	return nil
+}
+
+type fakeRoleService struct {
+	RoleService
+	granted []string
+}
+
+func (service *fakeRoleService) HasPermission(roleID uuid.UUID, permission string) (bool, error) {
+	return slices.Contains(service.granted, permission), nil
+}
+
+type fakeEmailService struct {
+	EmailService
+	mu   sync.Mutex
+	sent []string
+}
+
+func (service *fakeEmailService) Send(to string, locale string, data mails.Data) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.sent = append(service.sent, to)
+	return nil
+}
+
+type fakePostRepository struct {
+	repository.PostRepository
+	posts map[uuid.UUID]*models.Post
+
+	moved  []uuid.UUID
+	stubs  []models.Post
+	merged map[uuid.UUID]uuid.UUID
+}
+
+func newFakePostRepository(posts ...models.Post) *fakePostRepository {
+	repo := &fakePostRepository{posts: make(map[uuid.UUID]*models.Post), merged: make(map[uuid.UUID]uuid.UUID)}
+	for i := range posts {
+		post := posts[i]
+		repo.posts[post.ID] = &post
+	}
+	return repo
+}
+
+func (repo *fakePostRepository) FindByID(id uuid.UUID) (*models.Post, error) {
+	post, ok := repo.posts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *post
+	return &found, nil
+}
+
+func (repo *fakePostRepository) Move(postID uuid.UUID, forumID uuid.UUID, stub *models.Post) error {
+	post, ok := repo.posts[postID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	post.ForumID = forumID
+	post.PinnedAt = nil
+	repo.moved = append(repo.moved, postID)
+	if stub != nil {
+		repo.stubs = append(repo.stubs, *stub)
+	}
+	return nil
+}
+
+func (repo *fakePostRepository) Merge(source models.Post, targetID uuid.UUID) error {
+	post, ok := repo.posts[source.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	post.RedirectToID = &targetID
+	repo.merged[source.ID] = targetID
+	return nil
+}
+
+type fakeForumRepository struct {
+	repository.ForumRepository
+	forums map[uuid.UUID]*models.Forum
+}
+
+func newFakeForumRepository(forums ...models.Forum) *fakeForumRepository {
+	repo := &fakeForumRepository{forums: make(map[uuid.UUID]*models.Forum)}
+	for i := range forums {
+		forum := forums[i]
+		repo.forums[forum.ID] = &forum
+	}
+	return repo
+}
+
+// FindByID returns nil without an error for missing forums, as the forum repository does.
+func (repo *fakeForumRepository) FindByID(id uuid.UUID) (*models.Forum, error) {
+	forum, ok := repo.forums[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *forum
+	return &found, nil
+}
+
+// fakeModeratorService grants every permission in the forums it lists.
+type fakeModeratorService struct {
+	ModeratorService
+	forums []uuid.UUID
+}
+
+func (service *fakeModeratorService) HasPermissionInForum(actor dto.Actor, forumID uuid.UUID, permission string) (bool, error) {
+	return slices.Contains(service.forums, forumID), nil
+}
+
+type fakeTokenRepository struct {
+	repository.TokenRepository
+	tokens map[uuid.UUID]*models.TokenEntity
+}
+
+func newFakeTokenRepository(tokens ...models.TokenEntity) *fakeTokenRepository {
+	repo := &fakeTokenRepository{tokens: make(map[uuid.UUID]*models.TokenEntity)}
+	for i := range tokens {
+		token := tokens[i]
+		repo.tokens[token.ID] = &token
+	}
+	return repo
+}
+
+func (repo *fakeTokenRepository) FindTokenByID(tokenID uuid.UUID) (*models.TokenEntity, error) {
+	token, ok := repo.tokens[tokenID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+func (repo *fakeTokenRepository) FindActiveTokensByUserID(userID uuid.UUID) ([]*models.TokenEntity, error) {
+	var tokens []*models.TokenEntity
+	for _, token := range repo.tokens {
+		if token.UserID == userID && !token.Blocked && token.RotatedAt == nil {
+			found := *token
+			tokens = append(tokens, &found)
+		}
+	}
+	return tokens, nil
+}
+
+func (repo *fakeTokenRepository) FindActiveTokenByFamilyID(familyID uuid.UUID) (*models.TokenEntity, error) {
+	for _, token := range repo.tokens {
+		if token.FamilyID == familyID && !token.Blocked && token.RotatedAt == nil {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (repo *fakeTokenRepository) Rotate(tokenID uuid.UUID, newToken models.TokenEntity) error {
+	token, ok := repo.tokens[tokenID]
+	if !ok || token.Blocked || token.RotatedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	token.RotatedAt = &now
+	repo.tokens[newToken.ID] = &newToken
+	return nil
+}
+
+func (repo *fakeTokenRepository) BlockFamily(familyID uuid.UUID) error {
+	for _, token := range repo.tokens {
+		if token.FamilyID == familyID {
+			token.Blocked = true
+		}
+	}
+	return nil
+}
+
+type fakeRoleRepository struct {
+	repository.RoleRepository
+}
+
+func (repo *fakeRoleRepository) FindByID(roleID uuid.UUID) (*models.RoleEntity, error) {
+	return &models.RoleEntity{ID: roleID, RoleType: "user"}, nil
+}
+
+type fakeBanService struct {
+	BanService
+}
+
+func (service *fakeBanService) CheckBan(userID uuid.UUID) error {
+	return nil
+}
@@ -31,9 +31,9 @@ type ForumService interface {
 	CreateForum(newRequest request.NewForum) (uuid.UUID, error)
 
 	// UpdateForum updates an existing forum's information by its ID.
-	// The updated data is provided via the NewForum request structure.
+	// The updated data is provided via the UpdateForum request structure.
 	// Returns an error if the update fails or the forum is not found.
-	UpdateForum(id uuid.UUID, req request.NewForum) error
+	UpdateForum(id uuid.UUID, req request.UpdateForum) error
 
 	// DeleteForum removes a forum by its ID.
 	// Returns an error if the deletion fails or the forum is not found.
@@ -144,7 +144,7 @@ func (service *forumServiceImpl) RestoreForum(id uuid.UUID) error {
 }
 
 // UpdateForum implements ForumService.
-func (service *forumServiceImpl) UpdateForum(id uuid.UUID, req request.NewForum) error {
+func (service *forumServiceImpl) UpdateForum(id uuid.UUID, req request.UpdateForum) error {
 	forum, err := service.forumRepository.FindByID(id)
 	if err != nil {
 		return err
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/identity"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+)
+
+// LoginThrottleService defines the methods protecting the logins against brute-force attacks.
+// Failed attempts are counted in sliding windows, by username and by client IP.
+type LoginThrottleService interface {
+
+	// Check tells whether a login for the username from the IP may be attempted now.
+	// Returns an errorsUtils.LoginBlockedError wrapping errorsUtils.ErrAccountLocked if the account is locked,
+	// or errorsUtils.ErrLoginThrottled if the client has to wait before trying again.
+	Check(username string, ip string) error
+
+	// RegisterFailure records a failed login for the username from the IP, delaying or locking
+	// the next attempts once the configured thresholds are reached.
+	RegisterFailure(username string, ip string)
+
+	// RegisterSuccess records a successful login, forgetting the failed attempts of the username.
+	RegisterSuccess(username string, ip string)
+
+	// Unlock removes the lockout and forgets the failed attempts of a username.
+	Unlock(username string) error
+
+	// UnlockIP removes the lockout and forgets the failed attempts from a client IP.
+	UnlockIP(ip string) error
+}
+
+// LoginThrottleConfig holds the thresholds of the brute-force protection.
+type LoginThrottleConfig struct {
+	// Window is how long a failed attempt is remembered.
+	Window time.Duration
+
+	// DelayAfter is the number of failures of a username after which every new attempt has to wait,
+	// one second doubled for each further failure.
+	DelayAfter int
+
+	// LockoutAfter is the number of failures of a username after which the account is locked.
+	LockoutAfter int
+
+	// LockoutDuration is how long an account or an IP stays locked.
+	LockoutDuration time.Duration
+
+	// IPLockoutAfter is the number of failures from an IP, for any username, after which the IP is locked.
+	IPLockoutAfter int
+}
+
+type loginThrottleServiceImpl struct {
+	cacheRepository repository.RedisRepository
+	config          LoginThrottleConfig
+}
+
+// Check implements LoginThrottleService.
+func (service *loginThrottleServiceImpl) Check(username string, ip string) error {
+	ctx := context.Background()
+	username = normalizeLoginUsername(username)
+
+	if retryAfter := service.blockedFor(ctx, loginLockKey("user", username)); retryAfter > 0 {
+		return &errorsUtils.LoginBlockedError{Err: errorsUtils.ErrAccountLocked, RetryAfter: retryAfter}
+	}
+	if ip != "" {
+		if retryAfter := service.blockedFor(ctx, loginLockKey("ip", ip)); retryAfter > 0 {
+			return &errorsUtils.LoginBlockedError{Err: errorsUtils.ErrLoginThrottled, RetryAfter: retryAfter}
+		}
+	}
+	if retryAfter := service.blockedFor(ctx, loginDelayKey(username)); retryAfter > 0 {
+		return &errorsUtils.LoginBlockedError{Err: errorsUtils.ErrLoginThrottled, RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// RegisterFailure implements LoginThrottleService.
+func (service *loginThrottleServiceImpl) RegisterFailure(username string, ip string) {
+	ctx := context.Background()
+	now := time.Now()
+	username = normalizeLoginUsername(username)
+
+	failures, err := service.cacheRepository.AddToWindow(ctx, loginFailuresKey("user", username), now, service.config.Window)
+	if err != nil {
+		logger.CaptureError(err, "Error recording failed login", map[string]interface{}{
+			"username": username,
+		})
+	}
+
+	var ipFailures int64
+	if ip != "" {
+		ipFailures, err = service.cacheRepository.AddToWindow(ctx, loginFailuresKey("ip", ip), now, service.config.Window)
+		if err != nil {
+			logger.CaptureError(err, "Error recording failed login", map[string]interface{}{
+				"ip": ip,
+			})
+		}
+	}
+
+	logger.Warn("Failed login attempt", map[string]interface{}{
+		"username":   username,
+		"ip":         ip,
+		"failures":   failures,
+		"ipFailures": ipFailures,
+	})
+
+	switch {
+	case service.config.LockoutAfter > 0 && failures >= int64(service.config.LockoutAfter):
+		service.block(ctx, loginLockKey("user", username), service.config.LockoutDuration)
+		logger.Warn("Account locked after too many failed logins", map[string]interface{}{
+			"username": username,
+			"ip":       ip,
+			"duration": service.config.LockoutDuration.String(),
+		})
+	case service.config.DelayAfter > 0 && failures >= int64(service.config.DelayAfter):
+		service.block(ctx, loginDelayKey(username), service.delayFor(failures))
+	}
+
+	if service.config.IPLockoutAfter > 0 && ipFailures >= int64(service.config.IPLockoutAfter) {
+		service.block(ctx, loginLockKey("ip", ip), service.config.LockoutDuration)
+		logger.Warn("IP locked after too many failed logins", map[string]interface{}{
+			"ip":       ip,
+			"duration": service.config.LockoutDuration.String(),
+		})
+	}
+}
+
+// RegisterSuccess implements LoginThrottleService.
+func (service *loginThrottleServiceImpl) RegisterSuccess(username string, ip string) {
+	username = normalizeLoginUsername(username)
+
+	logger.Info("Successful login", map[string]interface{}{
+		"username": username,
+		"ip":       ip,
+	})
+
+	ctx := context.Background()
+	for _, key := range []string{loginFailuresKey("user", username), loginDelayKey(username)} {
+		if err := service.cacheRepository.Delete(ctx, key); err != nil {
+			logger.CaptureError(err, "Error clearing failed logins", map[string]interface{}{
+				"username": username,
+			})
+		}
+	}
+}
+
+// Unlock implements LoginThrottleService.
+func (service *loginThrottleServiceImpl) Unlock(username string) error {
+	ctx := context.Background()
+	username = normalizeLoginUsername(username)
+
+	for _, key := range []string{loginFailuresKey("user", username), loginDelayKey(username), loginLockKey("user", username)} {
+		if err := service.cacheRepository.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Account unlocked", map[string]interface{}{
+		"username": username,
+	})
+
+	return nil
+}
+
+// UnlockIP implements LoginThrottleService.
+func (service *loginThrottleServiceImpl) UnlockIP(ip string) error {
+	ctx := context.Background()
+
+	for _, key := range []string{loginFailuresKey("ip", ip), loginLockKey("ip", ip)} {
+		if err := service.cacheRepository.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("IP unlocked", map[string]interface{}{
+		"ip": ip,
+	})
+
+	return nil
+}
+
+// delayFor returns how long a username has to wait after the given number of failures:
+// one second at DelayAfter failures, doubled for each further failure, never more than the lockout.
+func (service *loginThrottleServiceImpl) delayFor(failures int64) time.Duration {
+	delay := time.Second
+	for i := int64(service.config.DelayAfter); i < failures && delay < service.config.LockoutDuration; i++ {
+		delay *= 2
+	}
+
+	if delay > service.config.LockoutDuration {
+		return service.config.LockoutDuration
+	}
+	return delay
+}
+
+// block sets a key that expires after the given duration, errors are logged.
+func (service *loginThrottleServiceImpl) block(ctx context.Context, key string, duration time.Duration) {
+	if err := service.cacheRepository.Set(ctx, key, "true", duration); err != nil {
+		logger.CaptureError(err, "Error blocking logins", map[string]interface{}{
+			"key": key,
+		})
+	}
+}
+
+// blockedFor returns the time left before a blocking key expires, or 0 if it does not exist.
+// Errors are ignored, so the cache being unavailable does not prevent every user from logging in.
+func (service *loginThrottleServiceImpl) blockedFor(ctx context.Context, key string) time.Duration {
+	ttl, err := service.cacheRepository.TTL(ctx, key)
+	if err != nil || ttl <= 0 {
+		return 0
+	}
+
+	return ttl
+}
+
+// normalizeLoginUsername makes the counters of a username independent of how it is typed.
+func normalizeLoginUsername(username string) string {
+	return identity.NormalizeUsername(username)
+}
+
+func loginFailuresKey(kind string, value string) string {
+	return fmt.Sprintf("loginFailures:%s:%s", kind, value)
+}
+
+func loginLockKey(kind string, value string) string {
+	return fmt.Sprintf("loginLock:%s:%s", kind, value)
+}
+
+func loginDelayKey(username string) string {
+	return fmt.Sprintf("loginDelay:user:%s", username)
+}
+
+func NewLoginThrottleService(cacheRepository repository.RedisRepository, config LoginThrottleConfig) LoginThrottleService {
+	return &loginThrottleServiceImpl{cacheRepository: cacheRepository, config: config}
+}
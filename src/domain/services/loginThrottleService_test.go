@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+)
+
+// throttleCache keeps the failure windows and the blocking keys of the login throttle.
+// Keys never expire on their own, TTL returns the duration they were set for.
+type throttleCache struct {
+	repository.RedisRepository
+	windows map[string][]time.Time
+	blocked map[string]time.Duration
+}
+
+func newThrottleCache() *throttleCache {
+	return &throttleCache{windows: make(map[string][]time.Time), blocked: make(map[string]time.Duration)}
+}
+
+func (cache *throttleCache) AddToWindow(ctx context.Context, key string, at time.Time, window time.Duration) (int64, error) {
+	events := []time.Time{at}
+	for _, event := range cache.windows[key] {
+		if !event.Before(at.Add(-window)) {
+			events = append(events, event)
+		}
+	}
+	cache.windows[key] = events
+	return int64(len(events)), nil
+}
+
+func (cache *throttleCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	cache.blocked[key] = expiration
+	return nil
+}
+
+func (cache *throttleCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, ok := cache.blocked[key]
+	if !ok {
+		// what redis answers for a missing key
+		return -2 * time.Nanosecond, nil
+	}
+	return ttl, nil
+}
+
+func (cache *throttleCache) Delete(ctx context.Context, key string) error {
+	delete(cache.windows, key)
+	delete(cache.blocked, key)
+	return nil
+}
+
+var testThrottleConfig = LoginThrottleConfig{
+	Window:          15 * time.Minute,
+	DelayAfter:      3,
+	LockoutAfter:    6,
+	LockoutDuration: 30 * time.Minute,
+	IPLockoutAfter:  10,
+}
+
+// checkBlocked fails the test unless err is a LoginBlockedError wrapping want and asking to wait retryAfter.
+func checkBlocked(t *testing.T, err error, want error, retryAfter time.Duration) {
+	t.Helper()
+	var blocked *errorsUtils.LoginBlockedError
+	if !errors.As(err, &blocked) || !errors.Is(err, want) {
+		t.Fatalf("Check() = %v, want %v", err, want)
+	}
+	if blocked.RetryAfter != retryAfter {
+		t.Errorf("retry after %v, want %v", blocked.RetryAfter, retryAfter)
+	}
+}
+
+func TestLoginThrottleDelaysThenLocks(t *testing.T) {
+	service := NewLoginThrottleService(newThrottleCache(), testThrottleConfig)
+
+	for i := 1; i < testThrottleConfig.DelayAfter; i++ {
+		service.RegisterFailure("Dave", "198.51.100.4")
+	}
+	if err := service.Check("dave", "198.51.100.4"); err != nil {
+		t.Fatalf("Check() before the delay = %v", err)
+	}
+
+	// the delay starts at one second and doubles with each failure
+	service.RegisterFailure("dave", "198.51.100.4")
+	checkBlocked(t, service.Check("DAVE", ""), errorsUtils.ErrLoginThrottled, time.Second)
+	service.RegisterFailure("dave", "198.51.100.4")
+	checkBlocked(t, service.Check("dave", ""), errorsUtils.ErrLoginThrottled, 2*time.Second)
+
+	for i := testThrottleConfig.DelayAfter + 2; i <= testThrottleConfig.LockoutAfter; i++ {
+		service.RegisterFailure("dave", "198.51.100.4")
+	}
+	checkBlocked(t, service.Check("dave", "203.0.113.50"), errorsUtils.ErrAccountLocked, testThrottleConfig.LockoutDuration)
+
+	// a successful login does not lift the lockout, only an administrator does
+	service.RegisterSuccess("dave", "")
+	checkBlocked(t, service.Check("dave", ""), errorsUtils.ErrAccountLocked, testThrottleConfig.LockoutDuration)
+
+	if err := service.Unlock("Dave"); err != nil {
+		t.Fatalf("Unlock() = %v", err)
+	}
+	if err := service.Check("dave", ""); err != nil {
+		t.Errorf("Check() after Unlock = %v", err)
+	}
+}
+
+func TestLoginThrottleSuccessForgetsFailures(t *testing.T) {
+	service := NewLoginThrottleService(newThrottleCache(), testThrottleConfig)
+
+	for i := 1; i < testThrottleConfig.DelayAfter; i++ {
+		service.RegisterFailure("erin", "")
+	}
+	service.RegisterSuccess("erin", "")
+	service.RegisterFailure("erin", "")
+
+	if err := service.Check("erin", ""); err != nil {
+		t.Errorf("Check() = %v, the failures before the success were counted", err)
+	}
+}
+
+func TestLoginThrottleLocksIPAcrossUsernames(t *testing.T) {
+	service := NewLoginThrottleService(newThrottleCache(), testThrottleConfig)
+
+	// one attempt per username, none of them is delayed
+	for i := 0; i < testThrottleConfig.IPLockoutAfter; i++ {
+		service.RegisterFailure(string(rune('a'+i))+"-sprayed", "192.0.2.77")
+	}
+
+	checkBlocked(t, service.Check("frank", "192.0.2.77"), errorsUtils.ErrLoginThrottled, testThrottleConfig.LockoutDuration)
+	if err := service.Check("frank", "192.0.2.78"); err != nil {
+		t.Errorf("Check() from another IP = %v", err)
+	}
+
+	if err := service.UnlockIP("192.0.2.77"); err != nil {
+		t.Fatalf("UnlockIP() = %v", err)
+	}
+	if err := service.Check("frank", "192.0.2.77"); err != nil {
+		t.Errorf("Check() after UnlockIP = %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/security"
+	"github.com/Dialosoft/src/pkg/utils/totp"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MFAService defines a set of methods for managing the TOTP two-factor authentication of the users.
+type MFAService interface {
+
+	// GetStatus reports whether a user has two-factor authentication enabled, whether their role requires it
+	// and how many recovery codes they have left.
+	GetStatus(userID uuid.UUID) (response.MFAStatusResponse, error)
+
+	// Setup generates a new TOTP secret for a user, to be confirmed with Enable.
+	// Returns the secret and the otpauth:// URI to show as a QR code, or errorsUtils.ErrMFAAlreadyEnabled.
+	Setup(userID uuid.UUID) (response.MFASetupResponse, error)
+
+	// Enable turns on two-factor authentication once the user proves their authenticator works with a valid code.
+	// Returns the recovery codes of the user, which are shown only this time.
+	Enable(userID uuid.UUID, code string) ([]string, error)
+
+	// Disable turns off two-factor authentication, given a valid code.
+	// Returns errorsUtils.ErrMFARequiredByRole if the role of the user requires it.
+	Disable(userID uuid.UUID, code string) error
+
+	// RegenerateRecoveryCodes replaces the recovery codes of a user, given a valid code.
+	// Returns the new recovery codes.
+	RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error)
+
+	// VerifyCode checks a TOTP code or a recovery code of a user. Each code can only be used once.
+	// Returns errorsUtils.ErrMFACodeInvalid if the code is not valid.
+	VerifyCode(userID uuid.UUID, code string) error
+}
+
+const (
+	// mfaIssuer is the name authenticator apps show next to the account.
+	mfaIssuer = "Dialosoft"
+
+	// recoveryCodesCount is how many recovery codes a user gets.
+	recoveryCodesCount = 10
+)
+
+type mfaServiceImpl struct {
+	userRepository         repository.UserRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+	cacheService           CacheService
+	encryptionKey          []byte
+}
+
+// GetStatus implements MFAService.
+func (service *mfaServiceImpl) GetStatus(userID uuid.UUID) (response.MFAStatusResponse, error) {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return response.MFAStatusResponse{}, err
+	}
+
+	left, err := service.recoveryCodeRepository.CountUnusedByUserID(userID)
+	if err != nil {
+		return response.MFAStatusResponse{}, err
+	}
+
+	return response.MFAStatusResponse{
+		Enabled:           userEntity.TOTPEnabled,
+		RequiredByRole:    userEntity.Role.RequireMFA,
+		RecoveryCodesLeft: left,
+	}, nil
+}
+
+// Setup implements MFAService.
+func (service *mfaServiceImpl) Setup(userID uuid.UUID) (response.MFASetupResponse, error) {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return response.MFASetupResponse{}, err
+	}
+
+	if userEntity.TOTPEnabled {
+		return response.MFASetupResponse{}, errorsUtils.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return response.MFASetupResponse{}, err
+	}
+
+	encryptedSecret, err := security.Encrypt(secret, service.encryptionKey)
+	if err != nil {
+		return response.MFASetupResponse{}, err
+	}
+
+	if err := service.userRepository.SetTOTP(userID, encryptedSecret, false); err != nil {
+		return response.MFASetupResponse{}, err
+	}
+
+	return response.MFASetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(mfaIssuer, userEntity.Username, secret),
+	}, nil
+}
+
+// Enable implements MFAService.
+func (service *mfaServiceImpl) Enable(userID uuid.UUID, code string) ([]string, error) {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if userEntity.TOTPEnabled {
+		return nil, errorsUtils.ErrMFAAlreadyEnabled
+	}
+	if userEntity.TOTPSecret == "" {
+		return nil, errorsUtils.ErrMFASetupMissing
+	}
+
+	if err := service.verifyTOTP(userEntity, code); err != nil {
+		return nil, err
+	}
+
+	if err := service.userRepository.SetTOTP(userID, userEntity.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+
+	if err := service.cacheService.DeleteUserInfoByID(userID); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := service.replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Two-factor authentication enabled", map[string]interface{}{
+		"userID": userID,
+	})
+
+	return recoveryCodes, nil
+}
+
+// Disable implements MFAService.
+func (service *mfaServiceImpl) Disable(userID uuid.UUID, code string) error {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if userEntity.Role.RequireMFA {
+		return errorsUtils.ErrMFARequiredByRole
+	}
+
+	if err := service.verifyCode(userEntity, code); err != nil {
+		return err
+	}
+
+	if err := service.userRepository.SetTOTP(userID, "", false); err != nil {
+		return err
+	}
+
+	if err := service.recoveryCodeRepository.DeleteByUserID(userID); err != nil {
+		return err
+	}
+
+	if err := service.cacheService.DeleteUserInfoByID(userID); err != nil {
+		return err
+	}
+
+	logger.Info("Two-factor authentication disabled", map[string]interface{}{
+		"userID": userID,
+	})
+
+	return nil
+}
+
+// RegenerateRecoveryCodes implements MFAService.
+func (service *mfaServiceImpl) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.verifyCode(userEntity, code); err != nil {
+		return nil, err
+	}
+
+	return service.replaceRecoveryCodes(userID)
+}
+
+// VerifyCode implements MFAService.
+func (service *mfaServiceImpl) VerifyCode(userID uuid.UUID, code string) error {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return service.verifyCode(userEntity, code)
+}
+
+// verifyCode checks a TOTP code, or a recovery code if it does not look like one, of a user with
+// two-factor authentication enabled.
+func (service *mfaServiceImpl) verifyCode(userEntity *models.UserEntity, code string) error {
+	if !userEntity.TOTPEnabled {
+		return errorsUtils.ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return service.verifyTOTP(userEntity, code)
+	}
+
+	recoveryCode, err := service.recoveryCodeRepository.FindUnusedByHash(userEntity.ID, security.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrMFACodeInvalid
+		}
+		return err
+	}
+
+	if err := service.recoveryCodeRepository.Consume(recoveryCode.ID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrMFACodeInvalid
+		}
+		return err
+	}
+
+	left, _ := service.recoveryCodeRepository.CountUnusedByUserID(userEntity.ID)
+	logger.Warn("Recovery code used", map[string]interface{}{
+		"userID":            userEntity.ID,
+		"recoveryCodesLeft": left,
+	})
+
+	return nil
+}
+
+// verifyTOTP checks a TOTP code against the secret of a user and records its time step,
+// so the same code cannot be used again.
+func (service *mfaServiceImpl) verifyTOTP(userEntity *models.UserEntity, code string) error {
+	secret, err := security.Decrypt(userEntity.TOTPSecret, service.encryptionKey)
+	if err != nil {
+		return err
+	}
+
+	valid, counter, err := totp.Validate(secret, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errorsUtils.ErrMFACodeInvalid
+	}
+
+	if err := service.userRepository.UseTOTPCounter(userEntity.ID, counter); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errorsUtils.ErrMFACodeInvalid
+		}
+		return err
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes generates new recovery codes for a user, invalidating the previous ones.
+// Returns the codes in clear, to be shown to the user.
+func (service *mfaServiceImpl) replaceRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	entities := make([]models.RecoveryCodeEntity, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		entities = append(entities, models.RecoveryCodeEntity{
+			UserID:   userID,
+			CodeHash: security.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := service.recoveryCodeRepository.ReplaceForUser(userID, entities); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode returns a random code of the form xxxxx-xxxxx.
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode makes a recovery code typed by a user comparable to the generated one.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func NewMFAService(userRepository repository.UserRepository,
+	recoveryCodeRepository repository.RecoveryCodeRepository,
+	cacheService CacheService,
+	encryptionKey []byte) MFAService {
+	return &mfaServiceImpl{
+		userRepository:         userRepository,
+		recoveryCodeRepository: recoveryCodeRepository,
+		cacheService:           cacheService,
+		encryptionKey:          encryptionKey}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/security"
+	"github.com/Dialosoft/src/pkg/utils/totp"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// totpUser is a user with two-factor authentication enabled, and the only user of the repository.
+type totpUser struct {
+	repository.UserRepository
+	user models.UserEntity
+}
+
+func (repo *totpUser) FindByID(id uuid.UUID) (*models.UserEntity, error) {
+	if id != repo.user.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := repo.user
+	return &found, nil
+}
+
+// UseTOTPCounter only moves the counter forward, like the query of the real repository.
+func (repo *totpUser) UseTOTPCounter(userID uuid.UUID, counter int64) error {
+	if userID != repo.user.ID || repo.user.TOTPCounter >= counter {
+		return gorm.ErrRecordNotFound
+	}
+	repo.user.TOTPCounter = counter
+	return nil
+}
+
+// recoveryCodes holds the recovery codes of a user by hash, with the time they were used.
+type recoveryCodes struct {
+	repository.RecoveryCodeRepository
+	codes map[string]*models.RecoveryCodeEntity
+}
+
+func (repo *recoveryCodes) FindUnusedByHash(userID uuid.UUID, codeHash string) (*models.RecoveryCodeEntity, error) {
+	code, ok := repo.codes[codeHash]
+	if !ok || code.UserID != userID || code.UsedAt != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *code
+	return &found, nil
+}
+
+func (repo *recoveryCodes) Consume(recoveryCodeID uuid.UUID) error {
+	for _, code := range repo.codes {
+		if code.ID == recoveryCodeID && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (repo *recoveryCodes) CountUnusedByUserID(userID uuid.UUID) (int64, error) {
+	var count int64
+	for _, code := range repo.codes {
+		if code.UserID == userID && code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestVerifyCodeRejectsReplays(t *testing.T) {
+	key := security.DeriveKey("test-signing-key", "totp-secrets")
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() = %v", err)
+	}
+	encrypted, err := security.Encrypt(secret, key)
+	if err != nil {
+		t.Fatalf("Encrypt() = %v", err)
+	}
+
+	users := &totpUser{user: models.UserEntity{ID: uuid.New(), Username: "grace", TOTPEnabled: true, TOTPSecret: encrypted}}
+	userID := users.user.ID
+	codes := &recoveryCodes{codes: map[string]*models.RecoveryCodeEntity{
+		security.HashToken("k7p2qx9mfa"): {ID: uuid.New(), UserID: userID},
+	}}
+	service := NewMFAService(users, codes, nil, key)
+
+	codeAt := func(counter int64) string {
+		code, err := totp.GenerateCode(secret, counter)
+		if err != nil {
+			t.Fatalf("GenerateCode() = %v", err)
+		}
+		return code
+	}
+	current := totp.Counter(time.Now())
+
+	t.Run("current code", func(t *testing.T) {
+		if err := service.VerifyCode(userID, codeAt(current)); err != nil {
+			t.Fatalf("VerifyCode() = %v", err)
+		}
+	})
+	t.Run("same code again", func(t *testing.T) {
+		if err := service.VerifyCode(userID, codeAt(current)); err != errorsUtils.ErrMFACodeInvalid {
+			t.Errorf("VerifyCode() = %v, want ErrMFACodeInvalid", err)
+		}
+	})
+	t.Run("code of an earlier step", func(t *testing.T) {
+		// still within the skew, but older than the code already accepted
+		if err := service.VerifyCode(userID, codeAt(current-1)); err != errorsUtils.ErrMFACodeInvalid {
+			t.Errorf("VerifyCode() = %v, want ErrMFACodeInvalid", err)
+		}
+	})
+	t.Run("recovery code", func(t *testing.T) {
+		if err := service.VerifyCode(userID, " K7P2Q-X9MFA "); err != nil {
+			t.Fatalf("VerifyCode() = %v", err)
+		}
+		if err := service.VerifyCode(userID, "k7p2q-x9mfa"); err != errorsUtils.ErrMFACodeInvalid {
+			t.Errorf("VerifyCode() with a used recovery code = %v, want ErrMFACodeInvalid", err)
+		}
+	})
+}
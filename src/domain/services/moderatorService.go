@@ -0,0 +1,287 @@
+package services
+
+import (
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModeratorService defines the methods to manage the moderators of single forums and categories,
+// and to resolve the permissions of a user in a forum.
+type ModeratorService interface {
+
+	// GetForumModerators retrieves the moderators of a forum, including the moderators of its category.
+	// Returns gorm.ErrRecordNotFound if the forum does not exist.
+	GetForumModerators(forumID uuid.UUID) ([]response.ModeratorResponse, error)
+
+	// GetCategoryModerators retrieves the moderators of a category.
+	// Returns gorm.ErrRecordNotFound if the category does not exist.
+	GetCategoryModerators(categoryID uuid.UUID) ([]response.ModeratorResponse, error)
+
+	// AddForumModerator makes a user or role moderator of a forum.
+	// Returns gorm.ErrRecordNotFound if the forum does not exist, errorsUtils.ErrModeratorSubjectNotFound
+	// if the user or role does not exist, or errorsUtils.ErrModeratorAlreadyAssigned.
+	AddForumModerator(forumID uuid.UUID, req request.NewModerator) (response.ModeratorResponse, error)
+
+	// AddCategoryModerator makes a user or role moderator of every forum of a category.
+	// Returns the same errors as AddForumModerator.
+	AddCategoryModerator(categoryID uuid.UUID, req request.NewModerator) (response.ModeratorResponse, error)
+
+	// RemoveForumModerator removes a moderator assignment of a forum.
+	// Returns gorm.ErrRecordNotFound if the forum has no such assignment.
+	RemoveForumModerator(forumID uuid.UUID, moderatorID uuid.UUID) error
+
+	// RemoveCategoryModerator removes a moderator assignment of a category.
+	// Returns gorm.ErrRecordNotFound if the category has no such assignment.
+	RemoveCategoryModerator(categoryID uuid.UUID, moderatorID uuid.UUID) error
+
+	// GetModeratedForums retrieves the forums a user moderates, directly, through a category or through their role.
+	// Returns gorm.ErrRecordNotFound if the user does not exist.
+	GetModeratedForums(userID uuid.UUID) ([]response.ForumResponse, error)
+
+	// HasPermissionInForum reports whether a user has a permission in a forum, either granted by their role
+	// or, for the moderation permissions, by being a moderator of the forum or its category.
+	// The privileged permissions are never granted to an actor that cannot use them.
+	HasPermissionInForum(actor dto.Actor, forumID uuid.UUID, permission string) (bool, error)
+}
+
+type moderatorServiceImpl struct {
+	moderatorRepository repository.ModeratorRepository
+	forumRepository     repository.ForumRepository
+	categoryRepository  repository.CategoryRepository
+	userRepository      repository.UserRepository
+	roleRepository      repository.RoleRepository
+	roleService         RoleService
+}
+
+// GetForumModerators implements ModeratorService.
+func (service *moderatorServiceImpl) GetForumModerators(forumID uuid.UUID) ([]response.ModeratorResponse, error) {
+	forum, err := service.findForum(forumID)
+	if err != nil {
+		return nil, err
+	}
+
+	moderators, err := service.moderatorRepository.FindAllByForumID(forum.ID, forumCategoryID(forum))
+	if err != nil {
+		return nil, err
+	}
+
+	return moderatorsToResponses(moderators), nil
+}
+
+// GetCategoryModerators implements ModeratorService.
+func (service *moderatorServiceImpl) GetCategoryModerators(categoryID uuid.UUID) ([]response.ModeratorResponse, error) {
+	if _, err := service.categoryRepository.FindByID(categoryID); err != nil {
+		return nil, err
+	}
+
+	moderators, err := service.moderatorRepository.FindAllByCategoryID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return moderatorsToResponses(moderators), nil
+}
+
+// AddForumModerator implements ModeratorService.
+func (service *moderatorServiceImpl) AddForumModerator(forumID uuid.UUID, req request.NewModerator) (response.ModeratorResponse, error) {
+	if _, err := service.findForum(forumID); err != nil {
+		return response.ModeratorResponse{}, err
+	}
+
+	return service.add(models.ModeratorEntity{ForumID: &forumID}, req)
+}
+
+// AddCategoryModerator implements ModeratorService.
+func (service *moderatorServiceImpl) AddCategoryModerator(categoryID uuid.UUID, req request.NewModerator) (response.ModeratorResponse, error) {
+	if _, err := service.categoryRepository.FindByID(categoryID); err != nil {
+		return response.ModeratorResponse{}, err
+	}
+
+	return service.add(models.ModeratorEntity{CategoryID: &categoryID}, req)
+}
+
+// RemoveForumModerator implements ModeratorService.
+func (service *moderatorServiceImpl) RemoveForumModerator(forumID uuid.UUID, moderatorID uuid.UUID) error {
+	moderator, err := service.moderatorRepository.FindByID(moderatorID)
+	if err != nil {
+		return err
+	}
+	if moderator.ForumID == nil || *moderator.ForumID != forumID {
+		return gorm.ErrRecordNotFound
+	}
+
+	return service.moderatorRepository.Delete(moderatorID)
+}
+
+// RemoveCategoryModerator implements ModeratorService.
+func (service *moderatorServiceImpl) RemoveCategoryModerator(categoryID uuid.UUID, moderatorID uuid.UUID) error {
+	moderator, err := service.moderatorRepository.FindByID(moderatorID)
+	if err != nil {
+		return err
+	}
+	if moderator.CategoryID == nil || *moderator.CategoryID != categoryID {
+		return gorm.ErrRecordNotFound
+	}
+
+	return service.moderatorRepository.Delete(moderatorID)
+}
+
+// GetModeratedForums implements ModeratorService.
+func (service *moderatorServiceImpl) GetModeratedForums(userID uuid.UUID) ([]response.ForumResponse, error) {
+	user, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	moderators, err := service.moderatorRepository.FindAllBySubject(user.ID, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	forumsResponse := []response.ForumResponse{}
+	seen := make(map[uuid.UUID]bool)
+	addForum := func(forum *models.Forum) {
+		if forum != nil && !seen[forum.ID] {
+			seen[forum.ID] = true
+			forumsResponse = append(forumsResponse, mapper.ForumEntityToForumResponse(forum))
+		}
+	}
+
+	for _, moderator := range moderators {
+		if moderator.ForumID != nil {
+			forum, err := service.forumRepository.FindByID(*moderator.ForumID)
+			if err != nil {
+				return nil, err
+			}
+			addForum(forum)
+			continue
+		}
+
+		forums, err := service.forumRepository.FindAllByCategoryID(*moderator.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range forums {
+			addForum(&forums[i])
+		}
+	}
+
+	return forumsResponse, nil
+}
+
+// HasPermissionInForum implements ModeratorService.
+func (service *moderatorServiceImpl) HasPermissionInForum(actor dto.Actor, forumID uuid.UUID, permission string) (bool, error) {
+	if !actorCanUse(actor, permission) {
+		return false, nil
+	}
+
+	user, err := service.userRepository.FindByID(actor.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	granted, err := service.roleService.HasPermission(user.RoleID, permission)
+	if err != nil || granted || !permissions.GrantedToForumModerators(permission) {
+		return granted, err
+	}
+
+	forum, err := service.findForum(forumID)
+	if err != nil {
+		return false, err
+	}
+
+	return service.moderatorRepository.IsModerator(user.ID, user.RoleID, forum.ID, forumCategoryID(forum))
+}
+
+// add stores an assignment of the forum or category in moderator to the user or role of the request.
+func (service *moderatorServiceImpl) add(moderator models.ModeratorEntity, req request.NewModerator) (response.ModeratorResponse, error) {
+	var err error
+	if req.UserID != nil {
+		moderator.UserID = req.UserID
+		_, err = service.userRepository.FindByID(*req.UserID)
+	} else {
+		moderator.RoleID = req.RoleID
+		_, err = service.roleRepository.FindByID(*req.RoleID)
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return response.ModeratorResponse{}, errorsUtils.ErrModeratorSubjectNotFound
+		}
+		return response.ModeratorResponse{}, err
+	}
+
+	exists, err := service.moderatorRepository.Exists(moderator)
+	if err != nil {
+		return response.ModeratorResponse{}, err
+	}
+	if exists {
+		return response.ModeratorResponse{}, errorsUtils.ErrModeratorAlreadyAssigned
+	}
+
+	moderator.ID, err = service.moderatorRepository.Create(moderator)
+	if err != nil {
+		return response.ModeratorResponse{}, err
+	}
+
+	return mapper.ModeratorEntityToModeratorResponse(&moderator), nil
+}
+
+// findForum retrieves a forum, returning gorm.ErrRecordNotFound if it does not exist.
+func (service *moderatorServiceImpl) findForum(forumID uuid.UUID) (*models.Forum, error) {
+	forum, err := service.forumRepository.FindByID(forumID)
+	if err != nil {
+		return nil, err
+	}
+	if forum == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return forum, nil
+}
+
+// actorCanUse reports whether an actor may use a permission, which for the privileged permissions
+// the personal access tokens without the admin scope cannot.
+func actorCanUse(actor dto.Actor, permission string) bool {
+	if actor.Privileged {
+		return true
+	}
+	registered, ok := permissions.Get(permission)
+	return ok && !registered.Privileged
+}
+
+// forumCategoryID returns the category of a forum, uuid.Nil if it is not a valid UUID.
+func forumCategoryID(forum *models.Forum) uuid.UUID {
+	categoryID, _ := uuid.Parse(forum.CategoryID)
+	return categoryID
+}
+
+func moderatorsToResponses(moderators []models.ModeratorEntity) []response.ModeratorResponse {
+	moderatorsResponse := make([]response.ModeratorResponse, 0, len(moderators))
+	for i := range moderators {
+		moderatorsResponse = append(moderatorsResponse, mapper.ModeratorEntityToModeratorResponse(&moderators[i]))
+	}
+	return moderatorsResponse
+}
+
+func NewModeratorService(moderatorRepository repository.ModeratorRepository,
+	forumRepository repository.ForumRepository,
+	categoryRepository repository.CategoryRepository,
+	userRepository repository.UserRepository,
+	roleRepository repository.RoleRepository,
+	roleService RoleService) ModeratorService {
+	return &moderatorServiceImpl{
+		moderatorRepository: moderatorRepository,
+		forumRepository:     forumRepository,
+		categoryRepository:  categoryRepository,
+		userRepository:      userRepository,
+		roleRepository:      roleRepository,
+		roleService:         roleService}
+}
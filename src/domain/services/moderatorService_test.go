@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/google/uuid"
+)
+
+func TestActorCanUse(t *testing.T) {
+	tests := []struct {
+		name       string
+		privileged bool
+		permission string
+		want       bool
+	}{
+		{"session with privileged permission", true, permissions.PostLock, true},
+		{"session with own permission", true, permissions.CommentDeleteOwn, true},
+		{"limited token with own permission", false, permissions.CommentDeleteOwn, true},
+		{"limited token with privileged permission", false, permissions.CommentDeleteAny, false},
+		{"limited token with report review", false, permissions.ReportReview, false},
+		{"limited token with unknown permission", false, "unknown", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actor := dto.Actor{UserID: uuid.New(), Privileged: test.privileged}
+			if got := actorCanUse(actor, test.permission); got != test.want {
+				t.Errorf("actorCanUse(%q) = %v, want %v", test.permission, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/adapters/oauth"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/Dialosoft/src/pkg/utils/identity"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/security"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// OAuthService defines the methods to log in with external OAuth2 and OpenID Connect providers,
+// and to link the accounts users have at them.
+type OAuthService interface {
+
+	// Providers returns the names of the configured providers.
+	Providers() []string
+
+	// AuthorizationURL starts a login with a provider.
+	// Returns the URL of the provider to send the user to, or errorsUtils.ErrOAuthProviderUnknown.
+	AuthorizationURL(provider string) (string, error)
+
+	// LinkAuthorizationURL starts linking the account of a user at a provider.
+	// Returns the URL of the provider to send the user to, or errorsUtils.ErrOAuthProviderUnknown.
+	LinkAuthorizationURL(provider string, userID uuid.UUID) (string, error)
+
+	// Callback finishes a flow started by AuthorizationURL or LinkAuthorizationURL with the code and the state
+	// the provider sent back. A login logs in the user linked to the provider account, or creates one with the
+	// default role the first time; linked is true when the flow linked the provider account instead.
+	// Returns errorsUtils.ErrOAuthStateInvalid if the state was not issued for this provider, has expired or was used.
+	Callback(provider string, code string, state string, session dto.SessionDto) (result dto.LoginResultDto, linked bool, err error)
+
+	// GetIdentities retrieves the provider accounts linked to a user.
+	GetIdentities(userID uuid.UUID) ([]response.IdentityResponse, error)
+
+	// Unlink removes the link between a user and their account at a provider.
+	// Returns gorm.ErrRecordNotFound if the provider is not linked, or errorsUtils.ErrOAuthLastLoginMethod
+	// if the user has no password and no other provider to log in with.
+	Unlink(userID uuid.UUID, provider string) error
+}
+
+// oauthStateDuration is how long a user has to come back from the provider.
+const oauthStateDuration = 10 * time.Minute
+
+// oauthState is what is remembered of a flow between its start and the callback, keyed by its state.
+type oauthState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+
+	// LinkUserID is set when the flow links an account to this user instead of logging in.
+	LinkUserID string `json:"linkUserID,omitempty"`
+}
+
+type oauthServiceImpl struct {
+	providers          map[string]oauth.Provider
+	userRepository     repository.UserRepository
+	roleRepository     repository.RoleRepository
+	identityRepository repository.IdentityRepository
+	cacheRepository    repository.RedisRepository
+	authService        AuthService
+}
+
+// Providers implements OAuthService.
+func (service *oauthServiceImpl) Providers() []string {
+	names := make([]string, 0, len(service.providers))
+	for name := range service.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// AuthorizationURL implements OAuthService.
+func (service *oauthServiceImpl) AuthorizationURL(provider string) (string, error) {
+	return service.startFlow(provider, "")
+}
+
+// LinkAuthorizationURL implements OAuthService.
+func (service *oauthServiceImpl) LinkAuthorizationURL(provider string, userID uuid.UUID) (string, error) {
+	return service.startFlow(provider, userID.String())
+}
+
+// Callback implements OAuthService.
+func (service *oauthServiceImpl) Callback(provider string, code string, state string, session dto.SessionDto) (dto.LoginResultDto, bool, error) {
+	oauthProvider, ok := service.providers[provider]
+	if !ok {
+		return dto.LoginResultDto{}, false, errorsUtils.ErrOAuthProviderUnknown
+	}
+
+	// the state can only be used once
+	ctx := context.Background()
+	rawState, err := service.cacheRepository.GetDel(ctx, oauthStateKey(state))
+	if err != nil || state == "" {
+		return dto.LoginResultDto{}, false, errorsUtils.ErrOAuthStateInvalid
+	}
+
+	var flow oauthState
+	if err := json.Unmarshal([]byte(rawState), &flow); err != nil || flow.Provider != provider {
+		return dto.LoginResultDto{}, false, errorsUtils.ErrOAuthStateInvalid
+	}
+
+	externalIdentity, err := oauthProvider.Exchange(ctx, code, flow.Verifier)
+	if err != nil {
+		logger.Warn("Login with provider failed", map[string]interface{}{
+			"provider": provider,
+			"error":    err.Error(),
+		})
+		return dto.LoginResultDto{}, false, errorsUtils.ErrOAuthLoginFailed
+	}
+
+	if flow.LinkUserID != "" {
+		userID, err := uuid.Parse(flow.LinkUserID)
+		if err != nil {
+			return dto.LoginResultDto{}, false, errorsUtils.ErrOAuthStateInvalid
+		}
+		return dto.LoginResultDto{}, true, service.link(userID, provider, externalIdentity)
+	}
+
+	result, err := service.login(provider, externalIdentity, session)
+	return result, false, err
+}
+
+// GetIdentities implements OAuthService.
+func (service *oauthServiceImpl) GetIdentities(userID uuid.UUID) ([]response.IdentityResponse, error) {
+	identities, err := service.identityRepository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identitiesResponses := make([]response.IdentityResponse, 0, len(identities))
+	for i := range identities {
+		identitiesResponses = append(identitiesResponses, mapper.IdentityEntityToIdentityResponse(&identities[i]))
+	}
+
+	return identitiesResponses, nil
+}
+
+// Unlink implements OAuthService.
+func (service *oauthServiceImpl) Unlink(userID uuid.UUID, provider string) error {
+	userEntity, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	identities, err := service.identityRepository.FindAllByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	// users created by a provider have no password until they reset it
+	if userEntity.Password == "" && len(identities) <= 1 {
+		return errorsUtils.ErrOAuthLastLoginMethod
+	}
+
+	if err := service.identityRepository.Delete(userID, provider); err != nil {
+		return err
+	}
+
+	logger.Info("Login provider unlinked", map[string]interface{}{
+		"userID":   userID,
+		"provider": provider,
+	})
+
+	return nil
+}
+
+// startFlow remembers the state and PKCE verifier of a new flow with a provider.
+// Returns the URL of the provider to send the user to.
+func (service *oauthServiceImpl) startFlow(provider string, linkUserID string) (string, error) {
+	oauthProvider, ok := service.providers[provider]
+	if !ok {
+		return "", errorsUtils.ErrOAuthProviderUnknown
+	}
+
+	state, err := security.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	flow, err := json.Marshal(oauthState{
+		Provider:   provider,
+		Verifier:   verifier,
+		LinkUserID: linkUserID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	authorizationURL, err := oauthProvider.AuthCodeURL(state, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.cacheRepository.Set(context.Background(), oauthStateKey(state), string(flow), oauthStateDuration); err != nil {
+		return "", err
+	}
+
+	return authorizationURL, nil
+}
+
+// login logs in the user linked to a provider account, creating the user the first time.
+func (service *oauthServiceImpl) login(provider string, externalIdentity oauth.Identity, session dto.SessionDto) (dto.LoginResultDto, error) {
+	identityEntity, err := service.identityRepository.FindByProviderSubject(provider, externalIdentity.Subject)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return dto.LoginResultDto{}, err
+	}
+
+	var userEntity *models.UserEntity
+	if identityEntity != nil {
+		userEntity, err = service.userRepository.FindByID(identityEntity.UserID)
+		if err != nil {
+			return dto.LoginResultDto{}, err
+		}
+		if err := service.identityRepository.TouchLastLogin(identityEntity.ID); err != nil {
+			return dto.LoginResultDto{}, err
+		}
+	} else {
+		userEntity, err = service.provisionUser(provider, externalIdentity)
+		if err != nil {
+			return dto.LoginResultDto{}, err
+		}
+	}
+
+	logger.Info("Login with provider", map[string]interface{}{
+		"userID":   userEntity.ID,
+		"provider": provider,
+	})
+
+	return service.authService.LoginUser(userEntity, session)
+}
+
+// link links a provider account to a user.
+func (service *oauthServiceImpl) link(userID uuid.UUID, provider string, externalIdentity oauth.Identity) error {
+	identityEntity, err := service.identityRepository.FindByProviderSubject(provider, externalIdentity.Subject)
+	if err == nil {
+		if identityEntity.UserID == userID {
+			return nil
+		}
+		return errorsUtils.ErrOAuthIdentityLinked
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	identities, err := service.identityRepository.FindAllByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return errorsUtils.ErrOAuthProviderLinked
+		}
+	}
+
+	err = service.identityRepository.Create(models.IdentityEntity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  externalIdentity.Subject,
+		Email:    externalIdentity.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Login provider linked", map[string]interface{}{
+		"userID":   userID,
+		"provider": provider,
+	})
+
+	return nil
+}
+
+// provisionUser creates a user with the default role for a provider account signing in for the first time.
+// The user has no password, and their email address is verified since the provider vouches for it.
+// An existing user with the same email address is not linked automatically, they have to log in and link the provider.
+func (service *oauthServiceImpl) provisionUser(provider string, externalIdentity oauth.Identity) (*models.UserEntity, error) {
+	if externalIdentity.Email == "" || !externalIdentity.EmailVerified {
+		return nil, errorsUtils.ErrOAuthEmailMissing
+	}
+
+	if _, err := service.userRepository.FindByEmail(externalIdentity.Email); err == nil {
+		return nil, errorsUtils.ErrOAuthAccountExists
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	roleEntity, err := service.roleRepository.FindByType("user")
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := service.availableUsername(externalIdentity)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := service.userRepository.Create(models.UserEntity{
+		Username:      username,
+		Email:         externalIdentity.Email,
+		Name:          externalIdentity.Name,
+		RoleID:        roleEntity.ID,
+		EmailVerified: true,
+		Locale:        mails.DefaultLocale,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.identityRepository.Create(models.IdentityEntity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  externalIdentity.Subject,
+		Email:    externalIdentity.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("User created by login provider", map[string]interface{}{
+		"userID":   userID,
+		"provider": provider,
+	})
+
+	return service.userRepository.FindByID(userID)
+}
+
+var usernameInvalidCharacters = regexp.MustCompile(`[^\p{L}\p{N}._-]`)
+
+// availableUsername derives a free username from the handle or the email address of a provider account,
+// adding digits to it if it is taken or reserved.
+func (service *oauthServiceImpl) availableUsername(externalIdentity oauth.Identity) (string, error) {
+	base := externalIdentity.Username
+	if base == "" {
+		base = externalIdentity.Email[:max(strings.LastIndex(externalIdentity.Email, "@"), 0)]
+	}
+	base = usernameInvalidCharacters.ReplaceAllString(base, "")
+	if runes := []rune(base); len(runes) > 24 {
+		base = string(runes[:24])
+	}
+	if len([]rune(base)) < 3 {
+		base = "user"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 10; attempt++ {
+		if !identity.IsReservedUsername(candidate) {
+			_, err := service.userRepository.FindByUsername(candidate)
+			if err == gorm.ErrRecordNotFound {
+				return candidate, nil
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s%04d", base, suffix.Int64())
+	}
+
+	return "", fmt.Errorf("no username available for %s", base)
+}
+
+func oauthStateKey(state string) string {
+	return fmt.Sprintf("oauthState:%s", state)
+}
+
+func NewOAuthService(providers map[string]oauth.Provider,
+	userRepository repository.UserRepository,
+	roleRepository repository.RoleRepository,
+	identityRepository repository.IdentityRepository,
+	cacheRepository repository.RedisRepository,
+	authService AuthService) OAuthService {
+	return &oauthServiceImpl{
+		providers:          providers,
+		userRepository:     userRepository,
+		roleRepository:     roleRepository,
+		identityRepository: identityRepository,
+		cacheRepository:    cacheRepository,
+		authService:        authService}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/oauth"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// stubProvider answers every code with the identity registered for it.
+type stubProvider struct {
+	name       string
+	identities map[string]oauth.Identity
+	verifiers  []string
+}
+
+func (provider *stubProvider) Name() string {
+	return provider.name
+}
+
+func (provider *stubProvider) AuthCodeURL(state string, verifier string) (string, error) {
+	return "https://" + provider.name + ".test/authorize?state=" + url.QueryEscape(state), nil
+}
+
+func (provider *stubProvider) Exchange(ctx context.Context, code string, verifier string) (oauth.Identity, error) {
+	provider.verifiers = append(provider.verifiers, verifier)
+	identity, ok := provider.identities[code]
+	if !ok {
+		return oauth.Identity{}, errors.New("invalid_grant")
+	}
+	return identity, nil
+}
+
+// linkedIdentities keeps the provider accounts linked to the users.
+type linkedIdentities struct {
+	repository.IdentityRepository
+	identities []models.IdentityEntity
+}
+
+func (repo *linkedIdentities) Create(identity models.IdentityEntity) error {
+	identity.ID = uuid.New()
+	repo.identities = append(repo.identities, identity)
+	return nil
+}
+
+func (repo *linkedIdentities) FindByProviderSubject(provider string, subject string) (*models.IdentityEntity, error) {
+	for _, identity := range repo.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (repo *linkedIdentities) FindAllByUserID(userID uuid.UUID) ([]models.IdentityEntity, error) {
+	var identities []models.IdentityEntity
+	for _, identity := range repo.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (repo *linkedIdentities) TouchLastLogin(identityID uuid.UUID) error {
+	return nil
+}
+
+// loginRecorder opens a session for every user it is given.
+type loginRecorder struct {
+	AuthService
+	loggedIn []uuid.UUID
+}
+
+func (service *loginRecorder) LoginUser(userEntity *models.UserEntity, session dto.SessionDto) (dto.LoginResultDto, error) {
+	service.loggedIn = append(service.loggedIn, userEntity.ID)
+	return dto.LoginResultDto{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+type oauthFixture struct {
+	service    OAuthService
+	github     *stubProvider
+	identities *linkedIdentities
+	logins     *loginRecorder
+	henry      models.UserEntity
+	iris       models.UserEntity
+}
+
+func newOAuthFixture() oauthFixture {
+	f := oauthFixture{
+		github: &stubProvider{name: "github", identities: map[string]oauth.Identity{
+			"code-henry": {Subject: "1001", Email: "henry@dialosoft.test", EmailVerified: true, Username: "henry"},
+			"code-other": {Subject: "1002", Email: "henry.alt@dialosoft.test", EmailVerified: true},
+		}},
+		identities: &linkedIdentities{},
+		logins:     &loginRecorder{},
+		henry:      models.UserEntity{ID: uuid.New(), Username: "henry", Email: "henry@dialosoft.test", Password: "hash"},
+		iris:       models.UserEntity{ID: uuid.New(), Username: "iris", Email: "iris@dialosoft.test", Password: "hash"},
+	}
+	providers := map[string]oauth.Provider{
+		"github": f.github,
+		"gitlab": &stubProvider{name: "gitlab"},
+	}
+	f.service = NewOAuthService(providers, newFakeUserRepository(f.henry, f.iris), nil, f.identities,
+		newFakeCacheRepository(), f.logins)
+	return f
+}
+
+// stateOf returns the state of an authorization URL.
+func stateOf(t *testing.T, authorizationURL string) string {
+	t.Helper()
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL %q: %v", authorizationURL, err)
+	}
+	return parsed.Query().Get("state")
+}
+
+func TestOAuthCallbackState(t *testing.T) {
+	f := newOAuthFixture()
+
+	if _, _, err := f.service.Callback("github", "code-henry", "never-issued", dto.SessionDto{}); err != errorsUtils.ErrOAuthStateInvalid {
+		t.Errorf("Callback() with an unknown state = %v, want ErrOAuthStateInvalid", err)
+	}
+
+	authorizationURL, err := f.service.LinkAuthorizationURL("github", f.henry.ID)
+	if err != nil {
+		t.Fatalf("LinkAuthorizationURL() = %v", err)
+	}
+	state := stateOf(t, authorizationURL)
+
+	// a state is bound to its provider, and is spent by the attempt to use it elsewhere
+	if _, _, err := f.service.Callback("gitlab", "code-henry", state, dto.SessionDto{}); err != errorsUtils.ErrOAuthStateInvalid {
+		t.Errorf("Callback() at another provider = %v, want ErrOAuthStateInvalid", err)
+	}
+	if _, _, err := f.service.Callback("github", "code-henry", state, dto.SessionDto{}); err != errorsUtils.ErrOAuthStateInvalid {
+		t.Errorf("Callback() with a spent state = %v, want ErrOAuthStateInvalid", err)
+	}
+	if len(f.github.verifiers) != 0 {
+		t.Error("a code was exchanged without a valid state")
+	}
+
+	state = stateOf(t, mustURL(t)(f.service.AuthorizationURL("github")))
+	if _, _, err := f.service.Callback("github", "code-unknown", state, dto.SessionDto{}); err != errorsUtils.ErrOAuthLoginFailed {
+		t.Errorf("Callback() with a code refused by the provider = %v, want ErrOAuthLoginFailed", err)
+	}
+	if len(f.github.verifiers) != 1 || f.github.verifiers[0] == "" {
+		t.Errorf("verifiers sent to the provider = %q, want the PKCE verifier of the flow", f.github.verifiers)
+	}
+}
+
+func TestOAuthLinkThenLogin(t *testing.T) {
+	f := newOAuthFixture()
+	link := func(userID uuid.UUID, code string) error {
+		t.Helper()
+		state := stateOf(t, mustURL(t)(f.service.LinkAuthorizationURL("github", userID)))
+		_, linked, err := f.service.Callback("github", code, state, dto.SessionDto{})
+		if err == nil && !linked {
+			t.Errorf("Callback() of a link flow logged in instead")
+		}
+		return err
+	}
+
+	if err := link(f.henry.ID, "code-henry"); err != nil {
+		t.Fatalf("linking the account of henry = %v", err)
+	}
+	if err := link(f.henry.ID, "code-henry"); err != nil {
+		t.Errorf("linking the same account again = %v, want nil", err)
+	}
+	if err := link(f.iris.ID, "code-henry"); err != errorsUtils.ErrOAuthIdentityLinked {
+		t.Errorf("linking the account of henry to iris = %v, want ErrOAuthIdentityLinked", err)
+	}
+	if err := link(f.henry.ID, "code-other"); err != errorsUtils.ErrOAuthProviderLinked {
+		t.Errorf("linking a second github account = %v, want ErrOAuthProviderLinked", err)
+	}
+	if len(f.identities.identities) != 1 {
+		t.Fatalf("%d identities linked, want 1", len(f.identities.identities))
+	}
+
+	state := stateOf(t, mustURL(t)(f.service.AuthorizationURL("github")))
+	result, linked, err := f.service.Callback("github", "code-henry", state, dto.SessionDto{})
+	if err != nil || linked {
+		t.Fatalf("Callback() of a login = %v, linked %v", err, linked)
+	}
+	if result.AccessToken == "" || len(f.logins.loggedIn) != 1 || f.logins.loggedIn[0] != f.henry.ID {
+		t.Errorf("logged in %v, want henry", f.logins.loggedIn)
+	}
+}
+
+// mustURL fails the test if starting a flow failed, and returns its authorization URL otherwise.
+func mustURL(t *testing.T) func(string, error) string {
+	return func(authorizationURL string, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("starting the flow = %v", err)
+		}
+		return authorizationURL
+	}
+}
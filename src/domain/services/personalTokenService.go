@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/Dialosoft/src/pkg/utils/security"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PersonalTokenService defines the methods to manage the personal access tokens bots and integrations
+// use instead of logging in.
+type PersonalTokenService interface {
+
+	// Create creates a token for a user, expiring after validFor, or never if validFor is zero.
+	// Returns the token, whose value cannot be retrieved again, errorsUtils.ErrPersonalTokenNameTaken
+	// or errorsUtils.ErrPersonalTokenLimit.
+	Create(userID uuid.UUID, name string, scopes []string, validFor time.Duration) (response.PersonalTokenCreatedResponse, error)
+
+	// GetAll retrieves the tokens of a user, without their values.
+	GetAll(userID uuid.UUID) ([]response.PersonalTokenResponse, error)
+
+	// Revoke deletes a token of a user, it stops working immediately.
+	// Returns gorm.ErrRecordNotFound if the user has no token with this ID.
+	Revoke(userID uuid.UUID, tokenID uuid.UUID) error
+
+	// Authenticate finds the token a request was made with and the user it acts as, recording that it was used.
+	// Returns errorsUtils.ErrPersonalTokenInvalid if the token does not exist or has expired.
+	// Bans are not checked, see BanService.CheckBan.
+	Authenticate(token string) (*models.PersonalTokenEntity, *models.UserEntity, error)
+}
+
+const (
+	// PersonalTokenPrefix starts the value of every personal access token, telling them apart from the JWTs
+	// and making them easy to find by secret scanners.
+	PersonalTokenPrefix = "dpat_"
+
+	// maxPersonalTokens is the number of tokens a user may have.
+	maxPersonalTokens = 50
+
+	// personalTokenTouchInterval is how often the last use of a token is written, not to write on every request.
+	personalTokenTouchInterval = time.Minute
+)
+
+type personalTokenServiceImpl struct {
+	personalTokenRepository repository.PersonalTokenRepository
+	userRepository          repository.UserRepository
+	cacheService            CacheService
+}
+
+// Create implements PersonalTokenService.
+func (service *personalTokenServiceImpl) Create(userID uuid.UUID, name string, scopes []string, validFor time.Duration) (response.PersonalTokenCreatedResponse, error) {
+	count, err := service.personalTokenRepository.CountByUserID(userID)
+	if err != nil {
+		return response.PersonalTokenCreatedResponse{}, err
+	}
+	if count >= maxPersonalTokens {
+		return response.PersonalTokenCreatedResponse{}, errorsUtils.ErrPersonalTokenLimit
+	}
+
+	secret, err := security.GenerateToken()
+	if err != nil {
+		return response.PersonalTokenCreatedResponse{}, err
+	}
+	token := PersonalTokenPrefix + secret
+
+	// kept in the order of models.PersonalTokenScopes, without duplicates
+	var tokenScopes []string
+	for _, scope := range models.PersonalTokenScopes {
+		if slices.Contains(scopes, scope) {
+			tokenScopes = append(tokenScopes, scope)
+		}
+	}
+
+	tokenEntity := models.PersonalTokenEntity{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    token[:len(PersonalTokenPrefix)+6],
+		TokenHash: security.HashToken(token),
+		Scopes:    strings.Join(tokenScopes, ","),
+		CreatedAt: time.Now(),
+	}
+	if validFor > 0 {
+		expiresAt := time.Now().Add(validFor)
+		tokenEntity.ExpiresAt = &expiresAt
+	}
+
+	tokenEntity.ID, err = service.personalTokenRepository.Create(tokenEntity)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return response.PersonalTokenCreatedResponse{}, errorsUtils.ErrPersonalTokenNameTaken
+		}
+		return response.PersonalTokenCreatedResponse{}, err
+	}
+
+	logger.Info("Personal access token created", map[string]interface{}{
+		"userID":  userID,
+		"tokenID": tokenEntity.ID,
+		"scopes":  tokenEntity.Scopes,
+	})
+
+	return response.PersonalTokenCreatedResponse{
+		PersonalTokenResponse: mapper.PersonalTokenEntityToPersonalTokenResponse(&tokenEntity),
+		Token:                 token,
+	}, nil
+}
+
+// GetAll implements PersonalTokenService.
+func (service *personalTokenServiceImpl) GetAll(userID uuid.UUID) ([]response.PersonalTokenResponse, error) {
+	tokens, err := service.personalTokenRepository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokensResponses := make([]response.PersonalTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		tokensResponses = append(tokensResponses, mapper.PersonalTokenEntityToPersonalTokenResponse(&tokens[i]))
+	}
+
+	return tokensResponses, nil
+}
+
+// Revoke implements PersonalTokenService.
+func (service *personalTokenServiceImpl) Revoke(userID uuid.UUID, tokenID uuid.UUID) error {
+	if err := service.personalTokenRepository.Delete(userID, tokenID); err != nil {
+		return err
+	}
+
+	logger.Info("Personal access token revoked", map[string]interface{}{
+		"userID":  userID,
+		"tokenID": tokenID,
+	})
+
+	return nil
+}
+
+// Authenticate implements PersonalTokenService.
+func (service *personalTokenServiceImpl) Authenticate(token string) (*models.PersonalTokenEntity, *models.UserEntity, error) {
+	if !strings.HasPrefix(token, PersonalTokenPrefix) {
+		return nil, nil, errorsUtils.ErrPersonalTokenInvalid
+	}
+
+	tokenEntity, err := service.personalTokenRepository.FindByHash(security.HashToken(token))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, errorsUtils.ErrPersonalTokenInvalid
+		}
+		return nil, nil, err
+	}
+	if tokenEntity.Expired() {
+		return nil, nil, errorsUtils.ErrPersonalTokenInvalid
+	}
+
+	userEntity, err := service.cacheService.GetUserInfoByID(tokenEntity.UserID)
+	if err != nil {
+		userEntity, err = service.userRepository.FindByID(tokenEntity.UserID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil, errorsUtils.ErrPersonalTokenInvalid
+			}
+			return nil, nil, err
+		}
+		if err := service.cacheService.SetUserInfoByID(tokenEntity.UserID, userEntity); err != nil {
+			return nil, nil, err
+		}
+	}
+	if userEntity.DeletedAt.Valid {
+		return nil, nil, errorsUtils.ErrPersonalTokenInvalid
+	}
+
+	now := time.Now()
+	if tokenEntity.LastUsedAt == nil || now.Sub(*tokenEntity.LastUsedAt) > personalTokenTouchInterval {
+		if err := service.personalTokenRepository.TouchLastUsed(tokenEntity.ID, now); err != nil {
+			return nil, nil, err
+		}
+		tokenEntity.LastUsedAt = &now
+	}
+
+	return tokenEntity, userEntity, nil
+}
+
+func NewPersonalTokenService(personalTokenRepository repository.PersonalTokenRepository,
+	userRepository repository.UserRepository,
+	cacheService CacheService) PersonalTokenService {
+	return &personalTokenServiceImpl{
+		personalTokenRepository: personalTokenRepository,
+		userRepository:          userRepository,
+		cacheService:            cacheService}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/google/uuid"
+)
+
+func TestMovePost(t *testing.T) {
+	moderator := dto.Actor{UserID: uuid.New(), Privileged: true}
+	from := models.Forum{ID: uuid.New(), Name: "from"}
+	to := models.Forum{ID: uuid.New(), Name: "to"}
+	unmoderated := models.Forum{ID: uuid.New(), Name: "unmoderated"}
+
+	pinnedAt := time.Now()
+	post := models.Post{ID: uuid.New(), UserID: uuid.New(), ForumID: from.ID, Title: "thread", PinnedAt: &pinnedAt}
+	stub := models.Post{ID: uuid.New(), UserID: post.UserID, ForumID: from.ID, RedirectToID: &post.ID}
+
+	tests := []struct {
+		name     string
+		postID   uuid.UUID
+		req      request.MovePost
+		want     error
+		wantStub bool
+	}{
+		{"leaving a redirect", post.ID, request.MovePost{ForumID: to.ID.String(), LeaveRedirect: true}, nil, true},
+		{"without redirect", post.ID, request.MovePost{ForumID: to.ID.String()}, nil, false},
+		{"same forum", post.ID, request.MovePost{ForumID: from.ID.String()}, errorsUtils.ErrPostSameForum, false},
+		{"missing forum", post.ID, request.MovePost{ForumID: uuid.NewString()}, errorsUtils.ErrPostForumNotFound, false},
+		{"forum not moderated", post.ID, request.MovePost{ForumID: unmoderated.ID.String()}, errorsUtils.ErrUserUnauthorized, false},
+		{"redirect stub", stub.ID, request.MovePost{ForumID: to.ID.String()}, errorsUtils.ErrPostIsRedirect, false},
+		{"invalid forum", post.ID, request.MovePost{ForumID: "forum"}, errorsUtils.ErrInvalidUUID, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postRepository := newFakePostRepository(post, stub)
+			service := NewPostService(postRepository, nil, nil, newFakeForumRepository(from, to, unmoderated),
+				&fakeModeratorService{forums: []uuid.UUID{from.ID, to.ID}})
+
+			err := service.MovePost(test.postID, moderator, test.req)
+			if err != test.want {
+				t.Fatalf("MovePost() = %v, want %v", err, test.want)
+			}
+
+			moved := postRepository.posts[post.ID]
+			if test.want != nil {
+				if len(postRepository.moved) != 0 || moved.ForumID != from.ID {
+					t.Error("the post was moved on error")
+				}
+				return
+			}
+
+			if moved.ForumID != to.ID {
+				t.Errorf("forum = %v, want %v", moved.ForumID, to.ID)
+			}
+			if moved.PinnedAt != nil {
+				t.Error("the moved post is still pinned")
+			}
+			if !test.wantStub {
+				if len(postRepository.stubs) != 0 {
+					t.Errorf("%d stubs left, want none", len(postRepository.stubs))
+				}
+				return
+			}
+			if len(postRepository.stubs) != 1 {
+				t.Fatalf("%d stubs left, want 1", len(postRepository.stubs))
+			}
+			left := postRepository.stubs[0]
+			if left.ForumID != from.ID || left.RedirectToID == nil || *left.RedirectToID != post.ID || !left.Locked {
+				t.Errorf("stub = %+v, want a locked redirect to %v in %v", left, post.ID, from.ID)
+			}
+		})
+	}
+}
+
+func TestMergePosts(t *testing.T) {
+	moderator := dto.Actor{UserID: uuid.New(), Privileged: true}
+	forum := models.Forum{ID: uuid.New(), Name: "forum"}
+	unmoderated := models.Forum{ID: uuid.New(), Name: "unmoderated"}
+
+	source := models.Post{ID: uuid.New(), UserID: uuid.New(), ForumID: forum.ID}
+	target := models.Post{ID: uuid.New(), UserID: uuid.New(), ForumID: forum.ID}
+	elsewhere := models.Post{ID: uuid.New(), UserID: uuid.New(), ForumID: unmoderated.ID}
+	stub := models.Post{ID: uuid.New(), UserID: uuid.New(), ForumID: forum.ID, RedirectToID: &target.ID}
+
+	tests := []struct {
+		name     string
+		targetID string
+		want     error
+	}{
+		{"merged", target.ID.String(), nil},
+		{"into itself", source.ID.String(), errorsUtils.ErrPostMergeSelf},
+		{"into a redirect stub", stub.ID.String(), errorsUtils.ErrPostIsRedirect},
+		{"into a forum not moderated", elsewhere.ID.String(), errorsUtils.ErrUserUnauthorized},
+		{"invalid target", "target", errorsUtils.ErrInvalidUUID},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postRepository := newFakePostRepository(source, target, elsewhere, stub)
+			service := NewPostService(postRepository, nil, nil, newFakeForumRepository(forum, unmoderated),
+				&fakeModeratorService{forums: []uuid.UUID{forum.ID}})
+
+			err := service.MergePosts(source.ID, moderator, request.MergePosts{TargetID: test.targetID})
+			if err != test.want {
+				t.Fatalf("MergePosts() = %v, want %v", err, test.want)
+			}
+
+			mergedInto, merged := postRepository.merged[source.ID]
+			if test.want != nil {
+				if merged {
+					t.Error("the post was merged on error")
+				}
+				return
+			}
+			if mergedInto != target.ID {
+				t.Errorf("merged into %v, want %v", mergedInto, target.ID)
+			}
+
+			// the ID of the merged post now leads to the thread it was merged into
+			thread, err := service.GetPostByID(source.ID)
+			if err != nil {
+				t.Fatalf("GetPostByID() = %v", err)
+			}
+			if thread.ID != target.ID {
+				t.Errorf("GetPostByID() = %v, want %v", thread.ID, target.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
+	"github.com/Dialosoft/src/adapters/mapper"
+	"github.com/Dialosoft/src/adapters/repository"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/mails"
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReportService defines the methods for the users to report posts, comments and other users,
+// and for the moderators to review and resolve the reports.
+type ReportService interface {
+
+	// ReportPost reports a post on behalf of a user.
+	// Returns gorm.ErrRecordNotFound if the post does not exist, errorsUtils.ErrReportSelf
+	// or errorsUtils.ErrReportAlreadySent.
+	ReportPost(postID uuid.UUID, reporterID uuid.UUID, req request.NewReport) error
+
+	// ReportComment reports a comment on behalf of a user.
+	// Returns the same errors as ReportPost.
+	ReportComment(commentID uuid.UUID, reporterID uuid.UUID, req request.NewReport) error
+
+	// ReportUser reports a user on behalf of another.
+	// Returns the same errors as ReportPost.
+	ReportUser(userID uuid.UUID, reporterID uuid.UUID, req request.NewReport) error
+
+	// GetQueue retrieves a page of the reports a moderator can review, filtered by status, target type
+	// and forum when they are not empty. The report.review permission gives access to every report,
+	// being moderator of a forum to the reports of the posts and comments of the forum.
+	// Returns errorsUtils.ErrUserUnauthorized if the moderator cannot review any report, or the forum asked for.
+	GetQueue(moderator dto.Actor, status string, targetType string, forumID *uuid.UUID, limit, offset int) (response.ReportQueueResponse, error)
+
+	// GetReport retrieves a report with the reports of every user.
+	// Returns gorm.ErrRecordNotFound if it does not exist or errorsUtils.ErrUserUnauthorized.
+	GetReport(moderator dto.Actor, reportID uuid.UUID) (response.ReportResponse, error)
+
+	// Resolve takes an action on an open report and records it. Deleting the content needs the
+	// post.delete.any or comment.delete.any permission in its forum, banning the user.ban permission.
+	// The report is claimed while the action is taken, and reopened if the action fails.
+	// Returns gorm.ErrRecordNotFound, errorsUtils.ErrUserUnauthorized, errorsUtils.ErrReportResolved,
+	// errorsUtils.ErrReportClaimed, errorsUtils.ErrReportActionInvalid, or the errors of BanService.Ban.
+	Resolve(moderator dto.Actor, reportID uuid.UUID, req request.ResolveReport) (response.ReportResponse, error)
+}
+
+// reportClaimDuration is how long a moderator resolving a report holds it, longer than any action takes.
+// A claim still held afterwards is left by a moderator whose request failed and can be taken over.
+const reportClaimDuration = 5 * time.Minute
+
+type reportServiceImpl struct {
+	reportRepository  repository.ReportRepository
+	postRepository    repository.PostRepository
+	commentRepository repository.CommentRepository
+	userRepository    repository.UserRepository
+	postService       PostService
+	commentService    CommentService
+	moderatorService  ModeratorService
+	banService        BanService
+	roleService       RoleService
+	emailService      EmailService
+}
+
+// ReportPost implements ReportService.
+func (service *reportServiceImpl) ReportPost(postID uuid.UUID, reporterID uuid.UUID, req request.NewReport) error {
+	post, err := service.postRepository.FindByID(postID)
+	if err != nil {
+		return err
+	}
+
+	return service.report(models.ReportEntity{
+		TargetType:   models.ReportTargetPost,
+		TargetID:     post.ID,
+		TargetUserID: post.UserID,
+		ForumID:      &post.ForumID,
+	}, reporterID, req)
+}
+
+// ReportComment implements ReportService.
+func (service *reportServiceImpl) ReportComment(commentID uuid.UUID, reporterID uuid.UUID, req request.NewReport) error {
+	comment, err := service.commentRepository.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	post, err := service.postRepository.FindByID(comment.PostID)
+	if err != nil {
+		return err
+	}
+
+	return service.report(models.ReportEntity{
+		TargetType:   models.ReportTargetComment,
+		TargetID:     comment.ID,
+		TargetUserID: comment.UserID,
+		ForumID:      &post.ForumID,
+	}, reporterID, req)
+}
+
+// ReportUser implements ReportService.
+func (service *reportServiceImpl) ReportUser(userID uuid.UUID, reporterID uuid.UUID, req request.NewReport) error {
+	user, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return service.report(models.ReportEntity{
+		TargetType:   models.ReportTargetUser,
+		TargetID:     user.ID,
+		TargetUserID: user.ID,
+	}, reporterID, req)
+}
+
+// GetQueue implements ReportService.
+func (service *reportServiceImpl) GetQueue(moderator dto.Actor, status string, targetType string, forumID *uuid.UUID, limit, offset int) (response.ReportQueueResponse, error) {
+	if !actorCanUse(moderator, permissions.ReportReview) {
+		return response.ReportQueueResponse{}, errorsUtils.ErrUserUnauthorized
+	}
+
+	filter := repository.ReportFilter{
+		Status:     status,
+		TargetType: targetType,
+		ForumID:    forumID,
+	}
+
+	reviewsAll, err := service.hasPermission(moderator, permissions.ReportReview)
+	if err != nil {
+		return response.ReportQueueResponse{}, err
+	}
+	if !reviewsAll {
+		forums, err := service.moderatorService.GetModeratedForums(moderator.UserID)
+		if err != nil {
+			return response.ReportQueueResponse{}, err
+		}
+
+		filter.ForumIDs = make([]uuid.UUID, 0, len(forums))
+		for _, forum := range forums {
+			filter.ForumIDs = append(filter.ForumIDs, forum.ID)
+		}
+		if len(filter.ForumIDs) == 0 || (forumID != nil && !slices.Contains(filter.ForumIDs, *forumID)) {
+			return response.ReportQueueResponse{}, errorsUtils.ErrUserUnauthorized
+		}
+	}
+
+	reports, total, err := service.reportRepository.FindAll(filter, limit, offset)
+	if err != nil {
+		return response.ReportQueueResponse{}, err
+	}
+
+	queue := response.ReportQueueResponse{
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		Reports: make([]response.ReportResponse, 0, len(reports)),
+	}
+	for i := range reports {
+		queue.Reports = append(queue.Reports, mapper.ReportEntityToReportResponse(&reports[i]))
+	}
+
+	return queue, nil
+}
+
+// GetReport implements ReportService.
+func (service *reportServiceImpl) GetReport(moderator dto.Actor, reportID uuid.UUID) (response.ReportResponse, error) {
+	report, err := service.reportRepository.FindByID(reportID)
+	if err != nil {
+		return response.ReportResponse{}, err
+	}
+
+	if err := service.checkPermission(moderator, report, permissions.ReportReview); err != nil {
+		return response.ReportResponse{}, err
+	}
+
+	return mapper.ReportEntityToReportResponse(report), nil
+}
+
+// Resolve implements ReportService.
+func (service *reportServiceImpl) Resolve(moderator dto.Actor, reportID uuid.UUID, req request.ResolveReport) (response.ReportResponse, error) {
+	report, err := service.reportRepository.FindByID(reportID)
+	if err != nil {
+		return response.ReportResponse{}, err
+	}
+	if report.Status == models.ReportStatusResolved {
+		return response.ReportResponse{}, errorsUtils.ErrReportResolved
+	}
+
+	if err := service.checkPermission(moderator, report, permissions.ReportReview); err != nil {
+		return response.ReportResponse{}, err
+	}
+
+	// the report is claimed before acting on it, so that two moderators cannot both act on it
+	if err := service.reportRepository.Claim(report.ID, moderator.UserID, time.Now(), reportClaimDuration); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return response.ReportResponse{}, errorsUtils.ErrReportClaimed
+		}
+		return response.ReportResponse{}, err
+	}
+
+	if err := service.takeAction(moderator, report, req); err != nil {
+		if releaseErr := service.reportRepository.ReleaseClaim(report.ID, moderator.UserID); releaseErr != nil {
+			logger.CaptureError(releaseErr, "Error releasing the claim of a report", map[string]interface{}{
+				"reportID":    report.ID,
+				"moderatorID": moderator.UserID,
+			})
+		}
+		return response.ReportResponse{}, err
+	}
+
+	if err := service.reportRepository.Resolve(report.ID, req.Action, req.Note, moderator.UserID, time.Now()); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return response.ReportResponse{}, errorsUtils.ErrReportClaimed
+		}
+		return response.ReportResponse{}, err
+	}
+
+	logger.Info("Report resolved", map[string]interface{}{
+		"reportID":    report.ID,
+		"targetType":  report.TargetType,
+		"targetID":    report.TargetID,
+		"action":      req.Action,
+		"moderatorID": moderator.UserID,
+	})
+
+	report, err = service.reportRepository.FindByID(report.ID)
+	if err != nil {
+		return response.ReportResponse{}, err
+	}
+
+	return mapper.ReportEntityToReportResponse(report), nil
+}
+
+// report adds the report of a user to the queue entry of its target.
+func (service *reportServiceImpl) report(report models.ReportEntity, reporterID uuid.UUID, req request.NewReport) error {
+	if report.TargetUserID == reporterID {
+		return errorsUtils.ErrReportSelf
+	}
+
+	reportID, err := service.reportRepository.AddEntry(report, models.ReportEntryEntity{
+		ReporterID: reporterID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return errorsUtils.ErrReportAlreadySent
+		}
+		return err
+	}
+
+	logger.Info("Report sent", map[string]interface{}{
+		"reportID":   reportID,
+		"targetType": report.TargetType,
+		"targetID":   report.TargetID,
+		"reporterID": reporterID,
+		"reason":     req.Reason,
+	})
+
+	return nil
+}
+
+// checkPermission returns errorsUtils.ErrUserUnauthorized unless the moderator has a permission in the forum
+// of the report, or everywhere for the reports of users.
+func (service *reportServiceImpl) checkPermission(moderator dto.Actor, report *models.ReportEntity, permission string) error {
+	var allowed bool
+	var err error
+	if report.ForumID != nil {
+		allowed, err = service.moderatorService.HasPermissionInForum(moderator, *report.ForumID, permission)
+	} else {
+		allowed, err = service.hasPermission(moderator, permission)
+	}
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errorsUtils.ErrUserUnauthorized
+	}
+
+	return nil
+}
+
+// hasPermission reports whether the role of the actor grants a permission it can use.
+func (service *reportServiceImpl) hasPermission(actor dto.Actor, permission string) (bool, error) {
+	if !actorCanUse(actor, permission) {
+		return false, nil
+	}
+
+	user, err := service.userRepository.FindByID(actor.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	return service.roleService.HasPermission(user.RoleID, permission)
+}
+
+// takeAction takes the action of the resolution of a report.
+func (service *reportServiceImpl) takeAction(moderator dto.Actor, report *models.ReportEntity, req request.ResolveReport) error {
+	switch req.Action {
+	case models.ReportActionDelete:
+		return service.deleteTarget(moderator, report)
+	case models.ReportActionWarn:
+		return service.warn(report.TargetUserID, req.Note)
+	case models.ReportActionBan:
+		return service.ban(moderator, report.TargetUserID, req)
+	}
+
+	return nil
+}
+
+// deleteTarget deletes the reported post or comment, content already deleted is left as it is.
+func (service *reportServiceImpl) deleteTarget(moderator dto.Actor, report *models.ReportEntity) error {
+	var err error
+	switch report.TargetType {
+	case models.ReportTargetPost:
+		if err := service.checkPermission(moderator, report, permissions.PostDeleteAny); err != nil {
+			return err
+		}
+		err = service.postService.DeletePost(report.TargetID)
+	case models.ReportTargetComment:
+		err = service.commentService.DeleteComment(report.TargetID, moderator)
+	default:
+		return errorsUtils.ErrReportActionInvalid
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// warn emails a warning to a user.
+func (service *reportServiceImpl) warn(userID uuid.UUID, reason string) error {
+	user, err := service.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = service.emailService.Send(user.Email, user.Locale, mails.WarningData{
+		Username: user.Username,
+		Reason:   reason,
+	})
+	if err != nil {
+		logger.CaptureError(err, "Error queueing email", map[string]interface{}{
+			"userID":   userID,
+			"template": mails.TemplateWarning,
+		})
+	}
+
+	return nil
+}
+
+// ban bans a user, which needs the user.ban permission whatever forums the moderator moderates.
+func (service *reportServiceImpl) ban(moderator dto.Actor, userID uuid.UUID, req request.ResolveReport) error {
+	canBan, err := service.hasPermission(moderator, permissions.UserBan)
+	if err != nil {
+		return err
+	}
+	if !canBan {
+		return errorsUtils.ErrUserUnauthorized
+	}
+
+	_, err = service.banService.Ban(userID, moderator.UserID, req.Note, time.Duration(req.BanDurationHours)*time.Hour)
+	return err
+}
+
+func NewReportService(reportRepository repository.ReportRepository,
+	postRepository repository.PostRepository,
+	commentRepository repository.CommentRepository,
+	userRepository repository.UserRepository,
+	postService PostService,
+	commentService CommentService,
+	moderatorService ModeratorService,
+	banService BanService,
+	roleService RoleService,
+	emailService EmailService) ReportService {
+	return &reportServiceImpl{
+		reportRepository:  reportRepository,
+		postRepository:    postRepository,
+		commentRepository: commentRepository,
+		userRepository:    userRepository,
+		postService:       postService,
+		commentService:    commentService,
+		moderatorService:  moderatorService,
+		banService:        banService,
+		roleService:       roleService,
+		emailService:      emailService}
+}
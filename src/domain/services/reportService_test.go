@@ -0,0 +1,169 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dialosoft/src/adapters/dto"
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/google/uuid"
+)
+
+func TestReportUser(t *testing.T) {
+	target := models.UserEntity{ID: uuid.New(), Username: "target", Email: "target@example.com"}
+	alice := models.UserEntity{ID: uuid.New(), Username: "alice", Email: "alice@example.com"}
+	bob := models.UserEntity{ID: uuid.New(), Username: "bob", Email: "bob@example.com"}
+
+	reportRepository := newFakeReportRepository()
+	service := NewReportService(reportRepository, nil, nil, newFakeUserRepository(target, alice, bob),
+		nil, nil, nil, nil, nil, nil)
+	req := request.NewReport{Reason: "spam"}
+
+	if err := service.ReportUser(target.ID, alice.ID, req); err != nil {
+		t.Fatalf("first report: %v", err)
+	}
+	if err := service.ReportUser(target.ID, alice.ID, req); err != errorsUtils.ErrReportAlreadySent {
+		t.Fatalf("same reporter again = %v, want ErrReportAlreadySent", err)
+	}
+	if err := service.ReportUser(target.ID, bob.ID, req); err != nil {
+		t.Fatalf("second reporter: %v", err)
+	}
+	if err := service.ReportUser(target.ID, target.ID, req); err != errorsUtils.ErrReportSelf {
+		t.Fatalf("self report = %v, want ErrReportSelf", err)
+	}
+
+	if len(reportRepository.reports) != 1 {
+		t.Fatalf("%d reports, want the reports merged into 1", len(reportRepository.reports))
+	}
+	for _, report := range reportRepository.reports {
+		if report.ReportCount != 2 {
+			t.Errorf("report count = %d, want 2", report.ReportCount)
+		}
+	}
+}
+
+// newReportedUser sets up a report of target by reporter and returns the service and the ID of the report.
+func newReportedUser(t *testing.T, users *fakeUserRepository, target, reporter uuid.UUID) (ReportService, *fakeReportRepository, *fakeEmailService, uuid.UUID) {
+	t.Helper()
+	reportRepository := newFakeReportRepository()
+	emailService := &fakeEmailService{}
+	service := NewReportService(reportRepository, nil, nil, users,
+		nil, nil, nil, nil, &fakeRoleService{granted: []string{permissions.ReportReview}}, emailService)
+
+	if err := service.ReportUser(target, reporter, request.NewReport{Reason: "spam"}); err != nil {
+		t.Fatalf("ReportUser() = %v", err)
+	}
+	for id := range reportRepository.reports {
+		return service, reportRepository, emailService, id
+	}
+	t.Fatal("no report was created")
+	return nil, nil, nil, uuid.Nil
+}
+
+func TestResolveReport(t *testing.T) {
+	moderatorID, targetID, reporterID := uuid.New(), uuid.New(), uuid.New()
+	users := newFakeUserRepository(
+		models.UserEntity{ID: moderatorID, Username: "moderator", Email: "moderator@dialosoft.test"},
+		models.UserEntity{ID: targetID, Username: "target", Email: "target@dialosoft.test"},
+		models.UserEntity{ID: reporterID, Username: "reporter", Email: "reporter@dialosoft.test"},
+	)
+	moderator := dto.Actor{UserID: moderatorID, Privileged: true}
+	other := uuid.New()
+
+	tests := []struct {
+		name       string
+		actor      dto.Actor
+		action     string
+		claimedAgo time.Duration // claimed by another moderator that long ago, if not zero
+		want       error
+		wantStatus string
+		wantEmails int
+	}{
+		{"warn", moderator, models.ReportActionWarn, 0, nil, models.ReportStatusResolved, 1},
+		{"claimed by another moderator", moderator, models.ReportActionWarn, time.Second, errorsUtils.ErrReportClaimed, models.ReportStatusClaimed, 0},
+		{"stale claim taken over", moderator, models.ReportActionWarn, reportClaimDuration + time.Minute, nil, models.ReportStatusResolved, 1},
+		{"refused action releases the claim", moderator, models.ReportActionBan, 0, errorsUtils.ErrUserUnauthorized, models.ReportStatusOpen, 0},
+		{"invalid action releases the claim", moderator, models.ReportActionDelete, 0, errorsUtils.ErrReportActionInvalid, models.ReportStatusOpen, 0},
+		{"token without the admin scope", dto.Actor{UserID: moderatorID}, models.ReportActionWarn, 0, errorsUtils.ErrUserUnauthorized, models.ReportStatusOpen, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, reportRepository, emailService, reportID := newReportedUser(t, users, targetID, reporterID)
+			if test.claimedAgo != 0 {
+				claimedAt := time.Now().Add(-test.claimedAgo)
+				if err := reportRepository.Claim(reportID, other, claimedAt, reportClaimDuration); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := service.Resolve(test.actor, reportID, request.ResolveReport{Action: test.action, Note: "be nice"})
+			if err != test.want {
+				t.Fatalf("Resolve() = %v, want %v", err, test.want)
+			}
+
+			report := reportRepository.reports[reportID]
+			if report.Status != test.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, test.wantStatus)
+			}
+			if test.wantStatus == models.ReportStatusResolved && *report.ResolvedBy != moderatorID {
+				t.Errorf("resolved by %v, want %v", *report.ResolvedBy, moderatorID)
+			}
+			if len(emailService.sent) != test.wantEmails {
+				t.Errorf("%d warnings sent, want %d", len(emailService.sent), test.wantEmails)
+			}
+		})
+	}
+}
+
+func TestResolveReportConcurrently(t *testing.T) {
+	targetID, reporterID := uuid.New(), uuid.New()
+	users := newFakeUserRepository(
+		models.UserEntity{ID: targetID, Username: "target", Email: "target@dialosoft.test"},
+		models.UserEntity{ID: reporterID, Username: "reporter", Email: "reporter@dialosoft.test"},
+	)
+	moderators := make([]uuid.UUID, 8)
+	for i := range moderators {
+		moderators[i] = uuid.New()
+		users.users[moderators[i]] = &models.UserEntity{ID: moderators[i]}
+	}
+	service, reportRepository, emailService, reportID := newReportedUser(t, users, targetID, reporterID)
+
+	errs := make(chan error, len(moderators))
+	var wg sync.WaitGroup
+	for _, moderatorID := range moderators {
+		wg.Add(1)
+		go func(moderatorID uuid.UUID) {
+			defer wg.Done()
+			_, err := service.Resolve(dto.Actor{UserID: moderatorID, Privileged: true}, reportID,
+				request.ResolveReport{Action: models.ReportActionWarn})
+			errs <- err
+		}(moderatorID)
+	}
+	wg.Wait()
+	close(errs)
+
+	resolved := 0
+	for err := range errs {
+		switch err {
+		case nil:
+			resolved++
+		case errorsUtils.ErrReportClaimed, errorsUtils.ErrReportResolved:
+		default:
+			t.Errorf("Resolve() = %v", err)
+		}
+	}
+	if resolved != 1 {
+		t.Errorf("%d moderators resolved the report, want 1", resolved)
+	}
+	if len(emailService.sent) != 1 {
+		t.Errorf("%d warnings sent, want 1", len(emailService.sent))
+	}
+	if status := reportRepository.reports[reportID].Status; status != models.ReportStatusResolved {
+		t.Errorf("status = %q, want resolved", status)
+	}
+}
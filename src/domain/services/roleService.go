@@ -2,12 +2,14 @@ package services
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/Dialosoft/src/adapters/dto"
 	"github.com/Dialosoft/src/adapters/http/request"
 	"github.com/Dialosoft/src/adapters/mapper"
 	"github.com/Dialosoft/src/adapters/repository"
-	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/domain/permissions"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
 	"github.com/google/uuid"
 )
 
@@ -28,9 +30,15 @@ type RoleService interface {
 	// Returns a pointer to RoleDto if found, or an error otherwise.
 	GetRoleByType(roleType string) (*dto.RoleDto, error)
 
-	// GetRolePermissionsByRoleID retrieves a role permission by its unique identifier (UUID).
-	// Returns a pointer to RolePermissions if found, or an error otherwise.
-	GetRolePermissionsByRoleID(roleID uuid.UUID) (*models.RolePermissions, error)
+	// GetRolePermissionsByRoleID retrieves the names of the permissions granted to a role.
+	// Returns gorm.ErrRecordNotFound if the role does not exist.
+	GetRolePermissionsByRoleID(roleID uuid.UUID) ([]string, error)
+
+	// GetAllPermissions retrieves the permissions of the registry roles can grant.
+	GetAllPermissions() []permissions.Permission
+
+	// HasPermission reports whether a role grants a permission of the registry.
+	HasPermission(roleID uuid.UUID, permission string) (bool, error)
 
 	// CreateNewRole creates a new role based on the provided RoleDto.
 	// Returns the UUID of the created role and an error if the creation fails.
@@ -40,9 +48,14 @@ type RoleService interface {
 	// Returns an error if the update fails.
 	UpdateRole(roleID uuid.UUID, req request.NewRole) error
 
-	// SetRolePermissionsByRoleID sets the permissions of a role identified by its UUID.
-	// Returns an error if the update fails.
-	SetRolePermissionsByRoleID(roleID uuid.UUID, req request.NewRolePermissions) error
+	// SetRolePermissionsByRoleID sets the permissions granted to a role, revoking the others.
+	// Returns gorm.ErrRecordNotFound if the role does not exist,
+	// or errorsUtils.ErrPermissionUnknown if a permission is not in the registry.
+	SetRolePermissionsByRoleID(roleID uuid.UUID, rolePermissions []string) error
+
+	// SetRoleRequireMFA sets whether the users of a role must use two-factor authentication to log in.
+	// Returns errorsUtils.ErrMFARoleNotPrivileged when requiring it for a role without AdminRole or ModRole.
+	SetRoleRequireMFA(roleID uuid.UUID, required bool) error
 
 	// DeleteRole marks a role as deleted by its UUID.
 	// Returns an error if the deletion fails.
@@ -122,20 +135,12 @@ func (service *roleServiceImpl) GetRoleByType(roleType string) (*dto.RoleDto, er
 func (service *roleServiceImpl) CreateNewRole(newRole dto.RoleDto) (uuid.UUID, error) {
 	roleEntity := mapper.RoleDtoToRoleEntity(&newRole)
 
-	rolePermissionEntity := models.RolePermissions{
-		RoleID:              roleEntity.ID,
-		CanManageCategories: newRole.AdminRole,
-		CanManageForums:     newRole.AdminRole,
-		CanManageRoles:      newRole.AdminRole,
-		CanManageUsers:      newRole.AdminRole,
-	}
-
 	roleUUID, err := service.roleRepository.Create(*roleEntity)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
 
-	_, err = service.rolePermissionsRepository.Save(rolePermissionEntity)
+	err = service.rolePermissionsRepository.Replace(roleUUID, permissions.Defaults(newRole.AdminRole, newRole.ModRole))
 	if err != nil {
 		return uuid.UUID{}, err
 	}
@@ -166,39 +171,56 @@ func (service *roleServiceImpl) UpdateRole(roleID uuid.UUID, req request.NewRole
 	return service.roleRepository.Update(roleID, *existingRole)
 }
 
-func (service *roleServiceImpl) SetRolePermissionsByRoleID(roleID uuid.UUID, req request.NewRolePermissions) error {
-	rolePermissionEntity, err := service.rolePermissionsRepository.FindByRoleID(roleID)
-	if err != nil {
+// SetRolePermissionsByRoleID implements RoleService.
+func (service *roleServiceImpl) SetRolePermissionsByRoleID(roleID uuid.UUID, rolePermissions []string) error {
+	if _, err := service.roleRepository.FindByID(roleID); err != nil {
 		return err
 	}
-	if req.CanManageCategories != nil {
-		rolePermissionEntity.CanManageCategories = *req.CanManageCategories
-	}
-	if req.CanManageForums != nil {
-		rolePermissionEntity.CanManageForums = *req.CanManageForums
-	}
-	if req.CanManageRoles != nil {
-		rolePermissionEntity.CanManageRoles = *req.CanManageRoles
-	}
-	if req.CanManageUsers != nil {
-		rolePermissionEntity.CanManageUsers = *req.CanManageUsers
+
+	var granted []string
+	for _, permission := range rolePermissions {
+		if !permissions.Exists(permission) {
+			return fmt.Errorf("%w: %s", errorsUtils.ErrPermissionUnknown, permission)
+		}
+		if !slices.Contains(granted, permission) {
+			granted = append(granted, permission)
+		}
 	}
 
-	_, err = service.rolePermissionsRepository.Save(*rolePermissionEntity)
-	if err != nil {
-		return err
+	return service.rolePermissionsRepository.Replace(roleID, granted)
+}
+
+// GetRolePermissionsByRoleID implements RoleService.
+func (service *roleServiceImpl) GetRolePermissionsByRoleID(roleID uuid.UUID) ([]string, error) {
+	if _, err := service.roleRepository.FindByID(roleID); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return service.rolePermissionsRepository.FindByRoleID(roleID)
+}
+
+// GetAllPermissions implements RoleService.
+func (service *roleServiceImpl) GetAllPermissions() []permissions.Permission {
+	return permissions.All()
+}
+
+// HasPermission implements RoleService.
+func (service *roleServiceImpl) HasPermission(roleID uuid.UUID, permission string) (bool, error) {
+	return service.rolePermissionsRepository.HasPermission(roleID, permission)
 }
 
-func (service *roleServiceImpl) GetRolePermissionsByRoleID(roleID uuid.UUID) (*models.RolePermissions, error) {
-	fmt.Println("entra a GetRolePermissionsByRoleID (service)")
-	rolePermission, err := service.rolePermissionsRepository.FindByRoleID(roleID)
+// SetRoleRequireMFA implements RoleService.
+func (service *roleServiceImpl) SetRoleRequireMFA(roleID uuid.UUID, required bool) error {
+	roleEntity, err := service.roleRepository.FindByID(roleID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return rolePermission, nil
+
+	if required && !roleEntity.AdminRole && !roleEntity.ModRole {
+		return errorsUtils.ErrMFARoleNotPrivileged
+	}
+
+	return service.roleRepository.SetRequireMFA(roleID, required)
 }
 
 // DeleteRole implements RoleService.
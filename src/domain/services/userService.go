@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -9,13 +10,18 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/Dialosoft/src/adapters/dto"
 	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/adapters/http/response"
 	"github.com/Dialosoft/src/adapters/mapper"
 	"github.com/Dialosoft/src/adapters/repository"
 	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/Dialosoft/src/pkg/utils/identity"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // UserService defines a set of methods for handling business logic related to users.
@@ -30,6 +36,10 @@ type UserService interface {
 	// Returns a pointer to UserDto if found, or an error otherwise.
 	GetUserByID(userID uuid.UUID) (*dto.UserDto, error)
 
+	// GetUserResponseByID retrieves a user with their role, as the administrators see them.
+	// Returns gorm.ErrRecordNotFound if the user does not exist or is deleted.
+	GetUserResponseByID(userID uuid.UUID) (response.UserResponse, error)
+
 	// GetUserByUsername retrieves a user by their username (string) as a DTO.
 	// Returns a pointer to UserDto if found, or an error otherwise.
 	GetUserByUsername(username string) (*dto.UserDto, error)
@@ -40,6 +50,8 @@ type UserService interface {
 
 	// UpdateUser modifies an existing user identified by their UUID based on the provided UserDto.
 	// Returns an error if the update fails.
+	// A new username is checked like on registration, returning errorsUtils.ErrUsernameInvalid,
+	// errorsUtils.ErrUsernameReserved or errorsUtils.ErrUsernameInUse.
 	UpdateUser(userID uuid.UUID, req request.NewUser) error
 
 	// DeleteUser marks a user as deleted by their UUID.
@@ -86,6 +98,16 @@ func (service *userServiceImpl) GetUserByID(userID uuid.UUID) (*dto.UserDto, err
 	return userDto, nil
 }
 
+// GetUserResponseByID implements UserService.
+func (service *userServiceImpl) GetUserResponseByID(userID uuid.UUID) (response.UserResponse, error) {
+	userEntity, err := service.repository.FindByID(userID)
+	if err != nil {
+		return response.UserResponse{}, err
+	}
+
+	return mapper.UserEntityToUserResponse(userEntity), nil
+}
+
 // GetUserByUsername implements UserService.
 func (service *userServiceImpl) GetUserByUsername(username string) (*dto.UserDto, error) {
 	userEntity, err := service.repository.FindByUsername(username)
@@ -127,12 +149,12 @@ func (service *userServiceImpl) UpdateUser(userID uuid.UUID, req request.NewUser
 		return err
 	}
 
-	if req.Username != nil && *req.Username != "" {
+	if req.Username != nil && *req.Username != "" && *req.Username != userEntity.Username {
+		if err := service.checkNewUsername(userID, *req.Username); err != nil {
+			return err
+		}
 		userEntity.Username = *req.Username
-	}
-
-	if req.Locked != nil {
-		userEntity.Banned = *req.Locked
+		userEntity.SetIdentityKeys()
 	}
 
 	if req.RoleID != nil {
@@ -151,12 +173,37 @@ func (service *userServiceImpl) UpdateUser(userID uuid.UUID, req request.NewUser
 	}
 
 	if err := service.repository.Update(userID, *userEntity); err != nil {
+		// another account took the username since it was checked
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return errorsUtils.ErrUsernameInUse
+		}
 		return err
 	}
 
 	return nil
 }
 
+// checkNewUsername applies the rules of the registration to a username userID changes to.
+func (service *userServiceImpl) checkNewUsername(userID uuid.UUID, username string) error {
+	if identity.IsEmail(username) {
+		return errorsUtils.ErrUsernameInvalid
+	}
+	if identity.IsReservedUsername(username) {
+		return errorsUtils.ErrUsernameReserved
+	}
+
+	// the lookup compares the normalised forms, "Alice" is taken if "alice" is
+	owner, err := service.repository.FindByUsername(username)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if owner != nil && owner.ID != userID {
+		return errorsUtils.ErrUsernameInUse
+	}
+
+	return nil
+}
+
 // DeleteUser implements UserService.
 func (service *userServiceImpl) DeleteUser(userID uuid.UUID) error {
 	return service.repository.Delete(userID)
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Dialosoft/src/adapters/http/request"
+	"github.com/Dialosoft/src/domain/models"
+	"github.com/Dialosoft/src/pkg/errorsUtils"
+	"github.com/google/uuid"
+)
+
+func TestUpdateUserUsername(t *testing.T) {
+	alice := models.UserEntity{ID: uuid.New(), Username: "Alice", Email: "alice@example.com"}
+	bob := models.UserEntity{ID: uuid.New(), Username: "bob", Email: "bob@example.com"}
+
+	tests := []struct {
+		name     string
+		username string
+		want     error
+	}{
+		{"new username", "carol", nil},
+		{"same account with another case", "ALICE", nil},
+		{"reserved", "Admin", errorsUtils.ErrUsernameReserved},
+		{"email address", "alice@example.org", errorsUtils.ErrUsernameInvalid},
+		{"taken", "bob", errorsUtils.ErrUsernameInUse},
+		{"taken once normalised", "ＢＯＢ", errorsUtils.ErrUsernameInUse},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userRepository := newFakeUserRepository(alice, bob)
+			service := NewUserService(userRepository, nil)
+
+			username := test.username
+			err := service.UpdateUser(alice.ID, request.NewUser{Username: &username})
+			if err != test.want {
+				t.Fatalf("UpdateUser() = %v, want %v", err, test.want)
+			}
+
+			stored := userRepository.users[alice.ID]
+			if test.want == nil && stored.Username != test.username {
+				t.Errorf("username = %q, want %q", stored.Username, test.username)
+			}
+			if test.want != nil && stored.Username != alice.Username {
+				t.Errorf("username changed to %q on error", stored.Username)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package errorsUtils
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrUserBanned is returned when a banned user logs in, refreshes their session or makes a request.
+	ErrUserBanned = errors.New("the account is banned")
+
+	// ErrBanSelf is returned when a moderator tries to ban themselves.
+	ErrBanSelf = errors.New("you cannot ban yourself")
+
+	// ErrBanNotAllowed is returned when banning a user whose role can ban users.
+	ErrBanNotAllowed = errors.New("users who can ban cannot be banned, change their role first")
+)
+
+// BannedError wraps ErrUserBanned with the reason of the ban and when it ends, nil if it does not.
+type BannedError struct {
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+func (e *BannedError) Error() string {
+	return ErrUserBanned.Error()
+}
+
+func (e *BannedError) Unwrap() error {
+	return ErrUserBanned
+}
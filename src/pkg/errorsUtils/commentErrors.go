@@ -0,0 +1,17 @@
+package errorsUtils
+
+import "errors"
+
+var (
+	// ErrCommentNotFound is returned when the requested comment cannot be found or has been deleted.
+	ErrCommentNotFound = errors.New("the comment you are looking for does not exist or has been deleted")
+
+	// ErrCommentParentMismatch is returned when a reply points to a comment that belongs to another post.
+	ErrCommentParentMismatch = errors.New("the parent comment does not belong to this post")
+
+	// ErrCommentParentDeleted is returned when trying to restore a reply whose parent comment is still deleted.
+	ErrCommentParentDeleted = errors.New("the parent comment has been deleted, restore it first")
+
+	// ErrCommentContentInvalid is returned when the content of a comment is empty.
+	ErrCommentContentInvalid = errors.New("the content of the comment cannot be empty")
+)
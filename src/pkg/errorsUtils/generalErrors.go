@@ -20,6 +20,39 @@ var (
 	// Used when the refresh token is either expired or has an invalid format.
 	ErrRefreshTokenExpiredOrInvalid = errors.New("refreshToken expired or invalid")
 
+	// ErrRefreshTokenReused is an error for a refresh token that was already rotated.
+	// Returned when a retired refresh token is presented again, the whole session is revoked.
+	ErrRefreshTokenReused = errors.New("refreshToken has already been used")
+
+	// ErrActionTokenInvalid is an error for a single-use token sent by email that cannot be used.
+	// Returned when the token does not exist, has expired or has already been used.
+	ErrActionTokenInvalid = errors.New("the token is invalid, expired or has already been used")
+
+	// ErrEmailAlreadyVerified is returned when asking to verify an email address that is already verified.
+	ErrEmailAlreadyVerified = errors.New("the email address is already verified")
+
+	// ErrEmailNotVerified is returned when an action requires a verified email address.
+	ErrEmailNotVerified = errors.New("you must verify your email address first")
+
+	// ErrEmailInUse is returned when changing the email address to one already used by another account.
+	ErrEmailInUse = errors.New("this email address is already used by another account")
+
+	// ErrEmailUnchanged is returned when changing the email address to the current one.
+	ErrEmailUnchanged = errors.New("the new email address is the same as the current one")
+
+	// ErrCurrentPasswordInvalid is returned when the current password given to confirm a sensitive change is not valid.
+	ErrCurrentPasswordInvalid = errors.New("the current password is not valid")
+
+	// ErrUsernameReserved is returned when registering a username that is reserved for the staff or the system.
+	ErrUsernameReserved = errors.New("this username is reserved")
+
+	// ErrUsernameInUse is returned when changing the username to one already used by another account,
+	// once normalised.
+	ErrUsernameInUse = errors.New("this username is already used by another account")
+
+	// ErrUsernameInvalid is returned when registering a username that could be mistaken for an email address.
+	ErrUsernameInvalid = errors.New("usernames cannot contain @")
+
 	// ErrRoleIDInRefreshToken is an error indicating the roleID is missing in the refresh token.
 	// Occurs when attempting to refresh and the refresh token does not contain a valid roleID.
 	ErrRoleIDInRefreshToken = errors.New("the roleID is empty in the refreshToken when trying to refresh")
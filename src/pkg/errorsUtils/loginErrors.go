@@ -0,0 +1,30 @@
+package errorsUtils
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrLoginThrottled is returned when too many failed logins were attempted recently
+	// and the client has to wait before trying again.
+	ErrLoginThrottled = errors.New("too many failed login attempts, try again later")
+
+	// ErrAccountLocked is returned when an account is temporarily locked after too many failed logins.
+	ErrAccountLocked = errors.New("the account is temporarily locked after too many failed login attempts")
+)
+
+// LoginBlockedError wraps ErrLoginThrottled or ErrAccountLocked with the time the client has to wait
+// before trying to log in again.
+type LoginBlockedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,30 @@
+package errorsUtils
+
+import "errors"
+
+var (
+	// ErrMFACodeInvalid is returned when a two-factor authentication or recovery code is wrong or was already used.
+	ErrMFACodeInvalid = errors.New("the authentication code is not valid")
+
+	// ErrMFANotEnabled is returned when an action requires two-factor authentication to be enabled.
+	ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+
+	// ErrMFAAlreadyEnabled is returned when setting up two-factor authentication for a user that already uses it.
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrMFASetupMissing is returned when enabling two-factor authentication before generating a secret.
+	ErrMFASetupMissing = errors.New("two-factor authentication has not been set up")
+
+	// ErrMFARequiredByRole is returned when disabling two-factor authentication for a user whose role requires it.
+	ErrMFARequiredByRole = errors.New("two-factor authentication is required for your role")
+
+	// ErrMFAEnrollmentRequired is returned when a user whose role requires two-factor authentication has not enabled it.
+	ErrMFAEnrollmentRequired = errors.New("you must enable two-factor authentication to continue")
+
+	// ErrMFATokenInvalid is returned when the token of a login pending a second factor is invalid or expired.
+	ErrMFATokenInvalid = errors.New("the login has expired, log in again")
+
+	// ErrMFARoleNotPrivileged is returned when requiring two-factor authentication for a role that is
+	// neither an administrator nor a moderator role.
+	ErrMFARoleNotPrivileged = errors.New("two-factor authentication can only be required for admin or moderator roles")
+)
@@ -0,0 +1,11 @@
+package errorsUtils
+
+import "errors"
+
+var (
+	// ErrModeratorAlreadyAssigned is returned when the user or role already moderates the forum or category.
+	ErrModeratorAlreadyAssigned = errors.New("already moderator of this forum or category")
+
+	// ErrModeratorSubjectNotFound is returned when the user or role to make moderator does not exist.
+	ErrModeratorSubjectNotFound = errors.New("user or role not found")
+)
@@ -0,0 +1,30 @@
+package errorsUtils
+
+import "errors"
+
+var (
+	// ErrOAuthProviderUnknown is returned when a login provider is not configured.
+	ErrOAuthProviderUnknown = errors.New("this login provider is not configured")
+
+	// ErrOAuthStateInvalid is returned when the state sent back by a provider was not issued here, has expired or was already used.
+	ErrOAuthStateInvalid = errors.New("the login has expired or was not started here, try again")
+
+	// ErrOAuthLoginFailed is returned when the provider refuses the code or does not return the profile of the user.
+	ErrOAuthLoginFailed = errors.New("the login with the provider failed, try again")
+
+	// ErrOAuthEmailMissing is returned when a new user signs in with a provider that does not share a verified email address.
+	ErrOAuthEmailMissing = errors.New("the provider did not share a verified email address")
+
+	// ErrOAuthAccountExists is returned when a new provider account has the email address of an existing user,
+	// who has to log in and link it instead, so nobody takes over an account through a provider.
+	ErrOAuthAccountExists = errors.New("an account already uses this email address, log in and link the provider from it")
+
+	// ErrOAuthIdentityLinked is returned when linking a provider account that is linked to another user.
+	ErrOAuthIdentityLinked = errors.New("this provider account is already linked to another user")
+
+	// ErrOAuthProviderLinked is returned when linking a provider the user has already linked.
+	ErrOAuthProviderLinked = errors.New("you have already linked an account of this provider")
+
+	// ErrOAuthLastLoginMethod is returned when unlinking the only way a user without a password can log in.
+	ErrOAuthLastLoginMethod = errors.New("set a password before unlinking your only login method")
+)
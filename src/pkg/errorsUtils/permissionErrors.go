@@ -0,0 +1,8 @@
+package errorsUtils
+
+import "errors"
+
+var (
+	// ErrPermissionUnknown is returned when a role is given a permission that is not in the registry.
+	ErrPermissionUnknown = errors.New("unknown permission")
+)
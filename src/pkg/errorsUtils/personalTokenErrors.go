@@ -0,0 +1,21 @@
+package errorsUtils
+
+import "errors"
+
+var (
+	// ErrPersonalTokenInvalid is returned when a personal access token does not exist, was revoked or has expired.
+	ErrPersonalTokenInvalid = errors.New("the personal access token is not valid")
+
+	// ErrPersonalTokenScope is returned when a personal access token does not have the scope a request needs.
+	ErrPersonalTokenScope = errors.New("the personal access token does not have the scope needed for this request")
+
+	// ErrPersonalTokenNotAllowed is returned when a personal access token is used on a route that needs a login,
+	// such as the ones managing the account, its sessions and its tokens.
+	ErrPersonalTokenNotAllowed = errors.New("personal access tokens cannot be used for this request, log in instead")
+
+	// ErrPersonalTokenNameTaken is returned when the user already has a token with the same name.
+	ErrPersonalTokenNameTaken = errors.New("a personal access token with this name already exists")
+
+	// ErrPersonalTokenLimit is returned when the user already has as many tokens as allowed.
+	ErrPersonalTokenLimit = errors.New("too many personal access tokens, revoke one first")
+)
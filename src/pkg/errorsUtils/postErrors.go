@@ -46,6 +46,21 @@ var (
 	// ErrDatabaseConnection is returned when there is a failure connecting to the database.
 	ErrDatabaseConnection = errors.New("unable to connect to the database")
 
+	// ErrPostLocked is returned when commenting on a locked post.
+	ErrPostLocked = errors.New("the post is locked and accepts no new comments")
+
+	// ErrPostSameForum is returned when moving a post to the forum it is already in.
+	ErrPostSameForum = errors.New("the post is already in this forum")
+
+	// ErrPostForumNotFound is returned when moving a post to a forum that does not exist.
+	ErrPostForumNotFound = errors.New("the forum to move the post to does not exist")
+
+	// ErrPostIsRedirect is returned when moving or merging a redirect stub instead of the post it points to.
+	ErrPostIsRedirect = errors.New("the post is a redirect to another post, move or merge that post instead")
+
+	// ErrPostMergeSelf is returned when merging a post into itself.
+	ErrPostMergeSelf = errors.New("a post cannot be merged into itself")
+
 	// ErrPostRestorationFailed is returned when a post restoration operation fails.
 	ErrPostRestorationFailed = errors.New("failed to restore the post due to a system error")
 )
@@ -0,0 +1,20 @@
+package errorsUtils
+
+import "errors"
+
+var (
+	// ErrReportAlreadySent is returned when a user reports the same target twice while its report is open.
+	ErrReportAlreadySent = errors.New("you already reported this, it is waiting for a moderator")
+
+	// ErrReportSelf is returned when a user reports themselves or what they posted.
+	ErrReportSelf = errors.New("you cannot report yourself or what you posted")
+
+	// ErrReportResolved is returned when resolving a report that has already been resolved.
+	ErrReportResolved = errors.New("the report has already been resolved")
+
+	// ErrReportClaimed is returned when resolving a report another moderator is resolving.
+	ErrReportClaimed = errors.New("another moderator is resolving this report")
+
+	// ErrReportActionInvalid is returned when a report is resolved with an action that does not apply to its target.
+	ErrReportActionInvalid = errors.New("this action does not apply to the reported target")
+)
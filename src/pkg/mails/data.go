@@ -0,0 +1,107 @@
+package mails
+
+import "time"
+
+// Template names, each one has a Data struct that carries the values it renders.
+const (
+	TemplateWelcome       = "welcome"
+	TemplateVerifyEmail   = "verify_email"
+	TemplatePasswordReset = "password_reset"
+	TemplateMention       = "mention"
+	TemplateBanNotice     = "ban_notice"
+	TemplateWarning       = "warning"
+
+	TemplatePasswordChanged   = "password_changed"
+	TemplateEmailChange       = "email_change"
+	TemplateEmailChangeNotice = "email_change_notice"
+)
+
+// Data is the data rendered by a template, it knows the name of the template it belongs to.
+type Data interface {
+	TemplateName() string
+}
+
+// WelcomeData is rendered by the welcome email, sent once the email address of an account is verified.
+type WelcomeData struct {
+	Username string
+	Link     string
+}
+
+func (WelcomeData) TemplateName() string { return TemplateWelcome }
+
+// VerifyEmailData is rendered by the email sent to confirm the email address of an account.
+type VerifyEmailData struct {
+	Username      string
+	Link          string
+	ValidForHours int
+}
+
+func (VerifyEmailData) TemplateName() string { return TemplateVerifyEmail }
+
+// PasswordResetData is rendered by the email sent to reset a forgotten password.
+type PasswordResetData struct {
+	Username      string
+	Link          string
+	ValidForHours int
+}
+
+func (PasswordResetData) TemplateName() string { return TemplatePasswordReset }
+
+// PasswordChangedData is rendered by the email sent to a user whose password has been changed.
+// Link points to the page to reset the password, in case the change was not made by the user.
+type PasswordChangedData struct {
+	Username string
+	Link     string
+}
+
+func (PasswordChangedData) TemplateName() string { return TemplatePasswordChanged }
+
+// EmailChangeData is rendered by the email sent to the new address of a user to confirm it.
+type EmailChangeData struct {
+	Username      string
+	NewEmail      string
+	Link          string
+	ValidForHours int
+}
+
+func (EmailChangeData) TemplateName() string { return TemplateEmailChange }
+
+// EmailChangeNoticeData is rendered by the email sent to the current address of a user
+// when a change of their email address is requested.
+// Link points to the page to reset the password, in case the change was not made by the user.
+type EmailChangeNoticeData struct {
+	Username string
+	NewEmail string
+	Link     string
+}
+
+func (EmailChangeNoticeData) TemplateName() string { return TemplateEmailChangeNotice }
+
+// MentionData is rendered by the email sent when a user is mentioned in a post or comment.
+type MentionData struct {
+	Username    string
+	MentionedBy string
+	PostTitle   string
+	Excerpt     string
+	Link        string
+}
+
+func (MentionData) TemplateName() string { return TemplateMention }
+
+// BanNoticeData is rendered by the email sent to a user who has been banned.
+// A nil ExpiresAt means the ban is permanent.
+type BanNoticeData struct {
+	Username  string
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+func (BanNoticeData) TemplateName() string { return TemplateBanNotice }
+
+// WarningData is rendered by the email sent to a user warned by a moderator about what they posted.
+type WarningData struct {
+	Username string
+	Reason   string
+}
+
+func (WarningData) TemplateName() string { return TemplateWarning }
@@ -0,0 +1,114 @@
+package mails
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain-text and an HTML alternative of the same content.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Encode builds the message as a multipart/alternative MIME message sent from the given address.
+// Header values are Q-encoded when they are not plain ASCII and both parts are quoted-printable.
+// Returns an error if the sender or any recipient is not a valid address.
+func (m Message) Encode(from string, date time.Time) ([]byte, error) {
+	fromAddress, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mails: invalid sender %q: %w", from, err)
+	}
+
+	if len(m.To) == 0 {
+		return nil, fmt.Errorf("mails: message without recipients")
+	}
+	recipients := make([]string, 0, len(m.To))
+	for _, to := range m.To {
+		toAddress, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, fmt.Errorf("mails: invalid recipient %q: %w", to, err)
+		}
+		recipients = append(recipients, toAddress.String())
+	}
+
+	messageID, err := newMessageID(fromAddress.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	body := multipart.NewWriter(&buffer)
+
+	// headers are written in a fixed order, before the first part
+	headers := [][2]string{
+		{"From", fromAddress.String()},
+		{"To", strings.Join(recipients, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": body.Boundary()})},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&buffer, "%s: %s\r\n", header[0], header[1])
+	}
+	buffer.WriteString("\r\n")
+
+	// the last part is the preferred one
+	if err := writePart(body, "text/plain", m.Text); err != nil {
+		return nil, err
+	}
+	if err := writePart(body, "text/html", m.HTML); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// writePart adds a quoted-printable UTF-8 part of the given media type to a multipart body.
+func writePart(body *multipart.Writer, mediaType string, content string) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, map[string]string{"charset": "utf-8"}))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := body.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	writer := quotedprintable.NewWriter(part)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// newMessageID returns a unique Message-ID in the domain of the sender.
+func newMessageID(sender string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if _, senderDomain, found := strings.Cut(sender, "@"); found {
+		domain = senderDomain
+	}
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain), nil
+}
@@ -1 +1,159 @@
 package mails
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	textTemplate "text/template"
+)
+
+// DefaultLocale is used when a template does not exist in the locale requested.
+const DefaultLocale = "en"
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// Renderer renders the email templates. Every template lives in a directory per locale
+// as two files: <name>.txt, which defines the "subject" and "body" blocks of the plain-text part,
+// and <name>.html, the HTML part.
+//
+// The templates shipped with the binary can be overridden, or new locales added,
+// by placing files with the same layout in the override directory, e.g. <dir>/es/welcome.html.
+type Renderer struct {
+	overrideDir string
+
+	mu    sync.Mutex
+	cache map[string]*parsedTemplate
+}
+
+type parsedTemplate struct {
+	text *textTemplate.Template
+	html *htmlTemplate.Template
+}
+
+// NewRenderer returns a Renderer that looks for templates in overrideDir before the embedded ones.
+// An empty overrideDir only uses the embedded templates.
+func NewRenderer(overrideDir string) *Renderer {
+	return &Renderer{
+		overrideDir: overrideDir,
+		cache:       make(map[string]*parsedTemplate),
+	}
+}
+
+// Render renders the template of data in the given locale, falling back to the base language
+// (es-AR -> es) and then to DefaultLocale when the template does not exist in it.
+// Returns a Message with the subject and both parts filled in, the recipients are left to the caller.
+func (r *Renderer) Render(locale string, data Data) (Message, error) {
+	tmpl, err := r.lookup(locale, data.TemplateName())
+	if err != nil {
+		return Message{}, err
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// lookup returns the parsed template for the first candidate locale that has it.
+func (r *Renderer) lookup(locale string, name string) (*parsedTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, candidate := range candidateLocales(locale) {
+		key := candidate + "/" + name
+		if tmpl, ok := r.cache[key]; ok {
+			return tmpl, nil
+		}
+
+		textSource, err := r.readFile(candidate, name+".txt")
+		if err != nil {
+			continue
+		}
+		htmlSource, err := r.readFile(candidate, name+".html")
+		if err != nil {
+			continue
+		}
+
+		textTmpl, err := textTemplate.New(name).Parse(string(textSource))
+		if err != nil {
+			return nil, fmt.Errorf("mails: parsing %s.txt: %w", key, err)
+		}
+		htmlTmpl, err := htmlTemplate.New(name).Parse(string(htmlSource))
+		if err != nil {
+			return nil, fmt.Errorf("mails: parsing %s.html: %w", key, err)
+		}
+
+		tmpl := &parsedTemplate{text: textTmpl, html: htmlTmpl}
+		r.cache[key] = tmpl
+		return tmpl, nil
+	}
+
+	return nil, fmt.Errorf("mails: template %q not found", name)
+}
+
+// readFile reads a template file from the override directory, or from the embedded templates if it is not there.
+func (r *Renderer) readFile(locale string, file string) ([]byte, error) {
+	if r.overrideDir != "" {
+		content, err := os.ReadFile(path.Join(r.overrideDir, locale, file))
+		if err == nil {
+			return content, nil
+		}
+	}
+
+	return fs.ReadFile(embeddedTemplates, path.Join("templates", locale, file))
+}
+
+// candidateLocales returns the locales to try, in order, for a requested locale.
+func candidateLocales(locale string) []string {
+	locale = ParseLocale(locale)
+
+	var candidates []string
+	if locale != "" {
+		candidates = append(candidates, locale)
+		if base, _, found := strings.Cut(locale, "-"); found {
+			candidates = append(candidates, base)
+		}
+	}
+
+	return append(candidates, DefaultLocale)
+}
+
+// ParseLocale normalises a locale tag, or the first language of an Accept-Language header,
+// into a lowercase tag such as "es" or "es-ar". Returns an empty string if there is none.
+func ParseLocale(value string) string {
+	tag, _, _ := strings.Cut(value, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	tag = strings.ReplaceAll(tag, "_", "-")
+
+	// tags are only used as directory names, anything else is discarded
+	if tag == "" || len(tag) > 10 || tag == "*" {
+		return ""
+	}
+	for _, char := range tag {
+		if (char < 'a' || char > 'z') && (char < '0' || char > '9') && char != '-' {
+			return ""
+		}
+	}
+
+	return tag
+}
@@ -0,0 +1,97 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// hashPrefixLength is the number of hexadecimal characters of the SHA-1 hash used to find the range of a password,
+// as in the range queries of Pwned Passwords.
+const hashPrefixLength = 5
+
+//go:embed breached.txt
+var embeddedBreachedList string
+
+// BreachedList is an offline list of common or breached passwords.
+// Passwords are never stored in clear: the list holds their SHA-1 hashes grouped in ranges by
+// the first characters of the hash, and a password is looked up by comparing the rest of its hash
+// with the ones of its range only, the way the k-anonymity model of Pwned Passwords does over the network.
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+	size   int
+}
+
+// DefaultBreachedList returns the list of common passwords shipped with the binary.
+func DefaultBreachedList() (*BreachedList, error) {
+	return ParseBreachedList(strings.NewReader(embeddedBreachedList))
+}
+
+// LoadBreachedList reads a list from a file, see ParseBreachedList for the format.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseBreachedList(file)
+}
+
+// ParseBreachedList reads a list with a SHA-1 hash in hexadecimal per line, optionally followed by ":<count>",
+// which is the format of the Pwned Passwords downloads. Empty lines and lines starting with "#" are ignored.
+func ParseBreachedList(r io.Reader) (*BreachedList, error) {
+	list := &BreachedList{ranges: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(entry, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of the breached passwords list", line)
+		}
+
+		prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+		if list.ranges[prefix] == nil {
+			list.ranges[prefix] = make(map[string]struct{})
+		}
+		list.ranges[prefix][suffix] = struct{}{}
+		list.size++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Contains tells whether a password is in the list.
+func (list *BreachedList) Contains(password string) bool {
+	if list == nil {
+		return false
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, found := list.ranges[hash[:hashPrefixLength]][hash[hashPrefixLength:]]
+	return found
+}
+
+// Size returns the number of passwords in the list.
+func (list *BreachedList) Size() int {
+	if list == nil {
+		return 0
+	}
+	return list.size
+}
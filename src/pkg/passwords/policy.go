@@ -0,0 +1,94 @@
+// Package passwords decides which passwords the users may choose.
+package passwords
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// MaxBcryptLength is the number of bytes of a password bcrypt takes into account, the rest is ignored.
+const MaxBcryptLength = 72
+
+// Policy describes the passwords the users may choose.
+type Policy struct {
+	// MinLength is the minimum number of characters.
+	MinLength int
+
+	// MaxLength is the maximum number of characters, never more than MaxBcryptLength bytes.
+	MaxLength int
+
+	// RequireLetter, RequireLowercase, RequireUppercase, RequireDigit and RequireSymbol
+	// are the classes of characters a password must contain.
+	RequireLetter    bool
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+
+	// Breached is the list of common or breached passwords that are refused, none if nil.
+	Breached *BreachedList
+}
+
+// Violation explains why a password does not satisfy the policy.
+type Violation struct {
+	// Code identifies the rule that is not satisfied, e.g. "too_short" or "breached_password".
+	Code string
+
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Check tells whether a password satisfies the policy.
+// Returns a *Violation describing the first rule that is not satisfied, or nil.
+func (policy Policy) Check(password string) error {
+	length := len([]rune(password))
+	if length < policy.MinLength {
+		return &Violation{Code: "too_short", Message: fmt.Sprintf("must be at least %d characters long", policy.MinLength)}
+	}
+	if length > policy.maxLength() || len(password) > MaxBcryptLength {
+		return &Violation{Code: "too_long", Message: fmt.Sprintf("must be at most %d characters long", policy.maxLength())}
+	}
+
+	var hasLetter, hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+			hasLower = hasLower || unicode.IsLower(r)
+			hasUpper = hasUpper || unicode.IsUpper(r)
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case policy.RequireLetter && !hasLetter:
+		return &Violation{Code: "missing_letter", Message: "must contain a letter"}
+	case policy.RequireLowercase && !hasLower:
+		return &Violation{Code: "missing_lowercase", Message: "must contain a lowercase letter"}
+	case policy.RequireUppercase && !hasUpper:
+		return &Violation{Code: "missing_uppercase", Message: "must contain an uppercase letter"}
+	case policy.RequireDigit && !hasDigit:
+		return &Violation{Code: "missing_digit", Message: "must contain a digit"}
+	case policy.RequireSymbol && !hasSymbol:
+		return &Violation{Code: "missing_symbol", Message: "must contain a symbol"}
+	}
+
+	if policy.Breached.Contains(password) {
+		return &Violation{Code: "breached_password", Message: "is too common or has appeared in a data breach, choose another one"}
+	}
+
+	return nil
+}
+
+func (policy Policy) maxLength() int {
+	if policy.MaxLength <= 0 || policy.MaxLength > MaxBcryptLength {
+		return MaxBcryptLength
+	}
+	return policy.MaxLength
+}
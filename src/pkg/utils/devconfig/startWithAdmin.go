@@ -10,6 +10,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// defaultAdminPassword is the well-known password of the seeded administrator, which has to be changed on the first login.
+const defaultAdminPassword = "admin"
+
 func StartWithAdmin(db *gorm.DB) error {
 	var adminRole models.RoleEntity
 	result := db.Where("role_type = ?", "administrator").First(&adminRole)
@@ -17,26 +20,28 @@ func StartWithAdmin(db *gorm.DB) error {
 		return result.Error
 	}
 
-	passwordHashed, err := security.HashPassword("admin")
+	passwordHashed, err := security.HashPassword(defaultAdminPassword)
 	if err != nil {
 		return err
 	}
 
 	defaultUser := models.UserEntity{
-		Username: "administrator",
-		Email:    "administrator@dialosoft.com",
-		Password: passwordHashed,
-		Name:     "Administrator",
-		RoleID:   adminRole.ID,
-		Banned:   false,
-		Role:     adminRole,
+		Username:           "administrator",
+		Email:              "administrator@dialosoft.com",
+		Password:           passwordHashed,
+		MustChangePassword: true,
+		Name:               "Administrator",
+		RoleID:             adminRole.ID,
+		Banned:             false,
+		Role:               adminRole,
+		EmailVerified:      true,
 	}
 
 	result = db.Create(&defaultUser)
 	if result.Error != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(result.Error.Error(), "duplicate key value violates unique constraint") {
 			fmt.Println("default User already exists")
-			return nil
+			return flagDefaultAdminPassword(db)
 		}
 		return result.Error
 	}
@@ -44,3 +49,22 @@ func StartWithAdmin(db *gorm.DB) error {
 	fmt.Println("default User created by id: " + defaultUser.ID.String())
 	return nil
 }
+
+// flagDefaultAdminPassword requires the seeded administrator to change their password
+// if it is still the default one, for databases seeded before the flag existed.
+func flagDefaultAdminPassword(db *gorm.DB) error {
+	var admin models.UserEntity
+	if err := db.Where("username = ?", "administrator").First(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if admin.MustChangePassword || !security.CheckPasswordHash(defaultAdminPassword, admin.Password) {
+		return nil
+	}
+
+	fmt.Println("default User still has the default password, it must be changed on the next login")
+	return db.Model(&models.UserEntity{}).Where("id = ?", admin.ID).Update("must_change_password", true).Error
+}
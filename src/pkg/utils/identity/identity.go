@@ -0,0 +1,60 @@
+// Package identity normalises the identifiers users log in with, so that two spellings
+// a person would read as the same name, such as "Alice", "alice" or "ａｌｉｃｅ", map to the same account.
+package identity
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// reservedUsernames cannot be taken on registration, they could be mistaken for the staff or the system.
+// They are compared once normalised.
+var reservedUsernames = map[string]struct{}{
+	"admin":         {},
+	"administrator": {},
+	"anonymous":     {},
+	"api":           {},
+	"deleted":       {},
+	"dialosoft":     {},
+	"help":          {},
+	"mod":           {},
+	"moderator":     {},
+	"null":          {},
+	"official":      {},
+	"root":          {},
+	"security":      {},
+	"staff":         {},
+	"support":       {},
+	"system":        {},
+}
+
+// NormalizeUsername returns the canonical form of a username: NFKC normalised and case folded.
+// Two usernames with the same canonical form belong to the same account.
+func NormalizeUsername(username string) string {
+	return normalize(username)
+}
+
+// NormalizeEmail returns the canonical form of an email address: NFKC normalised and case folded.
+func NormalizeEmail(email string) string {
+	return normalize(email)
+}
+
+// IsEmail tells whether a login identifier is an email address rather than a username.
+func IsEmail(identifier string) bool {
+	return strings.Contains(identifier, "@")
+}
+
+// IsReservedUsername tells whether a username is one that users cannot register.
+func IsReservedUsername(username string) bool {
+	_, reserved := reservedUsernames[NormalizeUsername(username)]
+	return reserved
+}
+
+func normalize(value string) string {
+	// NFKC first so compatibility characters (full width letters, ligatures...) fold like their plain forms,
+	// and again after folding, since folding may produce unnormalised sequences
+	// a Caser keeps state, so one is created for each call instead of being shared
+	return norm.NFKC.String(cases.Fold().String(norm.NFKC.String(strings.TrimSpace(value))))
+}
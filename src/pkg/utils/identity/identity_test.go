@@ -0,0 +1,49 @@
+package identity
+
+import "testing"
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"Alice", "alice", true},
+		{"ＡＬＩＣＥ", "alice", true},
+		{"  alice ", "alice", true},
+		{"Straße", "strasse", true},
+		{"ﬁle", "file", true},
+		{"alice", "alicia", false},
+	}
+
+	for _, test := range tests {
+		if same := NormalizeUsername(test.a) == NormalizeUsername(test.b); same != test.same {
+			t.Errorf("NormalizeUsername(%q) == NormalizeUsername(%q) is %v, want %v", test.a, test.b, same, test.same)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	if got := NormalizeEmail("Alice@Example.COM"); got != "alice@example.com" {
+		t.Errorf("NormalizeEmail = %q, want %q", got, "alice@example.com")
+	}
+}
+
+func TestIsReservedUsername(t *testing.T) {
+	for _, username := range []string{"admin", "Admin", "ＡＤＭＩＮ", " root "} {
+		if !IsReservedUsername(username) {
+			t.Errorf("IsReservedUsername(%q) = false, want true", username)
+		}
+	}
+	if IsReservedUsername("alice") {
+		t.Error(`IsReservedUsername("alice") = true, want false`)
+	}
+}
+
+func TestIsEmail(t *testing.T) {
+	if !IsEmail("alice@example.com") {
+		t.Error(`IsEmail("alice@example.com") = false, want true`)
+	}
+	if IsEmail("alice") {
+		t.Error(`IsEmail("alice") = true, want false`)
+	}
+}
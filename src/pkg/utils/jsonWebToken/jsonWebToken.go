@@ -9,20 +9,35 @@ import (
 	"github.com/google/uuid"
 )
 
-// GenerateAccessJWT generates a signed JWT access token using the given secret key.
-// It includes claims such as the user ID, role ID, expiration time (5 minutes), and issued at time.
+const (
+	// AccessTokenDuration is the lifetime of an access token.
+	AccessTokenDuration = time.Minute * 5
+
+	// RefreshTokenDuration is the lifetime of a refresh token (30 days).
+	RefreshTokenDuration = time.Hour * 720
+
+	// MFATokenDuration is the lifetime of the token given by a login that still needs a second factor.
+	MFATokenDuration = time.Minute * 5
+
+	// mfaTokenType is the typ claim of the tokens pending a second factor.
+	mfaTokenType = "mfa"
+)
+
+// GenerateAccessJWT generates a JWT access token signed with the current key of the KeySet.
+// It includes claims such as the user ID, role ID, the session (refresh token) it was issued for,
+// expiration time (5 minutes), and issued at time.
 // Returns the signed JWT as a string or an error if the signing process fails.
-func GenerateAccessJWT(secretKey string, id uuid.UUID, roleID uuid.UUID) (string, error) {
+func GenerateAccessJWT(keys *KeySet, id uuid.UUID, roleID uuid.UUID, sessionID uuid.UUID) (string, error) {
 	claims := jwt.MapClaims{
 		"iss": "dialosoft-api",
 		"sub": id.String(),
 		"rid": roleID.String(),
-		"exp": jwt.NewNumericDate(time.Now().Add(time.Minute * 5)).Unix(),
+		"sid": sessionID.String(),
+		"exp": jwt.NewNumericDate(time.Now().Add(AccessTokenDuration)).Unix(),
 		"iat": jwt.NewNumericDate(time.Now()).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(secretKey))
+	signedToken, err := keys.Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -31,20 +46,19 @@ func GenerateAccessJWT(secretKey string, id uuid.UUID, roleID uuid.UUID) (string
 }
 
 // GenerateRefreshToken generates a refresh token and returns the token along with a TokenEntity.
-// The refresh token has an expiration time of 720 hours (30 days) and is signed using the provided secret key.
+// The refresh token has an expiration time of 720 hours (30 days) and is signed with the current key of the KeySet.
 // Returns the signed refresh token, a TokenEntity containing metadata, or an error if token creation fails.
-func GenerateRefreshToken(secretKey string, userID uuid.UUID) (string, models.TokenEntity, error) {
+func GenerateRefreshToken(keys *KeySet, userID uuid.UUID) (string, models.TokenEntity, error) {
 	tokenID := uuid.New()
 	claims := jwt.MapClaims{
 		"iss": "dialosoft-api",
 		"sub": userID.String(),
-		"exp": jwt.NewNumericDate(time.Now().Add(time.Hour * 720)),
+		"exp": jwt.NewNumericDate(time.Now().Add(RefreshTokenDuration)),
 		"iat": jwt.NewNumericDate(time.Now()),
 		"jti": tokenID.String(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	refreshToken, err := token.SignedString([]byte(secretKey))
+	refreshToken, err := keys.Sign(claims)
 	if err != nil {
 		return "", models.TokenEntity{}, err
 	}
@@ -53,23 +67,63 @@ func GenerateRefreshToken(secretKey string, userID uuid.UUID) (string, models.To
 		Token:     refreshToken,
 		ID:        tokenID,
 		UserID:    userID,
-		ExpiresAt: time.Now().Add(time.Hour * 720),
+		ExpiresAt: time.Now().Add(RefreshTokenDuration),
 		CreatedAt: time.Now(),
 	}
 
 	return refreshToken, tokenEntity, nil
 }
 
-// ValidateJWT validates the given JWT token string using the provided secret key.
-// It checks if the token's signing method is HMAC and verifies the token's expiration time.
+// GenerateMFAJWT generates a signed JWT proving that a user has given a valid password but still has to
+// give a second factor. It carries no role, so it is never accepted as an access token.
+// When enroll is true the user has no authenticator yet and has to set one up before logging in.
+// Returns the signed JWT as a string or an error if the signing process fails.
+func GenerateMFAJWT(keys *KeySet, userID uuid.UUID, enroll bool) (string, error) {
+	claims := jwt.MapClaims{
+		"iss": "dialosoft-api",
+		"sub": userID.String(),
+		"typ": mfaTokenType,
+		"enr": enroll,
+		"exp": jwt.NewNumericDate(time.Now().Add(MFATokenDuration)).Unix(),
+		"iat": jwt.NewNumericDate(time.Now()).Unix(),
+	}
+
+	return keys.Sign(claims)
+}
+
+// ValidateMFAJWT validates a token generated by GenerateMFAJWT.
+// Returns the ID of the user and whether the user has to enroll an authenticator,
+// or an error if the token is invalid, expired or of another kind.
+func ValidateMFAJWT(tokenString string, keys *KeySet) (uuid.UUID, bool, error) {
+	claims, err := ValidateJWT(tokenString, keys)
+	if err != nil {
+		return uuid.UUID{}, false, err
+	}
+
+	if typ, _ := claims["typ"].(string); typ != mfaTokenType {
+		return uuid.UUID{}, false, fmt.Errorf("not an mfa token")
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return uuid.UUID{}, false, err
+	}
+
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.UUID{}, false, err
+	}
+
+	enroll, _ := claims["enr"].(bool)
+	return userID, enroll, nil
+}
+
+// ValidateJWT validates the given JWT token string with the keys of the KeySet.
+// It checks that the token is signed by one of the keys still valid, with the algorithm of that key,
+// and verifies the token's expiration time.
 // Returns the token claims if valid, or an error if the token is invalid or expired.
-func ValidateJWT(tokenString, secretKey string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secretKey), nil
-	})
+func ValidateJWT(tokenString string, keys *KeySet) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, keys.keyFunc, jwt.WithValidMethods(keys.validMethods()))
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,429 @@
+package jsonWebToken
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dialosoft/src/pkg/utils/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms the keys of a key directory can sign with.
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+)
+
+// rsaKeyBits is the size of the RSA keys generated for RS256.
+const rsaKeyBits = 3072
+
+// KeySetConfig holds the settings of a KeySet.
+type KeySetConfig struct {
+	// Dir is the directory of the signing keys, one PEM file per key named <kid>.pem.
+	// Private keys can sign, public keys ("PUBLIC KEY") only verify. When empty, tokens are
+	// signed with HS256 and LegacySecret, as before key directories existed.
+	Dir string
+
+	// Algorithm is the algorithm of the keys generated in Dir, AlgorithmEdDSA (default) or AlgorithmRS256.
+	Algorithm string
+
+	// GracePeriod is how long a key still verifies tokens once a newer key has replaced it.
+	// It should not be shorter than RefreshTokenDuration, or the sessions opened before the rotation end.
+	GracePeriod time.Duration
+
+	// RotateAfter is the age after which a new key is generated in Dir, zero never rotates automatically.
+	RotateAfter time.Duration
+
+	// LegacySecret is the HS256 secret of the tokens issued without a key directory.
+	LegacySecret string
+
+	// AcceptLegacy keeps accepting the HS256 tokens signed with LegacySecret when Dir is set,
+	// so the sessions opened before the key directory was introduced are not lost.
+	AcceptLegacy bool
+}
+
+// signingKey is a key of the key directory.
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+
+	// validUntil is when the key stops verifying tokens, zero while it is the newest key.
+	validUntil time.Time
+}
+
+// KeySet signs and verifies the tokens with the keys of a key directory.
+// The newest private key signs, the header of the tokens carries its ID in the kid claim,
+// and the keys it replaced keep verifying tokens during the grace period.
+type KeySet struct {
+	config KeySetConfig
+
+	mu      sync.RWMutex
+	current *signingKey
+	keys    map[string]*signingKey
+}
+
+// NewKeySet returns the KeySet described by the config, loading the keys of its directory.
+// A key is generated if the directory has none.
+func NewKeySet(config KeySetConfig) (*KeySet, error) {
+	if config.Dir == "" && config.LegacySecret == "" {
+		return nil, errors.New("a key directory or a secret is needed to sign the tokens")
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = AlgorithmEdDSA
+	}
+	if config.Algorithm != AlgorithmEdDSA && config.Algorithm != AlgorithmRS256 {
+		return nil, fmt.Errorf("unknown signing algorithm %q", config.Algorithm)
+	}
+
+	keySet := &KeySet{config: config}
+	if config.Dir == "" {
+		return keySet, nil
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := keySet.Reload(); err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Reload reads the key directory again, rotating the signing key if it is older than RotateAfter.
+// Other instances sharing the directory pick up a rotation on their next reload.
+func (keySet *KeySet) Reload() error {
+	if keySet.config.Dir == "" {
+		return nil
+	}
+
+	keys, err := loadKeys(keySet.config.Dir)
+	if err != nil {
+		return err
+	}
+
+	current := newestPrivateKey(keys)
+	if current == nil || (keySet.config.RotateAfter > 0 && time.Since(current.createdAt) > keySet.config.RotateAfter) {
+		generated, err := generateKey(keySet.config.Dir, keySet.config.Algorithm)
+		if err != nil {
+			return fmt.Errorf("generating a signing key: %w", err)
+		}
+		logger.Info("Signing key generated", map[string]interface{}{
+			"kid":       generated.kid,
+			"algorithm": keySet.config.Algorithm,
+		})
+		keys = append(keys, generated)
+		current = generated
+	}
+
+	// every key verifies tokens until the grace period after the key that replaced it
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].createdAt.Equal(keys[j].createdAt) {
+			return keys[i].kid < keys[j].kid
+		}
+		return keys[i].createdAt.Before(keys[j].createdAt)
+	})
+
+	keySet.mu.RLock()
+	previous := keySet.keys
+	keySet.mu.RUnlock()
+
+	byKid := make(map[string]*signingKey, len(keys))
+	for i, key := range keys {
+		if i+1 < len(keys) && key != current {
+			key.validUntil = keys[i+1].createdAt.Add(keySet.config.GracePeriod)
+			if time.Now().After(key.validUntil) {
+				if _, retiredNow := previous[key.kid]; retiredNow || previous == nil {
+					logger.Warn("Signing key past its grace period, it no longer verifies tokens and can be removed", map[string]interface{}{
+						"kid": key.kid,
+					})
+				}
+				continue
+			}
+		}
+		byKid[key.kid] = key
+	}
+
+	keySet.mu.Lock()
+	keySet.current = current
+	keySet.keys = byKid
+	keySet.mu.Unlock()
+
+	return nil
+}
+
+// StartReloader reloads the key directory every interval until the context is done.
+func (keySet *KeySet) StartReloader(ctx context.Context, interval time.Duration) {
+	if keySet.config.Dir == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := keySet.Reload(); err != nil {
+				logger.CaptureError(err, "Failed to reload the signing keys", map[string]interface{}{
+					"dir": keySet.config.Dir,
+				})
+			}
+		}
+	}
+}
+
+// Sign signs the claims with the current key.
+func (keySet *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if keySet.config.Dir == "" {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(keySet.config.LegacySecret))
+	}
+
+	keySet.mu.RLock()
+	current := keySet.current
+	keySet.mu.RUnlock()
+
+	token := jwt.NewWithClaims(current.method, claims)
+	token.Header["kid"] = current.kid
+	return token.SignedString(current.private)
+}
+
+// keyFunc returns the key verifying a token, found by its kid.
+func (keySet *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && keySet.acceptsLegacy() {
+			return []byte(keySet.config.LegacySecret), nil
+		}
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	keySet.mu.RLock()
+	key, ok := keySet.keys[kid]
+	keySet.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	if !key.validUntil.IsZero() && time.Now().After(key.validUntil) {
+		return nil, fmt.Errorf("the signing key %q has been retired", kid)
+	}
+
+	return key.public, nil
+}
+
+func (keySet *KeySet) acceptsLegacy() bool {
+	return keySet.config.LegacySecret != "" && (keySet.config.Dir == "" || keySet.config.AcceptLegacy)
+}
+
+// validMethods returns the algorithms of the tokens the KeySet may verify.
+func (keySet *KeySet) validMethods() []string {
+	methods := []string{AlgorithmEdDSA, AlgorithmRS256}
+	if keySet.acceptsLegacy() {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	return methods
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// N and E are the modulus and the exponent of an RSA key.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Crv and X are the curve and the public key of an Ed25519 key.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a set of public keys in the JSON Web Key Set format, as served on /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys verifying the tokens, so other services can verify them without being able to sign.
+// It is empty when the tokens are signed with an HS256 secret.
+func (keySet *KeySet) JWKS() JWKS {
+	keySet.mu.RLock()
+	defer keySet.mu.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range keySet.keys {
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// loadKeys reads the PEM files of a key directory.
+func loadKeys(dir string) ([]*signingKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*signingKey, 0, len(paths))
+	for _, path := range paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading the signing key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// loadKey reads a PEM file holding a PKCS#8 or PKCS#1 private key, or a PKIX public key.
+// The file name without its extension is the ID of the key, its modification time when it was created.
+func loadKey(path string) (*signingKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	key := &signingKey{
+		kid:       strings.TrimSuffix(filepath.Base(path), ".pem"),
+		createdAt: info.ModTime(),
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must have at least 2048 bits")
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	key.public = parsed
+
+	return key, nil
+}
+
+// newestPrivateKey returns the most recent key that can sign, or nil if there is none.
+func newestPrivateKey(keys []*signingKey) *signingKey {
+	var newest *signingKey
+	for _, key := range keys {
+		if key.private == nil {
+			continue
+		}
+		if newest == nil || key.createdAt.After(newest.createdAt) ||
+			(key.createdAt.Equal(newest.createdAt) && key.kid > newest.kid) {
+			newest = key
+		}
+	}
+	return newest
+}
+
+// generateKey creates a key for the algorithm and writes it to the key directory.
+func generateKey(dir string, algorithm string) (*signingKey, error) {
+	var private crypto.Signer
+	var method jwt.SigningMethod
+	var err error
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		method = jwt.SigningMethodRS256
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+		method = jwt.SigningMethodEdDSA
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	kid := now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+
+	// written under a temporary name so other instances never read half a key
+	path := filepath.Join(dir, kid+".pem")
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		return nil, err
+	}
+
+	return &signingKey{kid: kid, method: method, private: private, public: private.Public(), createdAt: now}, nil
+}
@@ -0,0 +1,161 @@
+package jsonWebToken
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newTestKeySet(t *testing.T, config KeySetConfig) *KeySet {
+	t.Helper()
+	if config.Dir == "" {
+		config.Dir = t.TempDir()
+	}
+	keys, err := NewKeySet(config)
+	if err != nil {
+		t.Fatalf("NewKeySet() = %v", err)
+	}
+	return keys
+}
+
+// ageKeys makes the keys of dir look created age ago.
+func ageKeys(t *testing.T, dir string, age time.Duration) {
+	t.Helper()
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	for _, path := range paths {
+		past := time.Now().Add(-age)
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKeySetSignsAndVerifies(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmEdDSA, AlgorithmRS256} {
+		t.Run(algorithm, func(t *testing.T) {
+			keys := newTestKeySet(t, KeySetConfig{Algorithm: algorithm})
+
+			userID := uuid.New()
+			token, err := GenerateAccessJWT(keys, userID, uuid.New(), uuid.New())
+			if err != nil {
+				t.Fatalf("GenerateAccessJWT() = %v", err)
+			}
+
+			claims, err := ValidateJWT(token, keys)
+			if err != nil {
+				t.Fatalf("ValidateJWT() = %v", err)
+			}
+			if claims["sub"] != userID.String() {
+				t.Errorf("sub = %v, want %v", claims["sub"], userID)
+			}
+
+			jwks := keys.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Alg != algorithm {
+				t.Errorf("JWKS() = %+v, want one %s key", jwks, algorithm)
+			}
+		})
+	}
+}
+
+func TestKeySetRejectsTokensOfOtherKeys(t *testing.T) {
+	keys := newTestKeySet(t, KeySetConfig{})
+	other := newTestKeySet(t, KeySetConfig{})
+
+	token, err := GenerateAccessJWT(other, uuid.New(), uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateJWT(token, keys); err == nil {
+		t.Error("ValidateJWT() accepted a token signed by another key directory")
+	}
+}
+
+func TestKeySetRotationGracePeriod(t *testing.T) {
+	tests := []struct {
+		name        string
+		gracePeriod time.Duration
+		valid       bool
+	}{
+		{"within the grace period", time.Hour, true},
+		{"past the grace period", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			keys := newTestKeySet(t, KeySetConfig{Dir: dir, RotateAfter: time.Hour, GracePeriod: test.gracePeriod})
+
+			token, err := GenerateAccessJWT(keys, uuid.New(), uuid.New(), uuid.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ageKeys(t, dir, 2*time.Hour)
+			if err := keys.Reload(); err != nil {
+				t.Fatalf("Reload() = %v", err)
+			}
+
+			rotated, err := GenerateAccessJWT(keys, uuid.New(), uuid.New(), uuid.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ValidateJWT(rotated, keys); err != nil {
+				t.Fatalf("ValidateJWT() of a token of the new key = %v", err)
+			}
+
+			_, err = ValidateJWT(token, keys)
+			if valid := err == nil; valid != test.valid {
+				t.Errorf("token of the replaced key valid = %v, want %v (err %v)", valid, test.valid, err)
+			}
+		})
+	}
+}
+
+func TestKeySetLegacyTokens(t *testing.T) {
+	const secret = "legacy-secret"
+	legacy, err := NewKeySet(KeySetConfig{LegacySecret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := GenerateAccessJWT(legacy, uuid.New(), uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, acceptLegacy := range []bool{true, false} {
+		keys := newTestKeySet(t, KeySetConfig{LegacySecret: secret, AcceptLegacy: acceptLegacy})
+		_, err := ValidateJWT(token, keys)
+		if accepted := err == nil; accepted != acceptLegacy {
+			t.Errorf("AcceptLegacy %v: legacy token accepted = %v (err %v)", acceptLegacy, accepted, err)
+		}
+	}
+}
+
+func TestKeySetRejectsUnsignedTokens(t *testing.T) {
+	keys := newTestKeySet(t, KeySetConfig{})
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": uuid.New().String()}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateJWT(token, keys); err == nil {
+		t.Error("ValidateJWT() accepted an unsigned token")
+	}
+}
+
+func TestValidateMFAJWTRejectsAccessTokens(t *testing.T) {
+	keys := newTestKeySet(t, KeySetConfig{})
+
+	token, err := GenerateAccessJWT(keys, uuid.New(), uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ValidateMFAJWT(token, keys); err == nil {
+		t.Error("ValidateMFAJWT() accepted an access token")
+	}
+}
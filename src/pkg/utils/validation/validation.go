@@ -0,0 +1,171 @@
+// Package validation checks the request bodies against their `validate` struct tags
+// and describes each failing field with a machine-readable code.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Dialosoft/src/pkg/passwords"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why a field of a request is not valid.
+type FieldError struct {
+	// Field is the JSON name of the field, with the index for the elements of a list (e.g. "rolesAllowed[1]").
+	Field string `json:"field"`
+
+	// Code identifies the failed rule, e.g. "required", "too_short" or "invalid_email".
+	Code string `json:"code"`
+
+	// Param is the parameter of the rule, such as the minimum length, if it has one.
+	Param string `json:"param,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// Errors is the list of the fields of a request that are not valid.
+type Errors []FieldError
+
+func (errs Errors) Error() string {
+	fields := make([]string, 0, len(errs))
+	for _, err := range errs {
+		fields = append(fields, err.Field+": "+err.Code)
+	}
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// FieldErrorsOf returns the field errors wrapped by err, if it is a validation error.
+func FieldErrorsOf(err error) (Errors, bool) {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return errs, true
+	}
+	return nil, false
+}
+
+// Required returns the error of a missing field, for the rules that cannot be expressed with tags.
+func Required(field string) FieldError {
+	return FieldError{Field: field, Code: "required", Message: "this field is required"}
+}
+
+// PasswordViolation returns the error of a password field that does not satisfy the policy,
+// for the passwords that are checked after the binding.
+func PasswordViolation(field string, violation *passwords.Violation) FieldError {
+	if violation.Code == "breached_password" {
+		return FieldError{Field: field, Code: violation.Code, Message: violation.Message}
+	}
+	return FieldError{Field: field, Code: "weak_password", Param: violation.Code, Message: violation.Message}
+}
+
+// StructValidator validates the structs bound by fiber, see fiber.Config.StructValidator.
+type StructValidator struct {
+	validate       *validator.Validate
+	passwordPolicy passwords.Policy
+}
+
+// NewStructValidator returns a StructValidator with the custom rules of the application:
+//
+//	username   3 to 32 letters, digits, dots, dashes or underscores
+//	password   satisfies the password policy
+func NewStructValidator(passwordPolicy passwords.Policy) *StructValidator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	// report the names clients know, the ones of the JSON body
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	validate.RegisterValidation("username", validateUsername)
+	validate.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return passwordPolicy.Check(fl.Field().String()) == nil
+	})
+
+	return &StructValidator{validate: validate, passwordPolicy: passwordPolicy}
+}
+
+// Validate implements fiber.StructValidator.
+// Returns Errors listing every field that is not valid, or nil.
+func (sv *StructValidator) Validate(out any) error {
+	err := sv.validate.Struct(out)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	errs := make(Errors, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		errs = append(errs, sv.newFieldError(fieldError))
+	}
+
+	return errs
+}
+
+var usernamePattern = regexp.MustCompile(`^[\p{L}\p{N}._-]{3,32}$`)
+
+func validateUsername(fl validator.FieldLevel) bool {
+	return usernamePattern.MatchString(fl.Field().String())
+}
+
+// newFieldError translates an error of the validator into a FieldError.
+func (sv *StructValidator) newFieldError(fieldError validator.FieldError) FieldError {
+	field := fieldError.Namespace()
+	// the namespace starts with the name of the struct, which means nothing to clients
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+
+	param := fieldError.Param()
+	isText := fieldError.Kind() == reflect.String
+
+	switch fieldError.Tag() {
+	case "required", "required_without", "required_with":
+		return FieldError{Field: field, Code: "required", Message: "this field is required"}
+	case "min", "gte":
+		if isText {
+			return FieldError{Field: field, Code: "too_short", Param: param, Message: fmt.Sprintf("must be at least %s characters long", param)}
+		}
+		return FieldError{Field: field, Code: "too_small", Param: param, Message: fmt.Sprintf("must be at least %s", param)}
+	case "max", "lte":
+		if isText {
+			return FieldError{Field: field, Code: "too_long", Param: param, Message: fmt.Sprintf("must be at most %s characters long", param)}
+		}
+		return FieldError{Field: field, Code: "too_large", Param: param, Message: fmt.Sprintf("must be at most %s", param)}
+	case "email":
+		return FieldError{Field: field, Code: "invalid_email", Message: "must be a valid email address"}
+	case "uuid", "uuid4":
+		return FieldError{Field: field, Code: "invalid_uuid", Message: "must be a valid UUID"}
+	case "username":
+		return FieldError{Field: field, Code: "invalid_username",
+			Message: "must be 3 to 32 letters, digits, dots, dashes or underscores"}
+	case "password":
+		// the policy tells which of its rules the password breaks
+		var violation *passwords.Violation
+		if errors.As(sv.passwordPolicy.Check(fmt.Sprint(fieldError.Value())), &violation) {
+			return PasswordViolation(field, violation)
+		}
+		return FieldError{Field: field, Code: "weak_password", Message: "does not satisfy the password policy"}
+	case "oneof":
+		return FieldError{Field: field, Code: "invalid_choice", Param: param, Message: "must be one of: " + param}
+	case "ip", "ipv4", "ipv6":
+		return FieldError{Field: field, Code: "invalid_ip", Message: "must be a valid IP address"}
+	case "numeric", "number":
+		return FieldError{Field: field, Code: "invalid_number", Message: "must be a number"}
+	}
+
+	return FieldError{Field: field, Code: "invalid", Param: param, Message: "is not valid"}
+}